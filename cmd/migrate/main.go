@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"pet-store/config"
+	"pet-store/internal/db"
+	migrations "pet-store/internal/infrastructure/db/migrate"
+	"pet-store/internal/infrastructure/logs"
+	"strconv"
+
+	"github.com/golang-migrate/migrate"
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+)
+
+const usage = `Usage: go run ./cmd/migrate [-source URL] <command> [arg]
+
+Commands:
+  up [N]       apply all pending migrations or the next N
+  down N       roll back the last N migrations
+  goto V       migrate up or down to version V
+  version      print the current version and whether it is dirty
+  force V      set version V without running migrations, clearing the dirty flag (-1 - no version)
+  create NAME  create empty NAME migration files under the next number for every database
+
+Connection is configured by the same DB_* variables as the server, including .env.
+`
+
+// Управление миграциями БД из DB_DRIVER вне запуска сервера. Запускается из корня репозитория,
+// где лежат .env и каталоги миграций
+func main() {
+	source := flag.String("source", "", "migrations source URL, by default the directory of DB_DRIVER")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 || flag.NArg() > 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command, arg := flag.Arg(0), flag.Arg(1)
+
+	// Файл .env необязателен: переменные могут быть заданы окружением
+	_ = godotenv.Load()
+	conf := config.NewAppConf()
+	logger := logs.NewLogger(conf, os.Stderr)
+
+	if command == "create" {
+		files, err := migrations.Create(migrations.Dirs(), arg)
+		if err != nil {
+			logger.Fatal("migrate: create error", zap.Error(err))
+		}
+		for _, file := range files {
+			fmt.Println(file)
+		}
+		return
+	}
+
+	if conf.DB.Driver == db.DriverMemory {
+		logger.Fatal("migrate: memory storage has no migrations")
+	}
+	if *source == "" {
+		*source = migrations.SourceURL(conf.DB.Driver)
+	}
+	m, err := migrations.NewMigrate(conf.DB, *source)
+	if err != nil {
+		logger.Fatal("migrate: init error", zap.Error(err))
+	}
+	defer m.Close()
+
+	switch command {
+	case "up":
+		if arg == "" {
+			err = m.Up()
+		} else {
+			err = m.Steps(parseNumber(logger, arg, 1))
+		}
+	case "down":
+		err = m.Steps(-parseNumber(logger, arg, 1))
+	case "goto":
+		err = m.Migrate(uint(parseNumber(logger, arg, 0)))
+	case "force":
+		err = m.Force(parseNumber(logger, arg, -1))
+	case "version":
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err == migrate.ErrNoChange {
+		err = nil
+		logger.Info("No change")
+	}
+	if err != nil {
+		logger.Fatal("migrate: "+command+" error", zap.Error(err))
+	}
+
+	version, dirty, err := m.Version()
+	if err == migrate.ErrNilVersion {
+		fmt.Println("no version")
+		return
+	}
+	if err != nil {
+		logger.Fatal("migrate: version error", zap.Error(err))
+	}
+	fmt.Printf("version %d, dirty %t\n", version, dirty)
+}
+
+// parseNumber - числовой аргумент команды не меньше least
+func parseNumber(logger *zap.Logger, arg string, least int) int {
+	number, err := strconv.Atoi(arg)
+	if err != nil || number < least {
+		logger.Fatal("migrate: argument must be a number", zap.String("arg", arg), zap.Int("least", least))
+	}
+
+	return number
+}
@@ -17,10 +17,22 @@ const (
 	envAccessTTL       = "ACCESS_TTL"
 	envRefreshTTL      = "REFRESH_TTL"
 	envVerifyLinkTTL   = "VERIFY_LINK_TTL"
+	envPetRetention    = "PET_RETENTION_DAYS"
+	envPetImagesDir    = "PET_IMAGES_DIR"
+	envPetImageMaxSize = "PET_IMAGE_MAX_SIZE_MB"
+	envDBAutoMigrate   = "DB_AUTO_MIGRATE"
+	envAdminUsername   = "ADMIN_USERNAME"
+
+	defaultPetRetentionDays = 30
+	defaultPetImagesDir     = "images"
+	defaultPetImageMaxSize  = 5
 
 	parseShutdownTimeoutError    = "config: parse server shutdown timeout error"
 	parseRpcShutdownTimeoutError = "config: parse rpc server shutdown timeout error"
 	parseTokenTTlError           = "config: parse token ttl error"
+	parsePetRetentionError       = "config: parse pet retention error"
+	parsePetImageMaxSizeError    = "config: parse pet image max size error"
+	parseDBAutoMigrateError      = "config: parse db auto migrate error"
 )
 
 type AppConf struct {
@@ -32,6 +44,9 @@ type AppConf struct {
 	Token           Token  `yaml:"token"`
 	Logger          Logger `yaml:"logger"`
 	DB              DB     `yaml:"db"`
+	Pet             Pet    `yaml:"pet"`
+	// AdminUsername - пользователь, которому при запуске назначается роль администратора
+	AdminUsername string `yaml:"admin_username"`
 }
 
 type Token struct {
@@ -56,6 +71,14 @@ type DB struct {
 	MaxConn  int    `yaml:"max_conn"`
 	Port     string `yaml:"port"`
 	Timeout  int    `yaml:"timeout"`
+	// AutoMigrate - применять ли не применённые миграции при запуске сервера
+	AutoMigrate bool `yaml:"auto_migrate"`
+}
+
+type Pet struct {
+	DeletedRetention time.Duration `yaml:"deleted_retention"`
+	ImagesDir        string        `yaml:"images_dir"`
+	ImageMaxSize     int64         `yaml:"image_max_size"`
 }
 
 type Logger struct {
@@ -100,6 +123,15 @@ func (a *AppConf) Init(logger *zap.Logger) {
 	a.DB.Timeout = dbTimeout
 	a.DB.MaxConn = dbMaxConn
 
+	// Миграции при запуске применяются, если не отключены явно
+	a.DB.AutoMigrate = true
+	if env := os.Getenv(envDBAutoMigrate); env != "" {
+		a.DB.AutoMigrate, err = strconv.ParseBool(env)
+		if err != nil {
+			logger.Fatal(parseDBAutoMigrateError)
+		}
+	}
+
 	var accessTTL int
 	accessTTL, err = strconv.Atoi(os.Getenv(envAccessTTL))
 	if err != nil {
@@ -114,10 +146,35 @@ func (a *AppConf) Init(logger *zap.Logger) {
 
 	a.Token.AccessSecret = os.Getenv("ACCESS_SECRET")
 	a.Token.RefreshSecret = os.Getenv("REFRESH_SECRET")
+	a.AdminUsername = os.Getenv(envAdminUsername)
 	a.Domain = os.Getenv("DOMAIN")
 	a.APIUrl = os.Getenv("API_URL")
 
 	a.Token.RefreshTTL = time.Duration(refreshTTL) * time.Hour * 24
 
 	a.Server.ShutdownTimeout = shutDownTimeout
+
+	// Срок хранения мягко удалённых питомцев, по умолчанию 30 дней
+	petRetention := defaultPetRetentionDays
+	if env := os.Getenv(envPetRetention); env != "" {
+		petRetention, err = strconv.Atoi(env)
+		if err != nil {
+			logger.Fatal(parsePetRetentionError)
+		}
+	}
+	a.Pet.DeletedRetention = time.Duration(petRetention) * time.Hour * 24
+
+	// Каталог для хранения изображений питомцев и максимальный размер изображения в мегабайтах
+	a.Pet.ImagesDir = defaultPetImagesDir
+	if env := os.Getenv(envPetImagesDir); env != "" {
+		a.Pet.ImagesDir = env
+	}
+	imageMaxSize := defaultPetImageMaxSize
+	if env := os.Getenv(envPetImageMaxSize); env != "" {
+		imageMaxSize, err = strconv.Atoi(env)
+		if err != nil {
+			logger.Fatal(parsePetImageMaxSizeError)
+		}
+	}
+	a.Pet.ImageMaxSize = int64(imageMaxSize) << 20
 }
@@ -0,0 +1,323 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+)
+
+// dialect - то, что в поддерживаемых БД записывается по-разному. Остальной SQL адаптера общий:
+// плейсхолдеры ?, которые переводятся в синтаксис драйвера через Rebind
+type dialect struct {
+	// forUpdate - блокировка выбранных строк до конца транзакции. SQLite блокирует всю БД
+	// на запись, а соединение с ней одно, поэтому там блокировка строк не нужна
+	forUpdate string
+	// now - текущее время в формате колонок с временем
+	now string
+	// returning - INSERT и UPDATE поддерживают RETURNING, без него значение возвращает LAST_INSERT_ID
+	returning bool
+	// nextVersion - новая версия питомца в UPDATE, которую вернёт returningInt
+	nextVersion string
+	// ignoreDuplicate - INSERT, который не вставляет строку с занятым уникальным ключом columns
+	// и не считает её затронутой
+	ignoreDuplicate func(insert string, columns ...string) string
+	// uniqueViolation - ошибка нарушения уникального ключа
+	uniqueViolation func(err error) bool
+	// textMatch, textRank и textHighlight - условие, релевантность и подсветка полнотекстового поиска.
+	// Каждый плейсхолдер в них - запрос, переведённый через textQuery
+	textMatch     string
+	textRank      string
+	textHighlight string
+	// textQuery - перевод запроса из синтаксиса websearch_to_tsquery в синтаксис БД
+	textQuery func(query string) string
+	// markText - подсветка совпадений в тексте, если textHighlight возвращает его без разметки
+	markText func(text, query string) string
+}
+
+var postgresDialect = dialect{
+	forUpdate:       " FOR UPDATE",
+	now:             "NOW()",
+	returning:       true,
+	nextVersion:     "version + 1",
+	ignoreDuplicate: onConflictDoNothing,
+	uniqueViolation: pqUniqueViolation,
+	textMatch:       petTextMatch,
+	textRank:        petTextRank,
+	textHighlight:   petTextHighlight,
+	textQuery:       func(query string) string { return query },
+}
+
+// В SQLite время хранится текстом в формате _time_format=sqlite драйвера, в UTC,
+// поэтому сравнение строк совпадает со сравнением моментов времени
+var sqliteDialect = dialect{
+	forUpdate:       "",
+	now:             "strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')",
+	returning:       true,
+	nextVersion:     "version + 1",
+	ignoreDuplicate: onConflictDoNothing,
+	uniqueViolation: sqliteUniqueViolation,
+	// Документ поиска лежит в FTS5-таблице pet_search с rowid = id питомца, веса колонок
+	// name, category и tags повторяют веса A, B и C в Postgres. bm25 тем меньше, чем лучше совпадение
+	textMatch: "p.id IN (SELECT rowid FROM pet_search WHERE pet_search MATCH ?)",
+	textRank:  "(SELECT -bm25(pet_search, 1.0, 0.4, 0.2) FROM pet_search WHERE pet_search MATCH ? AND rowid = p.id)",
+	textHighlight: "(SELECT TRIM(highlight(pet_search, 0, '<mark>', '</mark>') || ' ' || " +
+		"highlight(pet_search, 1, '<mark>', '</mark>') || ' ' || highlight(pet_search, 2, '<mark>', '</mark>')) " +
+		"FROM pet_search WHERE pet_search MATCH ? AND rowid = p.id)",
+	textQuery: ftsQuery,
+}
+
+// В MySQL время хранится в UTC: сессия открывается с time_zone = '+00:00'.
+// Новая версия питомца передаётся через LAST_INSERT_ID, потому что RETURNING нет
+var mysqlDialect = dialect{
+	forUpdate:       " FOR UPDATE",
+	now:             "UTC_TIMESTAMP(6)",
+	returning:       false,
+	nextVersion:     "LAST_INSERT_ID(version + 1)",
+	ignoreDuplicate: insertIgnore,
+	uniqueViolation: mysqlUniqueViolation,
+	// Документ поиска лежит в таблице pet_search с полнотекстовыми индексами по всему документу
+	// и по каждой колонке отдельно: релевантность по колонкам складывается с весами A, B и C из Postgres
+	textMatch: "p.id IN (SELECT pet_id FROM pet_search WHERE MATCH(name, category, tags) AGAINST(? IN BOOLEAN MODE))",
+	textRank: "(SELECT MATCH(s.name) AGAINST(? IN BOOLEAN MODE) + 0.4 * MATCH(s.category) AGAINST(? IN BOOLEAN MODE) + " +
+		"0.2 * MATCH(s.tags) AGAINST(? IN BOOLEAN MODE) FROM pet_search s WHERE s.pet_id = p.id)",
+	textHighlight: "(SELECT CONCAT_WS(' ', NULLIF(s.name, ''), NULLIF(s.category, ''), NULLIF(s.tags, '')) " +
+		"FROM pet_search s WHERE s.pet_id = p.id)",
+	textQuery: booleanQuery,
+	markText:  markWords,
+}
+
+// dialectFor - диалект по имени драйвера sqlx.DB
+func dialectFor(driverName string) dialect {
+	switch driverName {
+	case "sqlite":
+		return sqliteDialect
+	case "mysql":
+		return mysqlDialect
+	}
+	return postgresDialect
+}
+
+// returningInt - выполняет INSERT или UPDATE и возвращает целочисленную колонку column затронутой строки,
+// sql.ErrNoRows - если строка не затронута. Без RETURNING значение берётся из LAST_INSERT_ID: для INSERT
+// это id новой строки, а UPDATE должен присвоить колонку через LAST_INSERT_ID(...), как nextVersion
+func (d dialect) returningInt(ctx context.Context, q sqlx.ExtContext, query, column string, args ...interface{}) (int, error) {
+	if d.returning {
+		var value int
+		err := q.QueryRowxContext(ctx, q.Rebind(query+" RETURNING "+column), args...).Scan(&value)
+		return value, err
+	}
+
+	result, err := q.ExecContext(ctx, q.Rebind(query), args...)
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if rowsAffected == 0 {
+		return 0, sql.ErrNoRows
+	}
+	value, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(value), nil
+}
+
+func onConflictDoNothing(insert string, columns ...string) string {
+	return insert + " ON CONFLICT (" + strings.Join(columns, ", ") + ") DO NOTHING"
+}
+
+// insertIgnore - INSERT IGNORE вместо ON DUPLICATE KEY UPDATE без изменений: соединение с MySQL
+// считает найденные, а не изменённые строки, и такой UPDATE засчитывался бы как вставка.
+// IGNORE пропускает и другие ошибки строки, поэтому применяется только к вставкам,
+// где внешние ключи заведомо существуют
+func insertIgnore(insert string, _ ...string) string {
+	return strings.Replace(insert, "INSERT INTO", "INSERT IGNORE INTO", 1)
+}
+
+func pqUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// sqliteUniqueViolation - расширенный код SQLITE_CONSTRAINT_UNIQUE, драйвер включает расширенные коды
+func sqliteUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == 2067
+}
+
+// mysqlUniqueViolation - ER_DUP_ENTRY
+func mysqlUniqueViolation(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+// textArgs - аргументы выражения полнотекстового поиска: запрос на каждый его плейсхолдер
+func textArgs(expr, text string) []interface{} {
+	args := make([]interface{}, strings.Count(expr, "?"))
+	for i := range args {
+		args[i] = text
+	}
+	return args
+}
+
+// textGroup - группа запроса websearch_to_tsquery: фразы, которые должны встретиться все,
+// и фразы, которых быть не должно. Фраза - слова в нижнем регистре через пробел
+type textGroup struct {
+	include []string
+	exclude []string
+}
+
+// parseWebsearch - разбирает запрос websearch_to_tsquery: слова и фразы в кавычках, "-" перед словом - NOT,
+// or разделяет группы. Группа только из исключений ни в FTS5, ни в MySQL невыразима и пропускается
+func parseWebsearch(query string) []textGroup {
+	var groups []textGroup
+	var group textGroup
+	flush := func() {
+		if len(group.include) > 0 {
+			groups = append(groups, group)
+		}
+		group = textGroup{}
+	}
+	add := func(text string, not bool) {
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(words) == 0 {
+			return
+		}
+		phrase := strings.Join(words, " ")
+		if not {
+			group.exclude = append(group.exclude, phrase)
+		} else {
+			group.include = append(group.include, phrase)
+		}
+	}
+
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		switch {
+		case unicode.IsSpace(runes[i]):
+			i++
+		case runes[i] == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			add(string(runes[i+1:end]), false)
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+				end++
+			}
+			token := string(runes[i:end])
+			i = end
+			switch {
+			case strings.EqualFold(token, "or"):
+				flush()
+			case strings.HasPrefix(token, "-"):
+				add(token[1:], true)
+			default:
+				add(token, false)
+			}
+		}
+	}
+	flush()
+
+	return groups
+}
+
+// ftsQuery - переводит запрос websearch_to_tsquery в запрос FTS5: слова и фразы становятся фразами FTS5,
+// исключения - NOT, группы объединяются через OR. Пустой результат "" не находит ничего
+func ftsQuery(query string) string {
+	var groups []string
+	for _, group := range parseWebsearch(query) {
+		expr := `("` + strings.Join(group.include, `" AND "`) + `")`
+		for _, phrase := range group.exclude {
+			expr += ` NOT "` + phrase + `"`
+		}
+		groups = append(groups, expr)
+	}
+
+	if len(groups) == 0 {
+		return `""`
+	}
+	return strings.Join(groups, " OR ")
+}
+
+// booleanQuery - переводит запрос websearch_to_tsquery в запрос MATCH ... AGAINST в BOOLEAN MODE:
+// фразы группы обязательны (+), исключения запрещены (-), а группы необязательны, то есть объединяются через OR.
+// Пустой запрос не находит ничего
+func booleanQuery(query string) string {
+	var groups []string
+	for _, group := range parseWebsearch(query) {
+		terms := make([]string, 0, len(group.include)+len(group.exclude))
+		for _, phrase := range group.include {
+			terms = append(terms, `+"`+phrase+`"`)
+		}
+		for _, phrase := range group.exclude {
+			terms = append(terms, `-"`+phrase+`"`)
+		}
+		groups = append(groups, strings.Join(terms, " "))
+	}
+
+	if len(groups) == 1 {
+		return groups[0]
+	}
+	for i := range groups {
+		groups[i] = "(" + groups[i] + ")"
+	}
+	return strings.Join(groups, " ")
+}
+
+// markWords - выделяет <mark> слова текста, которые входят в искомые фразы запроса,
+// так же как ts_headline с HighlightAll в Postgres
+func markWords(text, query string) string {
+	words := make(map[string]bool)
+	for _, group := range parseWebsearch(query) {
+		for _, phrase := range group.include {
+			for _, word := range strings.Fields(phrase) {
+				words[word] = true
+			}
+		}
+	}
+
+	var b strings.Builder
+	start := -1
+	flush := func(end int) {
+		word := text[start:end]
+		if words[strings.ToLower(word)] {
+			word = "<mark>" + word + "</mark>"
+		}
+		b.WriteString(word)
+		start = -1
+	}
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			flush(i)
+		}
+		b.WriteRune(r)
+	}
+	if start >= 0 {
+		flush(len(text))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,29 @@
+package adapter
+
+import "strings"
+
+// patchSet - SET-часть UPDATE, собранная из полей JSON Merge Patch.
+// Плейсхолдеры - ?, запрос переводится в диалект БД через Rebind
+type patchSet struct {
+	columns []string
+	args    []interface{}
+}
+
+// set - добавляет присваивание column = value
+func (p *patchSet) set(column string, value interface{}) {
+	p.columns = append(p.columns, column+" = ?")
+	p.args = append(p.args, value)
+}
+
+// expr - добавляет присваивание column = expr без аргументов
+func (p *patchSet) expr(column, expr string) {
+	p.columns = append(p.columns, column+" = "+expr)
+}
+
+func (p *patchSet) empty() bool {
+	return len(p.columns) == 0
+}
+
+func (p *patchSet) sql() string {
+	return strings.Join(p.columns, ", ")
+}
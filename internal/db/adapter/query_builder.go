@@ -0,0 +1,130 @@
+package adapter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// identRe - допустимые имена таблиц и колонок, которые подставляются в запрос текстом
+var identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// queryBuilder - построитель SELECT-запросов. Значения попадают в запрос только параметрами "?":
+// срезы раскрываются для IN, а плейсхолдеры переводятся в синтаксис драйвера при сборке.
+// Текстом подставляются только имена таблиц и выражения, заданные в коде адаптера
+type queryBuilder struct {
+	columns    string
+	columnArgs []interface{}
+	from       string
+	where      []string
+	whereArgs  []interface{}
+	groupBy    string
+	having     string
+	havingArgs []interface{}
+	orderBy    string
+	limit      int
+}
+
+// newQuery - начинает запрос SELECT columns FROM from
+func newQuery(columns, from string) *queryBuilder {
+	return &queryBuilder{columns: columns, from: from}
+}
+
+// Select - заменяет список колонок, args - значения плейсхолдеров в выражениях колонок
+func (q *queryBuilder) Select(columns string, args ...interface{}) *queryBuilder {
+	q.columns = columns
+	q.columnArgs = args
+	return q
+}
+
+// Where - добавляет условие, условия объединяются через AND
+func (q *queryBuilder) Where(cond string, args ...interface{}) *queryBuilder {
+	q.where = append(q.where, cond)
+	q.whereArgs = append(q.whereArgs, args...)
+	return q
+}
+
+// WhereIn - условие column IN (values...), values не должен быть пустым
+func (q *queryBuilder) WhereIn(column string, values interface{}) *queryBuilder {
+	return q.Where(column+" IN (?)", values)
+}
+
+// WhereSubquery - условие column IN (подзапрос)
+func (q *queryBuilder) WhereSubquery(column string, sub *queryBuilder) *queryBuilder {
+	query, args := sub.sql()
+	return q.Where(column+" IN ("+query+")", args...)
+}
+
+func (q *queryBuilder) GroupBy(groupBy string) *queryBuilder {
+	q.groupBy = groupBy
+	return q
+}
+
+func (q *queryBuilder) Having(cond string, args ...interface{}) *queryBuilder {
+	q.having = cond
+	q.havingArgs = args
+	return q
+}
+
+func (q *queryBuilder) OrderBy(orderBy string) *queryBuilder {
+	q.orderBy = orderBy
+	return q
+}
+
+// Limit - ограничение числа строк, 0 - без ограничения
+func (q *queryBuilder) Limit(limit int) *queryBuilder {
+	q.limit = limit
+	return q
+}
+
+// sql - текст запроса с плейсхолдерами "?" и аргументы в порядке их следования
+func (q *queryBuilder) sql() (string, []interface{}) {
+	var b strings.Builder
+	args := make([]interface{}, 0, len(q.columnArgs)+len(q.whereArgs)+len(q.havingArgs)+1)
+
+	b.WriteString("SELECT " + q.columns + " FROM " + q.from)
+	args = append(args, q.columnArgs...)
+	if len(q.where) > 0 {
+		b.WriteString(" WHERE " + strings.Join(q.where, " AND "))
+		args = append(args, q.whereArgs...)
+	}
+	if q.groupBy != "" {
+		b.WriteString(" GROUP BY " + q.groupBy)
+	}
+	if q.having != "" {
+		b.WriteString(" HAVING " + q.having)
+		args = append(args, q.havingArgs...)
+	}
+	if q.orderBy != "" {
+		b.WriteString(" ORDER BY " + q.orderBy)
+	}
+	if q.limit > 0 {
+		b.WriteString(" LIMIT ?")
+		args = append(args, q.limit)
+	}
+
+	return b.String(), args
+}
+
+// Build - собирает запрос для выполнения через db
+func (q *queryBuilder) Build(db *sqlx.DB) (string, []interface{}, error) {
+	query, args := q.sql()
+	query, args, err := sqlx.In(query, args...)
+	if err != nil {
+		return "", nil, fmt.Errorf("build query: %w", err)
+	}
+
+	return db.Rebind(query), args, nil
+}
+
+// checkIdent - проверяет имя таблицы или колонки перед подстановкой в текст запроса
+func checkIdent(names ...string) error {
+	for _, name := range names {
+		if !identRe.MatchString(name) {
+			return fmt.Errorf("invalid identifier %q", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package adapter
+
+import (
+	"pet-store/internal/models"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryBuilder(t *testing.T) {
+	db := sqlx.NewDb(nil, "postgres")
+	created := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	from := " FROM pet p JOIN category c ON p.category = c.id"
+
+	cases := []struct {
+		nameTest string
+		query    *queryBuilder
+		sql      string
+		args     []interface{}
+	}{
+		{
+			nameTest: "Status page",
+			query: func() *queryBuilder {
+				q := newPetQuery().WhereIn("p.status", []string{"available", "sold"})
+				applyPetPage(q, models.PetPage{
+					Sort:  models.PetSortName,
+					Limit: 11,
+					After: &models.PetCursor{ID: 5, Name: "Rex"},
+				}, petTextRank, "")
+				return q
+			}(),
+			sql: "SELECT " + petColumns + ", 0 AS text_rank, ''" + from +
+				" WHERE p.deleted_at IS NULL AND p.status IN ($1, $2) AND (COALESCE(p.name, ''), p.id) > ($3, $4)" +
+				" ORDER BY COALESCE(p.name, '') ASC, p.id ASC LIMIT $5",
+			args: []interface{}{"available", "sold", "Rex", 5, 11},
+		},
+		{
+			nameTest: "All tags with created range",
+			query: newPetQuery().
+				WhereSubquery("p.id", petsWithTags([]string{"small", "calm"}, true)).
+				Where("p.created_at >= ?", created).
+				OrderBy("p.id"),
+			sql: "SELECT " + petColumns + ", 0 AS text_rank, ''" + from +
+				" WHERE p.deleted_at IS NULL AND p.id IN (SELECT pt.pet_id FROM pet_tags pt JOIN tags t ON pt.tag_id = t.id" +
+				" WHERE t.name IN ($1, $2) GROUP BY pt.pet_id HAVING COUNT(DISTINCT t.id) = $3) AND p.created_at >= $4" +
+				" ORDER BY p.id",
+			args: []interface{}{"small", "calm", 2, created},
+		},
+		{
+			nameTest: "Text search by rank",
+			query: func() *queryBuilder {
+				q := newPetQuery().
+					Select(petColumns+", "+petTextRank+" AS text_rank, "+petTextHighlight, "fluffy cat", "fluffy cat").
+					Where(petTextMatch, "fluffy cat")
+				applyPetPage(q, models.PetPage{
+					Sort:  models.PetSortRank,
+					Desc:  true,
+					Limit: 3,
+					After: &models.PetCursor{ID: 9, Rank: 0.5},
+				}, petTextRank, "fluffy cat")
+				return q
+			}(),
+			sql: "SELECT " + petColumns + ", ts_rank(p.search_vector, websearch_to_tsquery('simple', $1)) AS text_rank," +
+				" ts_headline('simple', p.search_text, websearch_to_tsquery('simple', $2)," +
+				" 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true')" + from +
+				" WHERE p.deleted_at IS NULL AND p.search_vector @@ websearch_to_tsquery('simple', $3)" +
+				" AND (ts_rank(p.search_vector, websearch_to_tsquery('simple', $4)), p.id) < ($5, $6)" +
+				" ORDER BY text_rank DESC, p.id DESC LIMIT $7",
+			args: []interface{}{"fluffy cat", "fluffy cat", "fluffy cat", "fluffy cat", 0.5, 9, 3},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.nameTest, func(t *testing.T) {
+			t.Parallel()
+
+			query, args, err := tc.query.Build(db)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.sql, query)
+			assert.Equal(t, tc.args, args)
+		})
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, `50\% off\_sale\\`, escapeLike(`50% off_sale\`))
+}
+
+func TestCheckIdent(t *testing.T) {
+	assert.NoError(t, checkIdent(categoryTable, "name"))
+	assert.Error(t, checkIdent("category; DROP TABLE pet"))
+}
+
+func TestFTSQuery(t *testing.T) {
+	cases := map[string]string{
+		"fluffy cat":             `("fluffy" AND "cat")`,
+		`"black cat" or Dog-Toy`: `("black cat") OR ("dog toy")`,
+		"cat -black":             `("cat") NOT "black"`,
+		"-black":                 `""`,
+		"  ":                     `""`,
+		`cat or -black or "Rex"`: `("cat") OR ("rex")`,
+	}
+	for query, want := range cases {
+		assert.Equal(t, want, ftsQuery(query), query)
+	}
+}
+
+func TestBooleanQuery(t *testing.T) {
+	cases := map[string]string{
+		"fluffy cat":             `+"fluffy" +"cat"`,
+		`"black cat" or Dog-Toy`: `(+"black cat") (+"dog toy")`,
+		"cat -black":             `+"cat" -"black"`,
+		"-black":                 ``,
+	}
+	for query, want := range cases {
+		assert.Equal(t, want, booleanQuery(query), query)
+	}
+}
+
+func TestMarkWords(t *testing.T) {
+	assert.Equal(t, "<mark>Rex</mark> Dogs <mark>fluffy</mark>", markWords("Rex Dogs fluffy", `FLUFFY or "rex"`))
+	assert.Equal(t, "Tom Cats", markWords("Tom Cats", "-tom parrot"))
+}
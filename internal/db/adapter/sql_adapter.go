@@ -3,6 +3,7 @@ package adapter
 import (
 	"context"
 	"fmt"
+	myerrors "pet-store/internal/infrastructure/errors"
 	"pet-store/internal/models"
 
 	"github.com/jmoiron/sqlx"
@@ -15,23 +16,29 @@ const (
 	petTagsTable = "pet_tags"
 	ordersTable = "orders"
 	categoryTable = "category"
+	revokedTokensTable = "revoked_tokens"
+	revokedUsersTable = "revoked_users"
+	orderHistoryTable = "order_status_history"
+	petImagesTable = "pet_images"
+	petPhotosTable = "pet_photos"
 )
 
 // SQLAdapter - адаптер для работы с БД
 type SQLAdapter struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	dialect dialect
 }
 
 // NewSqlAdapter - конструктор адаптера для работы с БД
 func NewSqlAdapter(db *sqlx.DB) *SQLAdapter {
-	return &SQLAdapter{db: db}
+	return &SQLAdapter{db: db, dialect: dialectFor(db.DriverName())}
 }
 
 // Create - создание пользователя в БД
 func (s *SQLAdapter) Create(ctx context.Context, u *models.UserDTO) (int, error) {
 	var id int
 	// Проверяем, существует ли уже пользователь с таким именем
-	queryCheck := fmt.Sprintf("SELECT id FROM %s WHERE username=$1", usersTable)
+	queryCheck := s.db.Rebind(fmt.Sprintf("SELECT id FROM %s WHERE username=?", usersTable))
 
 	row := s.db.QueryRow(queryCheck, u.UserName)
 	err := row.Scan(&id)
@@ -41,8 +48,8 @@ func (s *SQLAdapter) Create(ctx context.Context, u *models.UserDTO) (int, error)
 		return 0, fmt.Errorf("user with username %s already exists", u.UserName.NullString.String)
 	}
 	// Если пользователь не существует, создаём нового
-	queryInsert := fmt.Sprintf("INSERT INTO %s (username, password, phone, email, firstname, lastname) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id", usersTable)
-	err = s.db.QueryRowContext(ctx, queryInsert, u.UserName, u.Password, u.Phone, u.Email, u.FirstName, u.LastName).Scan(&id)
+	queryInsert := fmt.Sprintf("INSERT INTO %s (username, password, phone, email, firstname, lastname) VALUES (?, ?, ?, ?, ?, ?)", usersTable)
+	id, err = s.dialect.returningInt(ctx, s.db, queryInsert, "id", u.UserName, u.Password, u.Phone, u.Email, u.FirstName, u.LastName)
 	if err != nil {
 		fmt.Println(err)
 		return 0, err
@@ -53,13 +60,13 @@ func (s *SQLAdapter) Create(ctx context.Context, u *models.UserDTO) (int, error)
 
 func (s *SQLAdapter) GetUserByEmail(ctx context.Context, user *models.UserDTO, email string) error {
 	// SQL-запрос для поиска пользователя по email
-	query := fmt.Sprintf(`SELECT id, username, email, password, firstname, lastname, phone FROM %s WHERE email = $1`, usersTable)
+	query := s.db.Rebind(fmt.Sprintf(`SELECT id, username, email, password, firstname, lastname, phone, COALESCE(status, 0) FROM %s WHERE email = ?`, usersTable))
 
 	// Выполнение запроса к базе данных
 	row := s.db.QueryRowContext(ctx, query, email)
 
 	// Маппинг результата в структуру UserDTO
-	err := row.Scan(&user.ID, &user.UserName, &user.Email, &user.Password, &user.FirstName, &user.LastName, &user.Phone)
+	err := row.Scan(&user.ID, &user.UserName, &user.Email, &user.Password, &user.FirstName, &user.LastName, &user.Phone, &user.Status)
 	if err != nil {
 		return fmt.Errorf("failed to get user by email: %w", err)
 	}
@@ -69,13 +76,13 @@ func (s *SQLAdapter) GetUserByEmail(ctx context.Context, user *models.UserDTO, e
 
 func (s *SQLAdapter) GetUserByUsername(ctx context.Context, user *models.UserDTO, username string) error {
 	// SQL-запрос для поиска пользователя по email
-	query := fmt.Sprintf(`SELECT id, username, email, password, firstname, lastname, phone FROM %s WHERE username = $1`, usersTable)
+	query := s.db.Rebind(fmt.Sprintf(`SELECT id, username, email, password, firstname, lastname, phone, COALESCE(status, 0) FROM %s WHERE username = ?`, usersTable))
 
 	// Выполнение запроса к базе данных
 	row := s.db.QueryRowContext(ctx, query, username)
 
 	// Маппинг результата в структуру UserDTO
-	err := row.Scan(&user.ID, &user.UserName, &user.Email, &user.Password, &user.FirstName, &user.LastName, &user.Phone)
+	err := row.Scan(&user.ID, &user.UserName, &user.Email, &user.Password, &user.FirstName, &user.LastName, &user.Phone, &user.Status)
 	if err != nil {
 		return fmt.Errorf("failed to get user by email: %w", err)
 	}
@@ -83,8 +90,44 @@ func (s *SQLAdapter) GetUserByUsername(ctx context.Context, user *models.UserDTO
 	return nil
 }
 
+func (s *SQLAdapter) GetUserByID(ctx context.Context, user *models.UserDTO, id int) error {
+	// SQL-запрос для поиска пользователя по id
+	query := s.db.Rebind(fmt.Sprintf(`SELECT id, username, email, password, firstname, lastname, phone, COALESCE(status, 0) FROM %s WHERE id = ?`, usersTable))
+
+	// Выполнение запроса к базе данных
+	row := s.db.QueryRowContext(ctx, query, id)
+
+	// Маппинг результата в структуру UserDTO
+	err := row.Scan(&user.ID, &user.UserName, &user.Email, &user.Password, &user.FirstName, &user.LastName, &user.Phone, &user.Status)
+	if err != nil {
+		return fmt.Errorf("failed to get user by id: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateUserRole - изменение роли пользователя, роль хранится в колонке status
+func (s *SQLAdapter) UpdateUserRole(ctx context.Context, username string, role int) error {
+	query := s.db.Rebind(fmt.Sprintf("UPDATE %s SET status = ? WHERE username = ?", usersTable))
+
+	res, err := s.db.ExecContext(ctx, query, role, username)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return myerrors.ErrUserNotFound
+	}
+	return nil
+}
+
 func (s *SQLAdapter) UpdateUser(ctx context.Context, u *models.UserDTO) error {
-	query := fmt.Sprintf("UPDATE %s SET firstname = $1, lastname = $2, phone = $3, password = $4 WHERE username = $5", usersTable)
+	query := s.db.Rebind(fmt.Sprintf("UPDATE %s SET firstname = ?, lastname = ?, phone = ?, password = ? WHERE username = ?", usersTable))
 
 	res, err := s.db.ExecContext(ctx, query, u.FirstName, u.LastName, u.Phone, u.Password, u.UserName)
 	if err != nil {
@@ -98,7 +141,45 @@ func (s *SQLAdapter) UpdateUser(ctx context.Context, u *models.UserDTO) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("no user found with the username %s", u.UserName.String)
+		return myerrors.ErrUserNotFound
+	}
+	return nil
+}
+
+// PatchUser - частично обновляет профиль пользователя: меняются только поля патча с Set,
+// поля без значения записываются как NULL
+func (s *SQLAdapter) PatchUser(ctx context.Context, patch models.UserPatch) error {
+	var set patchSet
+	if patch.FirstName.Set {
+		set.set("firstname", patch.FirstName.NullString)
+	}
+	if patch.LastName.Set {
+		set.set("lastname", patch.LastName.NullString)
+	}
+	if patch.Phone.Set {
+		set.set("phone", patch.Phone.NullString)
+	}
+	if patch.Password.Set {
+		set.set("password", patch.Password.NullString)
+	}
+	// Пустой патч ничего не меняет, но по-прежнему проверяет, что пользователь существует
+	if set.empty() {
+		set.expr("username", "username")
+	}
+
+	query := s.db.Rebind(fmt.Sprintf("UPDATE %s SET %s WHERE username = ?", usersTable, set.sql()))
+	res, err := s.db.ExecContext(ctx, query, append(set.args, patch.UserName)...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return myerrors.ErrUserNotFound
 	}
 	return nil
 }
@@ -0,0 +1,128 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	myerrors "pet-store/internal/infrastructure/errors"
+	"pet-store/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// CreateCategory - создание категории с уникальным именем
+func (s *SQLAdapter) CreateCategory(ctx context.Context, name string) (int, error) {
+	query := s.dialect.ignoreDuplicate(fmt.Sprintf(`
+	INSERT INTO %s (name) 
+	VALUES (?)`, categoryTable), "name")
+
+	id, err := s.dialect.returningInt(ctx, s.db, query, "id", name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, myerrors.ErrCategoryExists
+		}
+		return 0, fmt.Errorf("error createCategory, %v", err)
+	}
+
+	return id, nil
+}
+
+// ListCategories - список категорий с количеством питомцев в каждой
+func (s *SQLAdapter) ListCategories(ctx context.Context) ([]models.CategoryWithCount, error) {
+	query := fmt.Sprintf(`
+	SELECT c.id, c.name, COUNT(p.id) 
+	FROM %s c
+	LEFT JOIN %s p ON p.category = c.id AND p.deleted_at IS NULL
+	GROUP BY c.id, c.name
+	ORDER BY c.name`, categoryTable, petTable)
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error listCategories, %v", err)
+	}
+	defer rows.Close()
+
+	categories := []models.CategoryWithCount{}
+	for rows.Next() {
+		var category models.CategoryWithCount
+		if err := rows.Scan(&category.ID, &category.Name, &category.PetCount); err != nil {
+			return nil, fmt.Errorf("error listCategories scan, %v", err)
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// RenameCategory - переименование категории, имя должно остаться уникальным. Занятое имя
+// определяет уникальный индекс, а не предварительная проверка, которую обгоняет параллельный запрос
+func (s *SQLAdapter) RenameCategory(ctx context.Context, categoryID int, name string) error {
+	return s.WithTx(ctx, func(tx *sqlx.Tx) error {
+		query := tx.Rebind(fmt.Sprintf(`UPDATE %s SET name = ? WHERE id = ?`, categoryTable))
+		result, err := tx.ExecContext(ctx, query, name, categoryID)
+		if err != nil {
+			if s.dialect.uniqueViolation(err) {
+				return myerrors.ErrCategoryExists
+			}
+			return fmt.Errorf("error renameCategory, %v", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return myerrors.ErrCategoryNotFound
+		}
+
+		if err := s.touchPets(ctx, tx, "category = ?", categoryID); err != nil {
+			return fmt.Errorf("error renameCategory touchPets, %v", err)
+		}
+
+		return nil
+	})
+}
+
+// DeleteCategory - удаление категории. Если у категории есть питомцы (в том числе мягко удалённые),
+// они переносятся в категорию reassignTo, а при reassignTo = 0 удаление отклоняется с ErrCategoryInUse
+func (s *SQLAdapter) DeleteCategory(ctx context.Context, categoryID, reassignTo int) error {
+	return s.WithTx(ctx, func(tx *sqlx.Tx) error {
+		queryLock := tx.Rebind(fmt.Sprintf(`SELECT id FROM %s WHERE id = ?%s`, categoryTable, s.dialect.forUpdate))
+		var id int
+		if err := tx.QueryRowContext(ctx, queryLock, categoryID).Scan(&id); err != nil {
+			if err == sql.ErrNoRows {
+				return myerrors.ErrCategoryNotFound
+			}
+			return fmt.Errorf("error deleteCategory queryLock, %v", err)
+		}
+
+		if reassignTo == 0 {
+			queryCount := tx.Rebind(fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE category = ?`, petTable))
+			var pets int
+			if err := tx.QueryRowContext(ctx, queryCount, categoryID).Scan(&pets); err != nil {
+				return fmt.Errorf("error deleteCategory queryCount, %v", err)
+			}
+			if pets > 0 {
+				return myerrors.ErrCategoryInUse
+			}
+		} else {
+			// Категория, в которую переносятся питомцы, не должна исчезнуть до конца транзакции
+			if err := tx.QueryRowContext(ctx, queryLock, reassignTo).Scan(&id); err != nil {
+				if err == sql.ErrNoRows {
+					return myerrors.ErrReassignCategoryNotFound
+				}
+				return fmt.Errorf("error deleteCategory queryLock reassign, %v", err)
+			}
+			queryReassign := tx.Rebind(fmt.Sprintf(`UPDATE %s SET category = ?, version = version + 1 WHERE category = ?`, petTable))
+			if _, err := tx.ExecContext(ctx, queryReassign, reassignTo, categoryID); err != nil {
+				return fmt.Errorf("error deleteCategory queryReassign, %v", err)
+			}
+		}
+
+		query := tx.Rebind(fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, categoryTable))
+		if _, err := tx.ExecContext(ctx, query, categoryID); err != nil {
+			return fmt.Errorf("error deleteCategory, %v", err)
+		}
+
+		return nil
+	})
+}
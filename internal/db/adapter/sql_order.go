@@ -2,39 +2,83 @@ package adapter
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	myerrors "pet-store/internal/infrastructure/errors"
 	"pet-store/internal/models"
+	"strings"
 	"time"
+
+	"github.com/jmoiron/sqlx"
 )
 
 func (s *SQLAdapter) CreateOrder(ctx context.Context, order models.Order) (int, error) {
+	var orderID int
+	err := s.WithTx(ctx, func(tx *sqlx.Tx) error {
+		// Блокируем строку питомца, чтобы два заказа не могли зарезервировать его одновременно
+		queryPet := tx.Rebind(fmt.Sprintf(`
+		SELECT status 
+		FROM %s 
+		WHERE id = ? AND deleted_at IS NULL%s`, petTable, s.dialect.forUpdate))
+		var petStatus string
+		err := tx.QueryRowContext(ctx, queryPet, order.PetID).Scan(&petStatus)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return myerrors.ErrPetNotFound
+			}
+			return fmt.Errorf("error createOrder queryPet, %v", err)
+		}
+		if petStatus != models.PetStatusAvailable {
+			return myerrors.ErrPetNotAvailable
+		}
 
-	query := fmt.Sprintf(`
-	INSERT INTO %s (petid, quantity, shipdate, status, complete) 
-	VALUES($1, $2, $3, $4, $5) 
-	RETURNING id`, ordersTable)
+		queryReserve := tx.Rebind(fmt.Sprintf(`
+		UPDATE %s 
+		SET status = ?, version = version + 1 
+		WHERE id = ?`, petTable))
+		_, err = tx.ExecContext(ctx, queryReserve, models.PetStatusPending, order.PetID)
+		if err != nil {
+			return fmt.Errorf("error createOrder queryReserve, %v", err)
+		}
 
-	var orderID int
-	err := s.db.QueryRowContext(ctx, query, order.PetID, order.Quantity, order.ShipDate, order.Status, order.Complete).Scan(&orderID)
+		query := fmt.Sprintf(`
+		INSERT INTO %s (petid, user_id, quantity, shipdate, status, complete) 
+		VALUES(?, NULLIF(?, 0), ?, ?, ?, ?)`, ordersTable)
+		orderID, err = s.dialect.returningInt(ctx, tx, query, "id", order.PetID, order.UserID, order.Quantity, order.ShipDate, order.Status, order.Complete)
+		if err != nil {
+			return fmt.Errorf("error createOrder, %v", err)
+		}
+
+		// Первая запись в истории - создание заказа
+		queryHistory := tx.Rebind(fmt.Sprintf(`
+		INSERT INTO %s (order_id, from_status, to_status, changed_by) 
+		VALUES(?, NULL, ?, NULLIF(?, 0))`, orderHistoryTable))
+		_, err = tx.ExecContext(ctx, queryHistory, orderID, order.Status, order.UserID)
+		if err != nil {
+			return fmt.Errorf("error createOrder queryHistory, %v", err)
+		}
+
+		return nil
+	})
 	if err != nil {
-		return 0, fmt.Errorf("error createOrder, %v", err)
+		return 0, err
 	}
 
 	return orderID, nil
 }
 
 func (s *SQLAdapter) FindOrderByID(ctx context.Context, orderID int) (models.Order, error) {
-	query := fmt.Sprintf(`
-	SELECT id, petid, quantity, shipdate, status, complete 
+	query := s.db.Rebind(fmt.Sprintf(`
+	SELECT id, petid, COALESCE(user_id, 0), quantity, shipdate, status, complete 
 	FROM %s 
-	WHERE id = $1`, ordersTable)
+	WHERE id = ?`, ordersTable))
 
-	var id, petid, quantity int
+	var id, petid, userid, quantity int
 	var status string
 	var shipdate time.Time
 	var complete bool
 
-	err := s.db.QueryRowContext(ctx, query, orderID).Scan(&id, &petid, &quantity, &shipdate, &status, &complete)
+	err := s.db.QueryRowContext(ctx, query, orderID).Scan(&id, &petid, &userid, &quantity, &shipdate, &status, &complete)
 	if err != nil {
 		return models.Order{}, err
 	}
@@ -42,6 +86,7 @@ func (s *SQLAdapter) FindOrderByID(ctx context.Context, orderID int) (models.Ord
 	return models.Order{
 		ID:       id,
 		PetID:    petid,
+		UserID:   userid,
 		Quantity: quantity,
 		ShipDate: shipdate,
 		Status:   status,
@@ -49,25 +94,237 @@ func (s *SQLAdapter) FindOrderByID(ctx context.Context, orderID int) (models.Ord
 	}, nil
 }
 
+// DeleteOrderByID - удаляет заказ вместе с историей. Питомец незавершённого заказа
+// снова становится доступным, иначе его больше никто не сможет заказать
 func (s *SQLAdapter) DeleteOrderByID(ctx context.Context, orderID int) error {
+	return s.WithTx(ctx, func(tx *sqlx.Tx) error {
+		queryLock := tx.Rebind(fmt.Sprintf(`
+		SELECT petid, status 
+		FROM %s 
+		WHERE id = ?%s`, ordersTable, s.dialect.forUpdate))
+		var petID int
+		var status string
+		if err := tx.QueryRowContext(ctx, queryLock, orderID).Scan(&petID, &status); err != nil {
+			if err == sql.ErrNoRows {
+				return myerrors.ErrOrderNotFound
+			}
+			return fmt.Errorf("error deleteOrder queryLock, %v", err)
+		}
+
+		if models.OrderReservesPet(status) {
+			queryRelease := tx.Rebind(fmt.Sprintf(`
+			UPDATE %s 
+			SET status = ?, version = version + 1 
+			WHERE id = ? AND status = ?`, petTable))
+			_, err := tx.ExecContext(ctx, queryRelease, models.PetStatusAvailable, petID, models.PetStatusPending)
+			if err != nil {
+				return fmt.Errorf("error deleteOrder queryRelease, %v", err)
+			}
+		}
+
+		query := tx.Rebind(fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, ordersTable))
+		if _, err := tx.ExecContext(ctx, query, orderID); err != nil {
+			return fmt.Errorf("error deleteOrder, %v", err)
+		}
+
+		return nil
+	})
+}
+
+// FindOrders - выборка заказов пользователя с фильтрами по статусу и дате доставки
+func (s *SQLAdapter) FindOrders(ctx context.Context, filter models.OrderFilter) ([]models.Order, error) {
+	var params []interface{}
+	var whereClauses []string
+
+	whereClauses = append(whereClauses, "user_id = ?")
+	params = append(params, filter.UserID)
+
+	if len(filter.Statuses) != 0 {
+		placeholders := make([]string, len(filter.Statuses))
+		for i := range filter.Statuses {
+			placeholders[i] = "?"
+			params = append(params, filter.Statuses[i])
+		}
+		whereClauses = append(whereClauses, fmt.Sprintf("status IN (%s)", strings.Join(placeholders, ", ")))
+	}
+	if !filter.ShipDateFrom.IsZero() {
+		whereClauses = append(whereClauses, "shipdate >= ?")
+		params = append(params, filter.ShipDateFrom)
+	}
+	if !filter.ShipDateTo.IsZero() {
+		whereClauses = append(whereClauses, "shipdate <= ?")
+		params = append(params, filter.ShipDateTo)
+	}
+
+	query := s.db.Rebind(fmt.Sprintf(`
+	SELECT id, petid, COALESCE(user_id, 0), quantity, shipdate, status, complete 
+	FROM %s 
+	WHERE %s
+	ORDER BY id DESC
+	LIMIT ? OFFSET ?`, ordersTable, strings.Join(whereClauses, " AND ")))
+	params = append(params, filter.Limit, filter.Offset)
+
+	rows, err := s.db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	orders := make([]models.Order, 0, filter.Limit)
+	for rows.Next() {
+		var order models.Order
+		err := rows.Scan(&order.ID, &order.PetID, &order.UserID, &order.Quantity, &order.ShipDate, &order.Status, &order.Complete)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return orders, nil
+}
+
+// PetInventory - количество питомцев в каждом статусе
+func (s *SQLAdapter) PetInventory(ctx context.Context) (map[string]int, error) {
 	query := fmt.Sprintf(`
-	DELETE FROM %s 
-	WHERE id = $1`, ordersTable)
+	SELECT status, COUNT(*) 
+	FROM %s 
+	WHERE deleted_at IS NULL 
+	GROUP BY status`, petTable)
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	inventory := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		inventory[status] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return inventory, nil
+}
 
-	result, err := s.db.ExecContext(ctx, query, orderID)
+// PetInventoryByCategory - количество питомцев в каждом статусе с разбивкой по категориям
+func (s *SQLAdapter) PetInventoryByCategory(ctx context.Context) (map[string]map[string]int, error) {
+	query := fmt.Sprintf(`
+	SELECT c.name, p.status, COUNT(*) 
+	FROM %s p
+	JOIN %s c ON p.category = c.id
+	WHERE p.deleted_at IS NULL
+	GROUP BY c.name, p.status`, petTable, categoryTable)
+
+	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	inventory := make(map[string]map[string]int)
+	for rows.Next() {
+		var category, status string
+		var count int
+		if err := rows.Scan(&category, &status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if inventory[category] == nil {
+			inventory[category] = make(map[string]int)
+		}
+		inventory[category][status] = count
 	}
 
-	// Проверяем, были ли затронуты строки
-	rowsAffected, err := result.RowsAffected()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return inventory, nil
+}
+
+// UpdateOrderStatus - переводит заказ из статуса from в статус to и пишет переход в историю.
+// Если petStatus не пустой, в той же транзакции меняет статус питомца из заказа.
+// Если статус заказа успел измениться, возвращает ErrOrderStatusStale.
+func (s *SQLAdapter) UpdateOrderStatus(ctx context.Context, orderID int, from, to string, complete bool, changedBy int, petStatus string) error {
+	return s.WithTx(ctx, func(tx *sqlx.Tx) error {
+		query := tx.Rebind(fmt.Sprintf(`
+		UPDATE %s 
+		SET status = ?, complete = ? 
+		WHERE id = ? AND status = ?`, ordersTable))
+
+		result, err := tx.ExecContext(ctx, query, to, complete, orderID, from)
+		if err != nil {
+			return fmt.Errorf("error updateOrderStatus, %v", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %v", err)
+		}
+		if rowsAffected == 0 {
+			return myerrors.ErrOrderStatusStale
+		}
+
+		if petStatus != "" {
+			queryPet := tx.Rebind(fmt.Sprintf(`
+			UPDATE %s 
+			SET status = ?, version = version + 1 
+			WHERE id = (SELECT petid FROM %s WHERE id = ?)`, petTable, ordersTable))
+			_, err = tx.ExecContext(ctx, queryPet, petStatus, orderID)
+			if err != nil {
+				return fmt.Errorf("error updateOrderStatus queryPet, %v", err)
+			}
+		}
+
+		queryHistory := tx.Rebind(fmt.Sprintf(`
+		INSERT INTO %s (order_id, from_status, to_status, changed_by) 
+		VALUES(?, ?, ?, NULLIF(?, 0))`, orderHistoryTable))
+		_, err = tx.ExecContext(ctx, queryHistory, orderID, from, to, changedBy)
+		if err != nil {
+			return fmt.Errorf("error updateOrderStatus queryHistory, %v", err)
+		}
+
+		return nil
+	})
+}
+
+// FindOrderHistory - история смены статусов заказа в хронологическом порядке
+func (s *SQLAdapter) FindOrderHistory(ctx context.Context, orderID int) ([]models.OrderStatusChange, error) {
+	query := s.db.Rebind(fmt.Sprintf(`
+	SELECT id, order_id, COALESCE(from_status, ''), to_status, COALESCE(changed_by, 0), changed_at 
+	FROM %s 
+	WHERE order_id = ?
+	ORDER BY changed_at, id`, orderHistoryTable))
+
+	rows, err := s.db.QueryContext(ctx, query, orderID)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %v", err)
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var history []models.OrderStatusChange
+	for rows.Next() {
+		var change models.OrderStatusChange
+		err := rows.Scan(&change.ID, &change.OrderID, &change.FromStatus, &change.ToStatus, &change.ChangedBy, &change.ChangedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		history = append(history, change)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("no order found with ID")
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
 	}
 
-	return nil
+	return history, nil
 }
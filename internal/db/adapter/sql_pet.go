@@ -3,126 +3,213 @@ package adapter
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
 	myerrors "pet-store/internal/infrastructure/errors"
 	"pet-store/internal/models"
 	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
 )
 
-func (s *SQLAdapter) UpdatePetForm(ctx context.Context, name, status string, petID int) error {
+// UpdatePetForm - меняет имя и статус питомца и возвращает его новую версию.
+// version - ожидаемая текущая версия, 0 - любая. При несовпадении версии возвращает ErrPetVersion
+func (s *SQLAdapter) UpdatePetForm(ctx context.Context, name, status string, petID, version int) (int, error) {
 	query := fmt.Sprintf("UPDATE %s Set", petTable)
 	var params []interface{}
-	var setClauses []string
-	count := 1
+	setClauses := []string{"version = " + s.dialect.nextVersion}
 
 	if name != "" {
-		setClauses = append(setClauses, fmt.Sprintf("name = $%d", count))
-		count++
+		setClauses = append(setClauses, "name = ?")
 		params = append(params, name)
 	}
 	if status != "" {
-		setClauses = append(setClauses, fmt.Sprintf("status = $%d", count))
-		count++
+		setClauses = append(setClauses, "status = ?")
 		params = append(params, status)
 	}
 
-	query += " " + strings.Join(setClauses, ", ") + fmt.Sprintf(" WHERE id = $%d", count)
-	params = append(params, petID)
+	query += " " + strings.Join(setClauses, ", ") + " WHERE id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)"
+	params = append(params, petID, version, version)
 
-	result, err := s.db.ExecContext(ctx, query, params...)
+	newVersion, err := s.dialect.returningInt(ctx, s.db, query, "version", params...)
+	if err == sql.ErrNoRows {
+		return 0, petUpdateMissed(ctx, s.db, petID)
+	}
 	if err != nil {
-		return err
+		return 0, err
 	}
-	rowsAffected, err := result.RowsAffected()
+
+	return newVersion, nil
+}
+
+// petUpdateMissed - причина, по которой UPDATE питомца с проверкой версии не затронул строку:
+// ErrPetVersion, если питомец есть, и ErrPetNotFound, если его нет
+func petUpdateMissed(ctx context.Context, q sqlx.ExtContext, petID int) error {
+	query := q.Rebind(fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE id = ? AND deleted_at IS NULL)`, petTable))
+	var exists bool
+	if err := q.QueryRowxContext(ctx, query, petID).Scan(&exists); err != nil {
+		return fmt.Errorf("check pet exists, %v", err)
+	}
+	if exists {
+		return myerrors.ErrPetVersion
+	}
+	return myerrors.ErrPetNotFound
+}
+
+func (s *SQLAdapter) FindPetbyID(ctx context.Context, petid int) (models.Pet, error) {
+	query, args, err := newPetQuery().Where("p.id = ?", petid).Build(s.db)
 	if err != nil {
-		return err
+		return models.Pet{}, err
 	}
 
-	// Если строки не были затронуты, возвращаем ошибку
-	if rowsAffected == 0 {
-		return fmt.Errorf("no rows were updated, possibly invalid ID")
+	pets, err := s.queryPets(ctx, query, args...)
+	if err != nil {
+		return models.Pet{}, err
+	}
+	if len(pets) == 0 {
+		return models.Pet{}, myerrors.ErrPetNotFound
 	}
 
-	return nil
+	return pets[0], nil
 }
 
-func (s *SQLAdapter) FindPetbyID(ctx context.Context, petid int) (models.Pet, error) {
-	query := `
-		SELECT 
-			p.id AS pet_id,
-			p.name AS pet_name,
-			p.photourls,
-			p.status AS pet_status,
-			c.id,
-			c.name AS category_name,
-			t.id,
-			t.name AS tag_name
-		FROM pet p
-		JOIN category c ON p.category = c.id
-		LEFT JOIN pet_tags pt ON p.id = pt.pet_id
-		LEFT JOIN tags t ON pt.tag_id = t.id
-		WHERE p.id IN ($1)
-		ORDER BY p.id;
-	`
-
-	rows, err := s.db.QueryContext(ctx, query, petid)
+// queryPets - выполняет выборку питомцев (колонки petColumns, релевантность и подсветка)
+// и догружает их теги и фотографии
+func (s *SQLAdapter) queryPets(ctx context.Context, query string, args ...interface{}) ([]models.Pet, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return models.Pet{}, fmt.Errorf("failed to execute query: %w", err)
+		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
 	defer rows.Close()
-	if !rows.Next() {
-		return models.Pet{}, myerrors.ErrPetNotFound
+
+	pets := []models.Pet{}
+	for rows.Next() {
+		pet := models.Pet{
+			PhotoUrls: []string{},
+			Tags:      []models.Tag{},
+		}
+		var birthDate sql.NullTime
+		err := rows.Scan(&pet.ID, &pet.Name, &pet.Status, &pet.Category.ID, &pet.Category.Name, &pet.CreatedAt, &pet.Version,
+			&pet.Breed, &birthDate, &pet.Sex, &pet.Weight, &pet.Color, &pet.MicrochipID, &pet.Description,
+			&pet.Rank, &pet.Highlight)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if birthDate.Valid {
+			pet.BirthDate = birthDate.Time.Format(models.PetDateLayout)
+		}
+		pets = append(pets, pet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	if len(pets) == 0 {
+		return pets, nil
+	}
+
+	if err := s.loadPetDetails(ctx, pets); err != nil {
+		return nil, err
+	}
+
+	return pets, nil
+}
+
+// loadPetDetails - заполняет теги и фотографии питомцев двумя запросами на всю выборку
+func (s *SQLAdapter) loadPetDetails(ctx context.Context, pets []models.Pet) error {
+	index := make(map[int]int, len(pets))
+	placeholders := make([]string, len(pets))
+	args := make([]interface{}, len(pets))
+	for i := range pets {
+		index[pets[i].ID] = i
+		placeholders[i] = "?"
+		args[i] = pets[i].ID
 	}
-	var currentPet models.Pet
+	in := strings.Join(placeholders, ", ")
 
+	queryTags := s.db.Rebind(fmt.Sprintf(`
+		SELECT pt.pet_id, t.id, t.name
+		FROM %s pt
+		JOIN %s t ON pt.tag_id = t.id
+		WHERE pt.pet_id IN (%s)
+		ORDER BY pt.pet_id, t.id`, petTagsTable, tagsTable, in))
+	rows, err := s.db.QueryContext(ctx, queryTags, args...)
+	if err != nil {
+		return fmt.Errorf("failed to execute tags query: %w", err)
+	}
+	defer rows.Close()
 	for rows.Next() {
-		var petID, categoryID, tagID int
-		var petName, photourls, petStatus, categoryName, tagName string
-
-		// Сканирование строки
-		if err := rows.Scan(&petID, &petName, &photourls, &petStatus, &categoryID, &categoryName, &tagID, &tagName); err != nil {
-			return models.Pet{}, fmt.Errorf("failed to scan row: %w", err)
-		}
-		// Добавляем питомца в переменную currentPet
-		if currentPet.ID != petID {
-			currentPet = models.Pet{
-				ID:        petID,
-				Name:      petName,
-				PhotoUrls: []string{photourls},
-				Status:    petStatus,
-				Category: models.Category{
-					ID:   categoryID,
-					Name: categoryName,
-				},
-				Tags: []models.Tag{
-					{
-						ID:   tagID,
-						Name: tagName,
-					},
-				},
-			}
-		} else {
-			// Если есть ещё строки добавляем новые теги
-			tag := models.Tag{
-				ID:   tagID,
-				Name: tagName,
-			}
-			currentPet.Tags = append(currentPet.Tags, tag)
+		var petID int
+		var tag models.Tag
+		if err := rows.Scan(&petID, &tag.ID, &tag.Name); err != nil {
+			return fmt.Errorf("failed to scan tag row: %w", err)
 		}
+		pet := &pets[index[petID]]
+		pet.Tags = append(pet.Tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("tags rows iteration error: %w", err)
 	}
-	return currentPet, nil
+
+	queryPhotos := s.db.Rebind(fmt.Sprintf(`
+		SELECT pet_id, url
+		FROM %s
+		WHERE pet_id IN (%s)
+		ORDER BY pet_id, position`, petPhotosTable, in))
+	photoRows, err := s.db.QueryContext(ctx, queryPhotos, args...)
+	if err != nil {
+		return fmt.Errorf("failed to execute photos query: %w", err)
+	}
+	defer photoRows.Close()
+	for photoRows.Next() {
+		var petID int
+		var url string
+		if err := photoRows.Scan(&petID, &url); err != nil {
+			return fmt.Errorf("failed to scan photo row: %w", err)
+		}
+		pet := &pets[index[petID]]
+		pet.PhotoUrls = append(pet.PhotoUrls, url)
+	}
+	if err := photoRows.Err(); err != nil {
+		return fmt.Errorf("photos rows iteration error: %w", err)
+	}
+
+	return nil
+}
+
+// replacePetPhotos - заменяет фотографии питомца, сохраняя порядок, в котором они были переданы
+func replacePetPhotos(ctx context.Context, tx *sqlx.Tx, petID int, urls []string) error {
+	queryDel := tx.Rebind(fmt.Sprintf(`DELETE FROM %s WHERE pet_id = ?`, petPhotosTable))
+	if _, err := tx.ExecContext(ctx, queryDel, petID); err != nil {
+		return fmt.Errorf("replacePetPhotos queryDel, %v", err)
+	}
+
+	queryPhoto := tx.Rebind(fmt.Sprintf(`
+		INSERT INTO %s (pet_id, position, url)
+		VALUES (?, ?, ?)`, petPhotosTable))
+	for i, url := range urls {
+		if _, err := tx.ExecContext(ctx, queryPhoto, petID, i, url); err != nil {
+			return fmt.Errorf("replacePetPhotos queryPhoto, %v", err)
+		}
+	}
+
+	return nil
 }
 
 // Проверяет наличие строки str в таблице tablename в поле colimnname и возвращает id строки если она есть
 func (s *SQLAdapter) CheckFields(tablename, str, columnname string) (int, error) {
-	query := fmt.Sprintf(
-		`SELECT id
-		FROM %s
-		WHERE %s = $1 LIMIT 1;`, tablename, columnname)
+	if err := checkIdent(tablename, columnname); err != nil {
+		return 0, err
+	}
+	query, args, err := newQuery("id", tablename).
+		Where(columnname+" = ?", str).
+		Limit(1).
+		Build(s.db)
+	if err != nil {
+		return 0, err
+	}
 
 	var id int
-	err := s.db.QueryRow(query, str).Scan(&id)
+	err = s.db.QueryRow(query, args...).Scan(&id)
 	if err != nil {
 		// Если ошибка связана с тем, что строка не найдена
 		if err == sql.ErrNoRows {
@@ -140,207 +227,522 @@ func (s *SQLAdapter) AddPet(ctx context.Context, pet *models.Pet) error {
 		return err
 	}
 	if idCategory == 0 {
-		return errors.New("некорректно введена категория")
-	}
-	//Вставляем pet в таблицу
-	var petID int
-	queryPet := fmt.Sprintf(
-		`INSERT INTO %s (category, name, photoUrls, status)
-				VALUES ($1, $2, $3, $4)
-				RETURNING id`, petTable)
-	urls := strings.Join(pet.PhotoUrls, ", ")
-	err = s.db.QueryRowContext(ctx, queryPet, idCategory, pet.Name, urls, pet.Status).Scan(&petID)
-	if err != nil {
-		return fmt.Errorf("addpet qurePet, %v", err)
-	}
-
-	//Вставим теги в таблицу
-	for i := range pet.Tags {
-		queryTag := fmt.Sprintf(
-			`INSERT INTO %s (name)
-			VALUES ($1)
-			ON CONFLICT (name) DO NOTHING
-			RETURNING id`, tagsTable)
-		var tagID int
-		err := s.db.QueryRowContext(ctx, queryTag, pet.Tags[i].Name).Scan(&tagID)
-		if err != nil && err.Error() != "sql: no rows in result set" {
-			return fmt.Errorf("addpet queryTag, %v", err)
-		}
-		// Если тег уже существует, возвращаем его ID
-		if tagID == 0 {
-			queryTag = fmt.Sprintf(
-				`SELECT id FROM %s WHERE name = $1`, tagsTable)
-			err := s.db.QueryRowContext(ctx, queryTag, pet.Tags[i].Name).Scan(&tagID)
-			if err != nil {
-				return fmt.Errorf("addpet queryTag when taID=0, %v", err)
-			}
+		return myerrors.ErrCategoryNotFound
+	}
+
+	// Питомец, его фотографии и теги записываются вместе или не записываются вовсе
+	return s.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := checkMicrochip(ctx, tx, pet.MicrochipID, 0); err != nil {
+			return err
 		}
-		//Связываем теги с вставленным питомцем
-		queryTagPet := fmt.Sprintf(
-			`INSERT INTO %s (pet_id, tag_id)
-			VALUES($1, $2)`, petTagsTable)
-		_, err = s.db.ExecContext(ctx, queryTagPet, petID, tagID)
+
+		//Вставляем pet в таблицу
+		queryPet := fmt.Sprintf(
+			`INSERT INTO %s (category, name, status, breed, birth_date, sex, weight, color, microchip_id, description)
+				VALUES (?, ?, ?, NULLIF(?, ''), ?, NULLIF(?, ''), NULLIF(?, 0), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''))`, petTable)
+		petID, err := s.dialect.returningInt(ctx, tx, queryPet, "id", idCategory, pet.Name, pet.Status,
+			pet.Breed, birthDateArg(pet.BirthDate), pet.Sex, pet.Weight, pet.Color, pet.MicrochipID, pet.Description)
 		if err != nil {
-			return fmt.Errorf("addpet queryTagPet, %v", err)
+			return fmt.Errorf("addpet qurePet, %v", err)
 		}
-	}
-	return nil
+
+		if err := replacePetPhotos(ctx, tx, petID, pet.PhotoUrls); err != nil {
+			return err
+		}
+		if err := s.linkPetTags(ctx, tx, petID, pet.Tags); err != nil {
+			return err
+		}
+
+		pet.ID = petID
+		return nil
+	})
 }
 
-func (s *SQLAdapter) UpdatePet(ctx context.Context, pet models.Pet) error {
+// UpdatePet - обновляет питомца, если его текущая версия равна pet.Version (0 - любая),
+// и записывает в pet.Version новую версию. При несовпадении версии возвращает ErrPetVersion
+func (s *SQLAdapter) UpdatePet(ctx context.Context, pet *models.Pet) error {
 	//Проверяем корректность введённой категории
 	var idCategory int
 	if pet.Category.Name != "" {
-		idCategory, err := s.CheckFields(categoryTable, pet.Category.Name, "name")
+		var err error
+		idCategory, err = s.CheckFields(categoryTable, pet.Category.Name, "name")
 		if err != nil {
 			return err
 		}
 		if idCategory == 0 {
-			return errors.New("некорректно введена категория")
+			return myerrors.ErrCategoryNotFound
 		}
 	}
-	query := fmt.Sprintf(`
+
+	return s.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := checkMicrochip(ctx, tx, pet.MicrochipID, pet.ID); err != nil {
+			return err
+		}
+
+		query := fmt.Sprintf(`
 	UPDATE %s
 	SET
-    name = COALESCE(NULLIF($1, ''), name),
-    category = COALESCE(NULLIF($2, 0), category),
-    status = COALESCE(NULLIF($3, ''), status),
-    photourls = COALESCE($4, photourls)
-WHERE id = $5;`, petTable)
-	urls := strings.Join(pet.PhotoUrls, ", ")
-	_, err := s.db.ExecContext(ctx, query, pet.Name, idCategory, pet.Status, urls, pet.ID)
-	if err != nil {
-		return fmt.Errorf("error in sqlAdapter-(UpdatePet)-execintable: %v", err)
+    name = COALESCE(NULLIF(?, ''), name),
+    category = COALESCE(NULLIF(?, 0), category),
+    status = COALESCE(NULLIF(?, ''), status),
+    breed = COALESCE(NULLIF(?, ''), breed),
+    birth_date = COALESCE(?, birth_date),
+    sex = COALESCE(NULLIF(?, ''), sex),
+    weight = COALESCE(NULLIF(?, 0), weight),
+    color = COALESCE(NULLIF(?, ''), color),
+    microchip_id = COALESCE(NULLIF(?, ''), microchip_id),
+    description = COALESCE(NULLIF(?, ''), description),
+    version = %s
+WHERE id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)`, petTable, s.dialect.nextVersion)
+		version, err := s.dialect.returningInt(ctx, tx, query, "version", pet.Name, idCategory, pet.Status,
+			pet.Breed, birthDateArg(pet.BirthDate), pet.Sex, pet.Weight, pet.Color, pet.MicrochipID, pet.Description,
+			pet.ID, pet.Version, pet.Version)
+		if err == sql.ErrNoRows {
+			return petUpdateMissed(ctx, tx, pet.ID)
+		}
+		if err != nil {
+			return fmt.Errorf("error in sqlAdapter-(UpdatePet)-execintable: %v", err)
+		}
+		// Фотографии заменяются, только если список передан (пустой список удаляет все фотографии)
+		if pet.PhotoUrls != nil {
+			if err := replacePetPhotos(ctx, tx, pet.ID, pet.PhotoUrls); err != nil {
+				return err
+			}
+		}
+		// Теги заменяются, только если передан непустой список
+		if len(pet.Tags) != 0 {
+			queryDel := tx.Rebind(fmt.Sprintf(`
+			DELETE FROM %s WHERE pet_id = ?`, petTagsTable))
+			if _, err := tx.ExecContext(ctx, queryDel, pet.ID); err != nil {
+				return fmt.Errorf("updatepet queryDel: %v", err)
+			}
+			if err := s.linkPetTags(ctx, tx, pet.ID, pet.Tags); err != nil {
+				return err
+			}
+		}
+
+		pet.Version = version
+		return nil
+	})
+}
+
+// PatchPet - частично обновляет питомца: меняются только поля патча с Set, поля без значения
+// записываются как NULL. Версия проверяется и возвращается в patch.Version так же, как в UpdatePet
+func (s *SQLAdapter) PatchPet(ctx context.Context, patch *models.PetPatch) error {
+	var set patchSet
+	if patch.Category.Set {
+		idCategory, err := s.CheckFields(categoryTable, patch.Category.String, "name")
+		if err != nil {
+			return err
+		}
+		if idCategory == 0 {
+			return myerrors.ErrCategoryNotFound
+		}
+		set.set("category", idCategory)
+	}
+	if patch.Name.Set {
+		set.set("name", patch.Name.NullString)
 	}
-	fmt.Println(pet.Tags)
-	if len(pet.Tags) != 0 {
-		queryDel := fmt.Sprintf(`
-			DELETE FROM %s WHERE pet_id = $1`, petTagsTable)
-		_, err = s.db.ExecContext(ctx, queryDel, pet.ID)
+	if patch.Status.Set {
+		set.set("status", patch.Status.NullString)
+	}
+	if patch.Breed.Set {
+		set.set("breed", patch.Breed.NullString)
+	}
+	if patch.BirthDate.Set {
+		set.set("birth_date", birthDateArg(patch.BirthDate.String))
+	}
+	if patch.Sex.Set {
+		set.set("sex", patch.Sex.NullString)
+	}
+	if patch.Weight.Set {
+		set.set("weight", patch.Weight.NullFloat64)
+	}
+	if patch.Color.Set {
+		set.set("color", patch.Color.NullString)
+	}
+	if patch.MicrochipID.Set {
+		set.set("microchip_id", patch.MicrochipID.NullString)
+	}
+	if patch.Description.Set {
+		set.set("description", patch.Description.NullString)
+	}
+	set.expr("version", s.dialect.nextVersion)
+
+	return s.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if patch.MicrochipID.Set {
+			if err := checkMicrochip(ctx, tx, patch.MicrochipID.String, patch.ID); err != nil {
+				return err
+			}
+		}
+
+		query := fmt.Sprintf(`
+	UPDATE %s
+	SET %s
+	WHERE id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)`, petTable, set.sql())
+		args := append(set.args, patch.ID, patch.Version, patch.Version)
+		version, err := s.dialect.returningInt(ctx, tx, query, "version", args...)
+		if err == sql.ErrNoRows {
+			return petUpdateMissed(ctx, tx, patch.ID)
+		}
 		if err != nil {
-			return fmt.Errorf("updatepet queryDel: %v", err)
-		}
-		for i := range pet.Tags {
-			queryTag := fmt.Sprintf(
-				`INSERT INTO %s (name)
-				VALUES ($1)
-				ON CONFLICT (name) DO NOTHING
-				RETURNING id`, tagsTable)
-			var tagID int
-			err := s.db.QueryRowContext(ctx, queryTag, pet.Tags[i].Name).Scan(&tagID)
-			if err != nil && err.Error() != "sql: no rows in result set" {
-				return fmt.Errorf("updatepet queryTag, %v", err)
+			return fmt.Errorf("error in sqlAdapter-(PatchPet)-execintable: %v", err)
+		}
+		// Массивы в merge patch заменяются целиком, null очищает их
+		if patch.PhotoUrls.Set {
+			if err := replacePetPhotos(ctx, tx, patch.ID, patch.PhotoUrls.Value); err != nil {
+				return err
+			}
+		}
+		if patch.Tags.Set {
+			queryDel := tx.Rebind(fmt.Sprintf(`
+			DELETE FROM %s WHERE pet_id = ?`, petTagsTable))
+			if _, err := tx.ExecContext(ctx, queryDel, patch.ID); err != nil {
+				return fmt.Errorf("patchpet queryDel: %v", err)
 			}
-			// Если тег уже существует, возвращаем его ID
-			if tagID == 0 {
-				queryTag = fmt.Sprintf(
-					`SELECT id FROM %s WHERE name = $1`, tagsTable)
-				err := s.db.QueryRowContext(ctx, queryTag, pet.Tags[i].Name).Scan(&tagID)
-				if err != nil {
-					return fmt.Errorf("updatepet queryTag: %v", err)
-				}
+			if err := s.linkPetTags(ctx, tx, patch.ID, patch.Tags.Value); err != nil {
+				return err
 			}
-			//Связываем теги с обновляемым питомцем
-			queryTagPet := fmt.Sprintf(
-				`INSERT INTO %s (pet_id, tag_id)
-		VALUES($1, $2)`, petTagsTable)
-			_, err = s.db.ExecContext(ctx, queryTagPet, pet.ID, tagID)
-			if err != nil {
-				return fmt.Errorf("addpet queryTagPet, %v", err)
+		}
+
+		patch.Version = version
+		return nil
+	})
+}
+
+// linkPetTags - привязывает к питомцу теги по именам, создавая недостающие. Повторы не дублируются
+func (s *SQLAdapter) linkPetTags(ctx context.Context, tx *sqlx.Tx, petID int, tags []models.Tag) error {
+	queryTag := s.dialect.ignoreDuplicate(fmt.Sprintf(
+		`INSERT INTO %s (name)
+			VALUES (?)`, tagsTable), "name")
+	querySelect := tx.Rebind(fmt.Sprintf(
+		`SELECT id FROM %s WHERE name = ?`, tagsTable))
+	queryTagPet := tx.Rebind(s.dialect.ignoreDuplicate(fmt.Sprintf(
+		`INSERT INTO %s (pet_id, tag_id)
+			VALUES(?, ?)`, petTagsTable), "pet_id", "tag_id"))
+
+	for i := range tags {
+		tagID, err := s.dialect.returningInt(ctx, tx, queryTag, "id", tags[i].Name)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("linkPetTags queryTag, %v", err)
+		}
+		// Если тег уже существует, берём его ID
+		if tagID == 0 {
+			if err := tx.QueryRowContext(ctx, querySelect, tags[i].Name).Scan(&tagID); err != nil {
+				return fmt.Errorf("linkPetTags querySelect, %v", err)
 			}
 		}
+		//Связываем тег с питомцем
+		if _, err := tx.ExecContext(ctx, queryTagPet, petID, tagID); err != nil {
+			return fmt.Errorf("linkPetTags queryTagPet, %v", err)
+		}
 	}
+
 	return nil
 }
 
-func (a *SQLAdapter) FindPetbyStatus(ctx context.Context, statuses []string) ([]models.Pet, error) {
-	placeholders := make([]string, len(statuses))
-	for i := range statuses {
-		placeholders[i] = fmt.Sprintf("$%d", i+1) // $1, $2 и т.д.
-	}
-	query := fmt.Sprintf(`
-		SELECT 
-			p.id AS pet_id,
-			p.name AS pet_name,
-			p.photourls,
-			p.status AS pet_status,
-			c.id,
-			c.name AS category_name,
-			t.id,
-			t.name AS tag_name
-		FROM pet p
-		JOIN category c ON p.category = c.id
-		LEFT JOIN pet_tags pt ON p.id = pt.pet_id
-		LEFT JOIN tags t ON pt.tag_id = t.id
-		WHERE p.status IN (%s)
-		ORDER BY p.id;
-	`, strings.Join(placeholders, ", "))
-
-	// Подготовка аргументов запроса
-	args := make([]interface{}, len(statuses))
-	for i, status := range statuses {
-		args[i] = status
-	}
-
-	// Выполнение запроса
-	rows, err := a.db.QueryContext(ctx, query, args...)
+// touchPets - увеличивает версию питомцев, отобранных условием where, когда меняются их теги
+// или категория, а не сама строка питомца: ответ GET /pet изменился, и If-Match со старой версией
+// проходить не должен. Условие не выбирает из pet подзапросом, этого не допускает MySQL
+func (s *SQLAdapter) touchPets(ctx context.Context, tx *sqlx.Tx, where string, args ...interface{}) error {
+	query := tx.Rebind(fmt.Sprintf(`UPDATE %s SET version = version + 1 WHERE %s`, petTable, where))
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+// checkMicrochip - возвращает ErrMicrochipExists, если номер чипа уже принадлежит другому питомцу.
+// Проверка даёт понятную ошибку, гонку двух запросов закрывает уникальный индекс
+func checkMicrochip(ctx context.Context, tx *sqlx.Tx, microchipID string, petID int) error {
+	if microchipID == "" {
+		return nil
+	}
+	query := tx.Rebind(fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE microchip_id = ? AND id <> ?)`, petTable))
+	var exists bool
+	if err := tx.QueryRowContext(ctx, query, microchipID, petID).Scan(&exists); err != nil {
+		return fmt.Errorf("check microchip, %v", err)
+	}
+	if exists {
+		return myerrors.ErrMicrochipExists
+	}
+	return nil
+}
+
+// birthDateArg - дата рождения для записи в БД, nil если она не указана или не разбирается
+func birthDateArg(date string) interface{} {
+	birthDate, err := time.Parse(models.PetDateLayout, date)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %w", err)
+		return nil
 	}
-	defer rows.Close()
+	return birthDate
+}
 
-	var pets []models.Pet
-	var currentPet *models.Pet
+// petColumns - колонки выборки питомцев в порядке, который ожидает queryPets.
+// За ними следуют релевантность и подсветка совпадений полнотекстового поиска
+const petColumns = "p.id, COALESCE(p.name, ''), p.status, c.id, c.name, p.created_at, p.version, " +
+	"COALESCE(p.breed, ''), p.birth_date, COALESCE(p.sex, ''), COALESCE(p.weight, 0), COALESCE(p.color, ''), " +
+	"COALESCE(p.microchip_id, ''), COALESCE(p.description, '')"
 
-	for rows.Next() {
-		var petID, categoryID, tagID int
-		var petName, photourls, petStatus, categoryName, tagName string
+// Выражения полнотекстового поиска в Postgres, плейсхолдер - строка запроса в синтаксисе websearch_to_tsquery
+const (
+	petTextMatch     = "p.search_vector @@ websearch_to_tsquery('simple', ?)"
+	petTextRank      = "ts_rank(p.search_vector, websearch_to_tsquery('simple', ?))"
+	petTextHighlight = "ts_headline('simple', p.search_text, websearch_to_tsquery('simple', ?), " +
+		"'StartSel=<mark>, StopSel=</mark>, HighlightAll=true')"
+)
 
-		// Сканирование строки
-		if err := rows.Scan(&petID, &petName, &photourls, &petStatus, &categoryID, &categoryName, &tagID, &tagName); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+// newPetQuery - выборка не удалённых питомцев вместе с их категорией
+func newPetQuery() *queryBuilder {
+	return newQuery(petColumns+", 0 AS text_rank, ''", fmt.Sprintf("%s p JOIN %s c ON p.category = c.id", petTable, categoryTable)).
+		Where("p.deleted_at IS NULL")
+}
+
+// FindPetbyStatus - страница питомцев с указанными статусами. Возвращает до page.Limit питомцев
+// в порядке page.Sort, начиная после page.After
+func (a *SQLAdapter) FindPetbyStatus(ctx context.Context, statuses []string, page models.PetPage) ([]models.Pet, error) {
+	return a.SearchPets(ctx, models.PetFilter{Statuses: statuses}, page)
+}
+
+// FindPetbyTags - поиск питомцев по тегам: matchAll = false - хотя бы один из тегов, true - все теги
+func (a *SQLAdapter) FindPetbyTags(ctx context.Context, tags []string, matchAll bool) ([]models.Pet, error) {
+	q := newPetQuery().
+		WhereSubquery("p.id", petsWithTags(tags, matchAll)).
+		OrderBy("p.id")
+
+	query, args, err := q.Build(a.db)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.queryPets(ctx, query, args...)
+}
+
+// SearchPets - страница питомцев, подходящих под все заданные условия фильтра.
+// С filter.Query у найденных питомцев заполняются релевантность и подсветка совпавших слов,
+// а страницу можно упорядочить по релевантности
+func (a *SQLAdapter) SearchPets(ctx context.Context, filter models.PetFilter, page models.PetPage) ([]models.Pet, error) {
+	q := newPetQuery()
+	text := a.dialect.textQuery(filter.Query)
+	if filter.Query != "" {
+		rank, highlight := a.dialect.textRank, a.dialect.textHighlight
+		q.Select(petColumns+", "+rank+" AS text_rank, "+highlight, append(textArgs(rank, text), textArgs(highlight, text)...)...).
+			Where(a.dialect.textMatch, textArgs(a.dialect.textMatch, text)...)
+	} else if page.Sort == models.PetSortRank {
+		return nil, fmt.Errorf("sort by rank requires a text query")
+	}
+	if filter.Name != "" {
+		q.Where(`LOWER(COALESCE(p.name, '')) LIKE ? ESCAPE '\'`, "%"+escapeLike(strings.ToLower(filter.Name))+"%")
+	}
+	if filter.Category != "" {
+		q.Where("c.name = ?", filter.Category)
+	}
+	if len(filter.Statuses) > 0 {
+		q.WhereIn("p.status", filter.Statuses)
+	}
+	if len(filter.Tags) > 0 {
+		q.WhereSubquery("p.id", petsWithTags(filter.Tags, filter.MatchAllTags))
+	}
+	if !filter.CreatedFrom.IsZero() {
+		q.Where("p.created_at >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		q.Where("p.created_at < ?", filter.CreatedTo)
+	}
+	if filter.Breed != "" {
+		q.Where("LOWER(p.breed) = ?", strings.ToLower(filter.Breed))
+	}
+	if filter.Sex != "" {
+		q.Where("p.sex = ?", filter.Sex)
+	}
+	if filter.Color != "" {
+		q.Where("LOWER(p.color) = ?", strings.ToLower(filter.Color))
+	}
+	if filter.MicrochipID != "" {
+		q.Where("p.microchip_id = ?", filter.MicrochipID)
+	}
+	if !filter.BornFrom.IsZero() {
+		q.Where("p.birth_date >= ?", filter.BornFrom)
+	}
+	if !filter.BornTo.IsZero() {
+		q.Where("p.birth_date <= ?", filter.BornTo)
+	}
+	if filter.WeightMin > 0 {
+		q.Where("p.weight >= ?", filter.WeightMin)
+	}
+	if filter.WeightMax > 0 {
+		q.Where("p.weight <= ?", filter.WeightMax)
+	}
+	applyPetPage(q, page, a.dialect.textRank, text)
+
+	query, args, err := q.Build(a.db)
+	if err != nil {
+		return nil, err
+	}
+
+	// Теги и фотографии догружаются отдельно, поэтому LIMIT отсекает ровно page.Limit питомцев
+	pets, err := a.queryPets(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Query != "" && a.dialect.markText != nil {
+		for i := range pets {
+			pets[i].Highlight = a.dialect.markText(pets[i].Highlight, filter.Query)
 		}
+	}
 
-		// Если это новый питомец, добавляем его в список
-		if currentPet == nil || currentPet.ID != petID {
-			if currentPet != nil {
-				pets = append(pets, *currentPet)
-			}
-			currentPet = &models.Pet{
-				ID:        petID,
-				Name:      petName,
-				PhotoUrls: []string{photourls},
-				Status:    petStatus,
-				Category: models.Category{
-					ID:   categoryID,
-					Name: categoryName,
-				},
-				Tags: []models.Tag{
-					{
-						ID:   tagID,
-						Name: tagName,
-					},
-				},
-			}
-		} else {
-			// Если это тот же питомец, добавляем новый тег
-			tag := models.Tag{
-				ID:   tagID,
-				Name: tagName,
-			}
-			currentPet.Tags = append(currentPet.Tags, tag)
+	return pets, nil
+}
+
+// petsWithTags - подзапрос id питомцев, у которых есть хотя бы один (или все) из тегов
+func petsWithTags(tags []string, matchAll bool) *queryBuilder {
+	sub := newQuery("pt.pet_id", fmt.Sprintf("%s pt JOIN %s t ON pt.tag_id = t.id", petTagsTable, tagsTable)).
+		WhereIn("t.name", tags).
+		GroupBy("pt.pet_id")
+	if matchAll {
+		sub.Having("COUNT(DISTINCT t.id) = ?", len(tags))
+	}
+
+	return sub
+}
+
+// applyPetPage - добавляет в запрос порядок сортировки page.Sort, условие продолжения
+// после курсора и ограничение размера страницы. rank и text - выражение релевантности
+// и запрос полнотекстового поиска, нужны для сортировки по релевантности
+func applyPetPage(q *queryBuilder, page models.PetPage, rank, text string) {
+	dir, cmp := "ASC", ">"
+	if page.Desc {
+		dir, cmp = "DESC", "<"
+	}
+	q.Limit(page.Limit)
+
+	var column, orderBy string
+	var columnArgs []interface{}
+	var value interface{}
+	switch page.Sort {
+	case models.PetSortName:
+		column = "COALESCE(p.name, '')"
+		if page.After != nil {
+			value = page.After.Name
+		}
+	case models.PetSortCreated:
+		column = "p.created_at"
+		if page.After != nil {
+			value = page.After.CreatedAt
+		}
+	case models.PetSortRank:
+		// В ORDER BY используется псевдоним колонки, в условии курсора выражение повторяется
+		column, orderBy, columnArgs = rank, "text_rank", textArgs(rank, text)
+		if page.After != nil {
+			value = page.After.Rank
+		}
+	default:
+		q.OrderBy("p.id " + dir)
+		if page.After != nil {
+			q.Where("p.id "+cmp+" ?", page.After.ID)
 		}
+		return
 	}
 
-	// Добавляем последнего питомца в список
-	if currentPet != nil {
-		pets = append(pets, *currentPet)
+	// id добавляется к ключу сортировки, чтобы порядок был однозначным при совпадающих значениях
+	if orderBy == "" {
+		orderBy = column
 	}
+	q.OrderBy(fmt.Sprintf("%s %s, p.id %s", orderBy, dir, dir))
+	if page.After != nil {
+		q.Where(fmt.Sprintf("(%s, p.id) %s (?, ?)", column, cmp), append(columnArgs, value, page.After.ID)...)
+	}
+}
 
-	// Проверка на ошибки при итерации по строкам
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows iteration error: %w", err)
+// escapeLike - экранирует спецсимволы шаблона LIKE, чтобы подстрока искалась буквально
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// DeletePet - мягкое удаление питомца: строка остаётся в БД до очистки
+func (s *SQLAdapter) DeletePet(ctx context.Context, petID int) error {
+	query := s.db.Rebind(fmt.Sprintf(`
+	UPDATE %s
+	SET deleted_at = %s, version = version + 1
+	WHERE id = ? AND deleted_at IS NULL`, petTable, s.dialect.now))
+
+	result, err := s.db.ExecContext(ctx, query, petID)
+	if err != nil {
+		return fmt.Errorf("deletepet query, %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return myerrors.ErrPetNotFound
 	}
 
-	return pets, nil
+	return nil
+}
+
+// RestorePet - восстановление мягко удалённого питомца
+func (s *SQLAdapter) RestorePet(ctx context.Context, petID int) error {
+	query := s.db.Rebind(fmt.Sprintf(`
+	UPDATE %s
+	SET deleted_at = NULL, version = version + 1
+	WHERE id = ? AND deleted_at IS NOT NULL`, petTable))
+
+	result, err := s.db.ExecContext(ctx, query, petID)
+	if err != nil {
+		return fmt.Errorf("restorepet query, %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return myerrors.ErrPetNotFound
+	}
+
+	return nil
+}
+
+// PurgeDeletedPets - окончательное удаление питомцев, удалённых раньше указанного момента.
+// Возвращает число удалённых и ключи их изображений: строки изображений удаляются каскадно,
+// а питомцы заблокированы до конца транзакции, поэтому восстановленный параллельно в неё не попадёт
+func (s *SQLAdapter) PurgeDeletedPets(ctx context.Context, deletedBefore time.Time) (int, []string, error) {
+	var petIDs []int
+	var keys []string
+	err := s.WithTx(ctx, func(tx *sqlx.Tx) error {
+		queryLock := tx.Rebind(fmt.Sprintf(`
+		SELECT id 
+		FROM %s 
+		WHERE deleted_at IS NOT NULL AND deleted_at < ?%s`, petTable, s.dialect.forUpdate))
+		if err := tx.SelectContext(ctx, &petIDs, queryLock, deletedBefore); err != nil {
+			return fmt.Errorf("purgedeletedpets queryLock, %v", err)
+		}
+		if len(petIDs) == 0 {
+			return nil
+		}
+
+		queryKeys, args, err := sqlx.In(fmt.Sprintf(`
+		SELECT storage_key 
+		FROM %s 
+		WHERE pet_id IN (?)`, petImagesTable), petIDs)
+		if err != nil {
+			return err
+		}
+		if err := tx.SelectContext(ctx, &keys, tx.Rebind(queryKeys), args...); err != nil {
+			return fmt.Errorf("purgedeletedpets queryKeys, %v", err)
+		}
+
+		query, args, err := sqlx.In(fmt.Sprintf(`DELETE FROM %s WHERE id IN (?)`, petTable), petIDs)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, tx.Rebind(query), args...); err != nil {
+			return fmt.Errorf("purgedeletedpets query, %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return len(petIDs), keys, nil
 }
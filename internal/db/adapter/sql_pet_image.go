@@ -0,0 +1,82 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	myerrors "pet-store/internal/infrastructure/errors"
+	"pet-store/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// AddPetImage - сохраняет метаданные изображения и добавляет его ссылку в фотографии питомца
+func (s *SQLAdapter) AddPetImage(ctx context.Context, image *models.PetImage) error {
+	return s.WithTx(ctx, func(tx *sqlx.Tx) error {
+		// Новая фотография меняет питомца, поэтому его версия растёт. UPDATE заодно блокирует строку
+		queryPet := tx.Rebind(fmt.Sprintf(`
+		UPDATE %s 
+		SET version = version + 1 
+		WHERE id = ? AND deleted_at IS NULL`, petTable))
+		result, err := tx.ExecContext(ctx, queryPet, image.PetID)
+		if err != nil {
+			return fmt.Errorf("error addPetImage queryPet, %v", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return myerrors.ErrPetNotFound
+		}
+
+		query := fmt.Sprintf(`
+		INSERT INTO %s (pet_id, name, storage_key, original_name, content_type, size, additional_metadata, url) 
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?)`, petImagesTable)
+		image.ID, err = s.dialect.returningInt(ctx, tx, query, "id", image.PetID, image.Name, image.StorageKey, image.OriginalName,
+			image.ContentType, image.Size, image.AdditionalMetadata, image.URL)
+		if err != nil {
+			return fmt.Errorf("error addPetImage, %v", err)
+		}
+		// Время создания задаёт БД, RETURNING для него есть не во всех БД
+		queryCreated := tx.Rebind(fmt.Sprintf(`SELECT created_at FROM %s WHERE id = ?`, petImagesTable))
+		if err := tx.QueryRowContext(ctx, queryCreated, image.ID).Scan(&image.CreatedAt); err != nil {
+			return fmt.Errorf("error addPetImage queryCreated, %v", err)
+		}
+
+		// Ссылка на изображение добавляется в конец списка фотографий питомца
+		queryPhoto := tx.Rebind(fmt.Sprintf(`
+		INSERT INTO %s (pet_id, position, url) 
+		SELECT ?, COALESCE(MAX(position) + 1, 0), ? 
+		FROM %s 
+		WHERE pet_id = ?`, petPhotosTable, petPhotosTable))
+		_, err = tx.ExecContext(ctx, queryPhoto, image.PetID, image.URL, image.PetID)
+		if err != nil {
+			return fmt.Errorf("error addPetImage queryPhoto, %v", err)
+		}
+
+		return nil
+	})
+}
+
+// FindPetImage - поиск изображения питомца по имени файла
+func (s *SQLAdapter) FindPetImage(ctx context.Context, petID int, name string) (models.PetImage, error) {
+	query := s.db.Rebind(fmt.Sprintf(`
+	SELECT i.id, i.pet_id, i.name, i.storage_key, COALESCE(i.original_name, ''), i.content_type, i.size, 
+		COALESCE(i.additional_metadata, ''), i.url, i.created_at 
+	FROM %s i
+	JOIN %s p ON p.id = i.pet_id
+	WHERE i.pet_id = ? AND i.name = ? AND p.deleted_at IS NULL`, petImagesTable, petTable))
+
+	var image models.PetImage
+	err := s.db.QueryRowContext(ctx, query, petID, name).Scan(&image.ID, &image.PetID, &image.Name, &image.StorageKey,
+		&image.OriginalName, &image.ContentType, &image.Size, &image.AdditionalMetadata, &image.URL, &image.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.PetImage{}, myerrors.ErrPetImageNotFound
+		}
+		return models.PetImage{}, fmt.Errorf("error findPetImage, %v", err)
+	}
+
+	return image, nil
+}
@@ -0,0 +1,126 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	myerrors "pet-store/internal/infrastructure/errors"
+	"pet-store/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ListTags - список тегов с количеством питомцев, к которым они привязаны
+func (s *SQLAdapter) ListTags(ctx context.Context) ([]models.TagWithCount, error) {
+	query := fmt.Sprintf(`
+	SELECT t.id, COALESCE(t.name, ''), COUNT(p.id) 
+	FROM %s t
+	LEFT JOIN %s pt ON pt.tag_id = t.id
+	LEFT JOIN %s p ON p.id = pt.pet_id AND p.deleted_at IS NULL
+	GROUP BY t.id, t.name
+	ORDER BY t.name`, tagsTable, petTagsTable, petTable)
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error listTags, %v", err)
+	}
+	defer rows.Close()
+
+	tags := []models.TagWithCount{}
+	for rows.Next() {
+		var tag models.TagWithCount
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.PetCount); err != nil {
+			return nil, fmt.Errorf("error listTags scan, %v", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// petsWithTag - условие touchPets для питомцев с тегом
+var petsWithTag = fmt.Sprintf(`id IN (SELECT pet_id FROM %s WHERE tag_id = ?)`, petTagsTable)
+
+// RenameTag - переименование тега, имя должно остаться уникальным. Занятое имя определяет
+// уникальный индекс, как в RenameCategory
+func (s *SQLAdapter) RenameTag(ctx context.Context, tagID int, name string) error {
+	return s.WithTx(ctx, func(tx *sqlx.Tx) error {
+		query := tx.Rebind(fmt.Sprintf(`UPDATE %s SET name = ? WHERE id = ?`, tagsTable))
+		result, err := tx.ExecContext(ctx, query, name, tagID)
+		if err != nil {
+			if s.dialect.uniqueViolation(err) {
+				return myerrors.ErrTagExists
+			}
+			return fmt.Errorf("error renameTag, %v", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return myerrors.ErrTagNotFound
+		}
+
+		if err := s.touchPets(ctx, tx, petsWithTag, tagID); err != nil {
+			return fmt.Errorf("error renameTag touchPets, %v", err)
+		}
+
+		return nil
+	})
+}
+
+// MergeTags - переносит питомцев тега sourceID на тег targetID и удаляет sourceID
+func (s *SQLAdapter) MergeTags(ctx context.Context, sourceID, targetID int) error {
+	return s.WithTx(ctx, func(tx *sqlx.Tx) error {
+		queryLock := tx.Rebind(fmt.Sprintf(`SELECT id FROM %s WHERE id = ?%s`, tagsTable, s.dialect.forUpdate))
+		for _, id := range []int{sourceID, targetID} {
+			var found int
+			if err := tx.QueryRowContext(ctx, queryLock, id).Scan(&found); err != nil {
+				if err == sql.ErrNoRows {
+					return myerrors.ErrTagNotFound
+				}
+				return fmt.Errorf("error mergeTags queryLock, %v", err)
+			}
+		}
+
+		// Питомцы, у которых уже есть оба тега, получают только одну связь
+		queryRepoint := tx.Rebind(s.dialect.ignoreDuplicate(fmt.Sprintf(`
+		INSERT INTO %s (pet_id, tag_id) 
+		SELECT src.pet_id, ? 
+		FROM %s src 
+		WHERE src.tag_id = ?`, petTagsTable, petTagsTable), "pet_id", "tag_id"))
+		if _, err := tx.ExecContext(ctx, queryRepoint, targetID, sourceID); err != nil {
+			return fmt.Errorf("error mergeTags queryRepoint, %v", err)
+		}
+
+		if err := s.touchPets(ctx, tx, petsWithTag, sourceID); err != nil {
+			return fmt.Errorf("error mergeTags touchPets, %v", err)
+		}
+
+		// Связи со старым тегом удаляются каскадно
+		query := tx.Rebind(fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, tagsTable))
+		if _, err := tx.ExecContext(ctx, query, sourceID); err != nil {
+			return fmt.Errorf("error mergeTags, %v", err)
+		}
+
+		return nil
+	})
+}
+
+// DeleteOrphanTags - удаляет теги, не привязанные ни к одному питомцу, и возвращает их количество
+func (s *SQLAdapter) DeleteOrphanTags(ctx context.Context) (int, error) {
+	query := fmt.Sprintf(`
+	DELETE FROM %s 
+	WHERE NOT EXISTS (SELECT 1 FROM %s pt WHERE pt.tag_id = %s.id)`, tagsTable, petTagsTable, tagsTable)
+
+	result, err := s.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("error deleteOrphanTags, %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
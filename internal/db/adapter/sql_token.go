@@ -0,0 +1,71 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RevokeToken - сохраняет идентификатор отозванного токена до истечения его срока действия.
+// Возвращает false, если токен уже был отозван: проверка и отзыв выполняются одной вставкой
+func (s *SQLAdapter) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error) {
+	// Заодно удаляем записи о токенах, срок действия которых уже истёк
+	queryClean := fmt.Sprintf(`DELETE FROM %s WHERE expires_at < %s`, revokedTokensTable, s.dialect.now)
+	if _, err := s.db.ExecContext(ctx, queryClean); err != nil {
+		return false, fmt.Errorf("revoketoken queryClean, %v", err)
+	}
+
+	query := s.db.Rebind(s.dialect.ignoreDuplicate(fmt.Sprintf(`
+	INSERT INTO %s (jti, expires_at)
+	VALUES (?, ?)`, revokedTokensTable), "jti"))
+	result, err := s.db.ExecContext(ctx, query, tokenID, expiresAt)
+	if err != nil {
+		return false, fmt.Errorf("revoketoken query, %v", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("revoketoken rows affected, %v", err)
+	}
+
+	return rows == 1, nil
+}
+
+// IsTokenRevoked - проверяет, отозван ли токен tokenID сам по себе или вместе со всеми токенами
+// пользователя userID, выпущенными раньше issuedAt
+func (s *SQLAdapter) IsTokenRevoked(ctx context.Context, tokenID string, userID int, issuedAt time.Time) (bool, error) {
+	query := s.db.Rebind(fmt.Sprintf(`
+	SELECT EXISTS(SELECT 1 FROM %s WHERE jti = ?)
+		OR EXISTS(SELECT 1 FROM %s WHERE user_id = ? AND tokens_valid_after > ?)`,
+		revokedTokensTable, revokedUsersTable))
+
+	var revoked bool
+	if err := s.db.QueryRowContext(ctx, query, tokenID, userID, issuedAt.UTC()).Scan(&revoked); err != nil {
+		return false, fmt.Errorf("istokenrevoked query, %v", err)
+	}
+
+	return revoked, nil
+}
+
+// RevokeUserTokens - отзывает все токены пользователя, выпущенные раньше at. Время выпуска в токене
+// хранится с точностью до секунды, поэтому и границу округляем вниз до секунды: токены, выпущенные
+// в ту же секунду после отзыва, остаются действительными. Более ранняя граница не заменяет поздней
+func (s *SQLAdapter) RevokeUserTokens(ctx context.Context, userID int, at time.Time) error {
+	at = at.UTC().Truncate(time.Second)
+
+	queryInsert := s.db.Rebind(s.dialect.ignoreDuplicate(fmt.Sprintf(`
+	INSERT INTO %s (user_id, tokens_valid_after)
+	VALUES (?, ?)`, revokedUsersTable), "user_id"))
+	if _, err := s.db.ExecContext(ctx, queryInsert, userID, at); err != nil {
+		return fmt.Errorf("revokeusertokens queryInsert, %v", err)
+	}
+
+	query := s.db.Rebind(fmt.Sprintf(`
+	UPDATE %s 
+	SET tokens_valid_after = ? 
+	WHERE user_id = ? AND tokens_valid_after < ?`, revokedUsersTable))
+	if _, err := s.db.ExecContext(ctx, query, at, userID, at); err != nil {
+		return fmt.Errorf("revokeusertokens query, %v", err)
+	}
+
+	return nil
+}
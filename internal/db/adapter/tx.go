@@ -0,0 +1,29 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// WithTx - выполняет fn в одной транзакции: фиксирует её, если fn вернула nil,
+// и откатывает, если fn вернула ошибку или запаниковала. Ошибка fn возвращается без обёртки,
+// чтобы вызывающий код мог сравнить её с ошибками из пакета errors
+func (s *SQLAdapter) WithTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx, %w", err)
+	}
+	// После успешного Commit откат ничего не делает
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx, %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,276 @@
+package adapter
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"pet-store/internal/infrastructure/db/types"
+	myerrors "pet-store/internal/infrastructure/errors"
+	"pet-store/internal/models"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errInjected = errors.New("connection reset by peer")
+
+func newMockAdapter(t *testing.T) (*SQLAdapter, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return NewSqlAdapter(sqlx.NewDb(db, "postgres")), mock
+}
+
+// step - ожидаемый запрос внутри транзакции: при fail запрос завершается ошибкой
+type step struct {
+	name   string
+	expect func(mock sqlmock.Sqlmock, fail bool)
+}
+
+// expectQuery - запрос, возвращающий одну строку из одной колонки column
+func expectQuery(query, column string, value driver.Value) func(sqlmock.Sqlmock, bool) {
+	return func(mock sqlmock.Sqlmock, fail bool) {
+		e := mock.ExpectQuery(regexp.QuoteMeta(query))
+		if fail {
+			e.WillReturnError(errInjected)
+			return
+		}
+		e.WillReturnRows(sqlmock.NewRows([]string{column}).AddRow(value))
+	}
+}
+
+func expectExec(query string) func(sqlmock.Sqlmock, bool) {
+	return func(mock sqlmock.Sqlmock, fail bool) {
+		e := mock.ExpectExec(regexp.QuoteMeta(query))
+		if fail {
+			e.WillReturnError(errInjected)
+			return
+		}
+		e.WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+}
+
+// runFailures - для каждого шага запускает write, уронив этот шаг, и проверяет,
+// что транзакция откатилась, а последующие запросы не выполнялись
+func runFailures(t *testing.T, before func(mock sqlmock.Sqlmock), steps []step, write func(a *SQLAdapter) error) {
+	for i := range steps {
+		failed := steps[i]
+
+		t.Run("fail at "+failed.name, func(t *testing.T) {
+			a, mock := newMockAdapter(t)
+			if before != nil {
+				before(mock)
+			}
+			mock.ExpectBegin()
+			for _, s := range steps[:i] {
+				s.expect(mock, false)
+			}
+			failed.expect(mock, true)
+			mock.ExpectRollback()
+
+			err := write(a)
+			assert.ErrorContains(t, err, errInjected.Error())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWithTx(t *testing.T) {
+	t.Run("Commit", func(t *testing.T) {
+		a, mock := newMockAdapter(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE pet").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := a.WithTx(context.Background(), func(tx *sqlx.Tx) error {
+			_, err := tx.Exec("UPDATE pet SET status = 'sold'")
+			return err
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rollback keeps error", func(t *testing.T) {
+		a, mock := newMockAdapter(t)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err := a.WithTx(context.Background(), func(tx *sqlx.Tx) error {
+			return errInjected
+		})
+		assert.Equal(t, errInjected, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rollback on panic", func(t *testing.T) {
+		a, mock := newMockAdapter(t)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		assert.Panics(t, func() {
+			_ = a.WithTx(context.Background(), func(tx *sqlx.Tx) error {
+				panic("boom")
+			})
+		})
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Commit error", func(t *testing.T) {
+		a, mock := newMockAdapter(t)
+		mock.ExpectBegin()
+		mock.ExpectCommit().WillReturnError(errInjected)
+
+		err := a.WithTx(context.Background(), func(tx *sqlx.Tx) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, errInjected)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAddPetFailures(t *testing.T) {
+	steps := []step{
+		{"insert pet", expectQuery("INSERT INTO pet (", "id", 7)},
+		{"delete photos", expectExec("DELETE FROM pet_photos")},
+		{"insert photo", expectExec("INSERT INTO pet_photos")},
+		{"upsert tag", expectQuery("INSERT INTO tags", "id", 11)},
+		{"link tag", expectExec("INSERT INTO pet_tags")},
+	}
+	category := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM category WHERE name = $1")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	}
+
+	runFailures(t, category, steps, func(a *SQLAdapter) error {
+		pet := models.Pet{
+			Category:  models.Category{Name: "Dog"},
+			Name:      "Rex",
+			Status:    models.PetStatusAvailable,
+			PhotoUrls: []string{"http://example.com/rex.jpg"},
+			Tags:      []models.Tag{{Name: "calm"}},
+		}
+		err := a.AddPet(context.Background(), &pet)
+		// Питомец не считается созданным, если транзакция откатилась
+		assert.Zero(t, pet.ID)
+		return err
+	})
+}
+
+func TestUpdatePetFailures(t *testing.T) {
+	steps := []step{
+		{"update pet", expectQuery("UPDATE pet", "version", 2)},
+		{"delete photos", expectExec("DELETE FROM pet_photos")},
+		{"insert photo", expectExec("INSERT INTO pet_photos")},
+		{"delete tags", expectExec("DELETE FROM pet_tags")},
+		{"upsert tag", expectQuery("INSERT INTO tags", "id", 11)},
+		{"link tag", expectExec("INSERT INTO pet_tags")},
+	}
+
+	runFailures(t, nil, steps, func(a *SQLAdapter) error {
+		return a.UpdatePet(context.Background(), &models.Pet{
+			ID:        7,
+			Name:      "Rex",
+			PhotoUrls: []string{"http://example.com/rex.jpg"},
+			Tags:      []models.Tag{{Name: "calm"}},
+			Version:   1,
+		})
+	})
+}
+
+func TestCreateOrderFailures(t *testing.T) {
+	steps := []step{
+		{"lock pet", expectQuery("SELECT status", "status", models.PetStatusAvailable)},
+		{"reserve pet", expectExec("UPDATE pet")},
+		{"insert order", expectQuery("INSERT INTO orders", "id", 5)},
+		{"insert history", expectExec("INSERT INTO order_status_history")},
+	}
+
+	runFailures(t, nil, steps, func(a *SQLAdapter) error {
+		_, err := a.CreateOrder(context.Background(), models.Order{PetID: 7, Quantity: 1, Status: "placed"})
+		return err
+	})
+}
+
+func TestUpdatePetVersion(t *testing.T) {
+	update := regexp.QuoteMeta("UPDATE pet")
+	exists := regexp.QuoteMeta("SELECT EXISTS")
+
+	t.Run("Stale version", func(t *testing.T) {
+		a, mock := newMockAdapter(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(update).WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		mock.ExpectQuery(exists).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectRollback()
+
+		pet := models.Pet{ID: 7, Name: "Rex", Version: 1}
+		err := a.UpdatePet(context.Background(), &pet)
+		assert.ErrorIs(t, err, myerrors.ErrPetVersion)
+		assert.Equal(t, 1, pet.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Missing pet", func(t *testing.T) {
+		a, mock := newMockAdapter(t)
+		mock.ExpectQuery(update).WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		mock.ExpectQuery(exists).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		_, err := a.UpdatePetForm(context.Background(), "Rex", "", 7, 1)
+		assert.ErrorIs(t, err, myerrors.ErrPetNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("New version", func(t *testing.T) {
+		a, mock := newMockAdapter(t)
+		mock.ExpectQuery(update).WithArgs("Rex", 7, 1, 1).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+
+		version, err := a.UpdatePetForm(context.Background(), "Rex", "", 7, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPatchPet(t *testing.T) {
+	t.Run("Only set fields", func(t *testing.T) {
+		a, mock := newMockAdapter(t)
+		mock.ExpectBegin()
+		// Очищенные поля записываются как NULL, отсутствующие в запрос не попадают
+		mock.ExpectQuery(regexp.QuoteMeta("SET name = $1, weight = $2, color = $3, version = version + 1")).
+			WithArgs("Rex", nil, nil, 7, 3, 3).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(4))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM pet_tags")).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		patch := models.PetPatch{
+			ID:      7,
+			Version: 3,
+			Name:    types.PatchString{NullString: types.NewNullString("Rex"), Set: true},
+			Color:   types.PatchString{Set: true},
+			Weight:  types.PatchFloat64{Set: true},
+			Tags:    types.Patch[[]models.Tag]{Set: true, Null: true},
+		}
+		err := a.PatchPet(context.Background(), &patch)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, patch.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Stale version", func(t *testing.T) {
+		a, mock := newMockAdapter(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("SET version = version + 1")).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS")).WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectRollback()
+
+		err := a.PatchPet(context.Background(), &models.PetPatch{ID: 7, Version: 2})
+		assert.ErrorIs(t, err, myerrors.ErrPetVersion)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,193 @@
+package memory
+
+import (
+	"context"
+	myerrors "pet-store/internal/infrastructure/errors"
+	"pet-store/internal/models"
+	"sort"
+)
+
+// CreateCategory - создание категории с уникальным именем
+func (s *Store) CreateCategory(ctx context.Context, name string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.categoryByName(name) != 0 {
+		return 0, myerrors.ErrCategoryExists
+	}
+	s.categorySeq++
+	s.categories[s.categorySeq] = name
+
+	return s.categorySeq, nil
+}
+
+// ListCategories - категории в порядке имён с количеством не удалённых питомцев
+func (s *Store) ListCategories(ctx context.Context) ([]models.CategoryWithCount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	categories := []models.CategoryWithCount{}
+	for id, name := range s.categories {
+		category := models.CategoryWithCount{Category: models.Category{ID: id, Name: name}}
+		for _, row := range s.pets {
+			if row.categoryID == id && !row.deleted() {
+				category.PetCount++
+			}
+		}
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].Name < categories[j].Name })
+
+	return categories, nil
+}
+
+// RenameCategory - переименование категории, имя должно остаться уникальным
+func (s *Store) RenameCategory(ctx context.Context, categoryID int, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id := s.categoryByName(name); id != 0 && id != categoryID {
+		return myerrors.ErrCategoryExists
+	}
+	if _, ok := s.categories[categoryID]; !ok {
+		return myerrors.ErrCategoryNotFound
+	}
+	s.categories[categoryID] = name
+	for _, row := range s.pets {
+		if row.categoryID == categoryID {
+			row.pet.Version++
+		}
+	}
+
+	return nil
+}
+
+// DeleteCategory - удаление категории с семантикой SQLAdapter.DeleteCategory: питомцы, в том числе
+// удалённые, переносятся в reassignTo, а при reassignTo = 0 удаление отклоняется
+func (s *Store) DeleteCategory(ctx context.Context, categoryID, reassignTo int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.categories[categoryID]; !ok {
+		return myerrors.ErrCategoryNotFound
+	}
+	if reassignTo == 0 {
+		for _, row := range s.pets {
+			if row.categoryID == categoryID {
+				return myerrors.ErrCategoryInUse
+			}
+		}
+	} else {
+		if _, ok := s.categories[reassignTo]; !ok {
+			return myerrors.ErrReassignCategoryNotFound
+		}
+		for _, row := range s.pets {
+			if row.categoryID == categoryID {
+				row.categoryID = reassignTo
+				row.pet.Version++
+			}
+		}
+	}
+	delete(s.categories, categoryID)
+
+	return nil
+}
+
+// ListTags - теги в порядке имён с количеством не удалённых питомцев
+func (s *Store) ListTags(ctx context.Context) ([]models.TagWithCount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tags := []models.TagWithCount{}
+	for id, name := range s.tags {
+		tag := models.TagWithCount{Tag: models.Tag{ID: id, Name: name}}
+		for _, row := range s.pets {
+			if !row.deleted() && containsInt(row.tagIDs, id) {
+				tag.PetCount++
+			}
+		}
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+
+	return tags, nil
+}
+
+// RenameTag - переименование тега, имя должно остаться уникальным
+func (s *Store) RenameTag(ctx context.Context, tagID int, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id := s.tagByName(name); id != 0 && id != tagID {
+		return myerrors.ErrTagExists
+	}
+	if _, ok := s.tags[tagID]; !ok {
+		return myerrors.ErrTagNotFound
+	}
+	s.tags[tagID] = name
+	for _, row := range s.pets {
+		if containsInt(row.tagIDs, tagID) {
+			row.pet.Version++
+		}
+	}
+
+	return nil
+}
+
+// MergeTags - переносит питомцев тега sourceID на тег targetID и удаляет sourceID
+func (s *Store) MergeTags(ctx context.Context, sourceID, targetID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range []int{sourceID, targetID} {
+		if _, ok := s.tags[id]; !ok {
+			return myerrors.ErrTagNotFound
+		}
+	}
+	for _, row := range s.pets {
+		if !containsInt(row.tagIDs, sourceID) {
+			continue
+		}
+		// Питомцы, у которых уже есть оба тега, получают только одну связь
+		row.tagIDs = removeInt(row.tagIDs, sourceID)
+		if !containsInt(row.tagIDs, targetID) {
+			row.tagIDs = append(row.tagIDs, targetID)
+		}
+		row.pet.Version++
+	}
+	delete(s.tags, sourceID)
+
+	return nil
+}
+
+// DeleteOrphanTags - удаляет теги, не привязанные ни к одному питомцу, и возвращает их количество
+func (s *Store) DeleteOrphanTags(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	linked := make(map[int]bool)
+	for _, row := range s.pets {
+		for _, id := range row.tagIDs {
+			linked[id] = true
+		}
+	}
+	var deleted int
+	for id := range s.tags {
+		if !linked[id] {
+			delete(s.tags, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func removeInt(list []int, value int) []int {
+	result := list[:0]
+	for _, item := range list {
+		if item != value {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,13 @@
+package memory_test
+
+import (
+	"pet-store/internal/storages"
+	"pet-store/internal/storages/storagetest"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) *storages.Storages {
+		return storages.NewMemoryStorages(nil)
+	})
+}
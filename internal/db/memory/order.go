@@ -0,0 +1,192 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	myerrors "pet-store/internal/infrastructure/errors"
+	"pet-store/internal/models"
+	"sort"
+)
+
+// CreateOrder - создаёт заказ, резервируя доступного питомца, и пишет первую запись истории
+func (s *Store) CreateOrder(ctx context.Context, order models.Order) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row, ok := s.pets[order.PetID]
+	if !ok || row.deleted() {
+		return 0, myerrors.ErrPetNotFound
+	}
+	if row.pet.Status != models.PetStatusAvailable {
+		return 0, myerrors.ErrPetNotAvailable
+	}
+	row.pet.Status = models.PetStatusPending
+	row.pet.Version++
+
+	s.orderSeq++
+	order.ID = s.orderSeq
+	s.orders[order.ID] = order
+	s.addHistory(order.ID, "", order.Status, order.UserID)
+
+	return order.ID, nil
+}
+
+// FindOrderByID - заказ по id. Как и SQL-адаптер, при отсутствии заказа возвращает sql.ErrNoRows
+func (s *Store) FindOrderByID(ctx context.Context, orderID int) (models.Order, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	order, ok := s.orders[orderID]
+	if !ok {
+		return models.Order{}, sql.ErrNoRows
+	}
+
+	return order, nil
+}
+
+// DeleteOrderByID - удаляет заказ вместе с его историей, освобождая питомца незавершённого заказа
+func (s *Store) DeleteOrderByID(ctx context.Context, orderID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[orderID]
+	if !ok {
+		return myerrors.ErrOrderNotFound
+	}
+	if row, ok := s.pets[order.PetID]; ok && models.OrderReservesPet(order.Status) && row.pet.Status == models.PetStatusPending {
+		row.pet.Status = models.PetStatusAvailable
+		row.pet.Version++
+	}
+	delete(s.orders, orderID)
+
+	history := s.history[:0]
+	for _, change := range s.history {
+		if change.OrderID != orderID {
+			history = append(history, change)
+		}
+	}
+	s.history = history
+
+	return nil
+}
+
+// FindOrders - заказы пользователя с фильтрами по статусу и дате доставки, новые первыми
+func (s *Store) FindOrders(ctx context.Context, filter models.OrderFilter) ([]models.Order, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	orders := make([]models.Order, 0, filter.Limit)
+	for _, order := range s.orders {
+		// Заказ без пользователя (user_id IS NULL) не подходит ни под один фильтр
+		if order.UserID == 0 || order.UserID != filter.UserID {
+			continue
+		}
+		if len(filter.Statuses) != 0 && !contains(filter.Statuses, order.Status) {
+			continue
+		}
+		if !filter.ShipDateFrom.IsZero() && order.ShipDate.Before(filter.ShipDateFrom) {
+			continue
+		}
+		if !filter.ShipDateTo.IsZero() && order.ShipDate.After(filter.ShipDateTo) {
+			continue
+		}
+		orders = append(orders, order)
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID > orders[j].ID })
+
+	if filter.Offset >= len(orders) {
+		return orders[:0], nil
+	}
+	orders = orders[filter.Offset:]
+	if len(orders) > filter.Limit {
+		orders = orders[:filter.Limit]
+	}
+
+	return orders, nil
+}
+
+// Inventory - количество не удалённых питомцев в каждом статусе
+func (s *Store) Inventory(ctx context.Context) (map[string]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	inventory := make(map[string]int)
+	for _, row := range s.pets {
+		if !row.deleted() {
+			inventory[row.pet.Status]++
+		}
+	}
+
+	return inventory, nil
+}
+
+// InventoryByCategory - количество не удалённых питомцев в каждом статусе с разбивкой по категориям
+func (s *Store) InventoryByCategory(ctx context.Context) (map[string]map[string]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	inventory := make(map[string]map[string]int)
+	for _, row := range s.pets {
+		if row.deleted() {
+			continue
+		}
+		category := s.categories[row.categoryID]
+		if inventory[category] == nil {
+			inventory[category] = make(map[string]int)
+		}
+		inventory[category][row.pet.Status]++
+	}
+
+	return inventory, nil
+}
+
+// UpdateOrderStatus - переводит заказ из статуса from в статус to с семантикой SQLAdapter.UpdateOrderStatus
+func (s *Store) UpdateOrderStatus(ctx context.Context, orderID int, from, to string, complete bool, changedBy int, petStatus string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[orderID]
+	if !ok || order.Status != from {
+		return myerrors.ErrOrderStatusStale
+	}
+	order.Status = to
+	order.Complete = complete
+	s.orders[orderID] = order
+
+	if petStatus != "" {
+		if row, ok := s.pets[order.PetID]; ok {
+			row.pet.Status = petStatus
+			row.pet.Version++
+		}
+	}
+	s.addHistory(orderID, from, to, changedBy)
+
+	return nil
+}
+
+// FindOrderHistory - история смены статусов заказа в хронологическом порядке
+func (s *Store) FindOrderHistory(ctx context.Context, orderID int) ([]models.OrderStatusChange, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var history []models.OrderStatusChange
+	for _, change := range s.history {
+		if change.OrderID == orderID {
+			history = append(history, change)
+		}
+	}
+
+	return history, nil
+}
+
+func (s *Store) addHistory(orderID int, from, to string, changedBy int) {
+	s.historySeq++
+	s.history = append(s.history, models.OrderStatusChange{
+		ID:         s.historySeq,
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+		ChangedBy:  changedBy,
+		ChangedAt:  s.timestamp(),
+	})
+}
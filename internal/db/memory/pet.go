@@ -0,0 +1,587 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	myerrors "pet-store/internal/infrastructure/errors"
+	"pet-store/internal/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AddPet - добавление питомца в существующую категорию, повторяющиеся теги связываются один раз
+func (s *Store) AddPet(ctx context.Context, pet *models.Pet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	categoryID := s.categoryByName(pet.Category.Name)
+	if categoryID == 0 {
+		return myerrors.ErrCategoryNotFound
+	}
+	if err := s.checkMicrochip(pet.MicrochipID, 0); err != nil {
+		return err
+	}
+
+	s.petSeq++
+	row := &petRow{
+		pet: models.Pet{
+			ID:          s.petSeq,
+			Name:        pet.Name,
+			Status:      pet.Status,
+			CreatedAt:   s.timestamp(),
+			Version:     1,
+			Breed:       pet.Breed,
+			BirthDate:   birthDate(pet.BirthDate),
+			Sex:         pet.Sex,
+			Weight:      pet.Weight,
+			Color:       pet.Color,
+			MicrochipID: pet.MicrochipID,
+			Description: pet.Description,
+			PhotoUrls:   append([]string{}, pet.PhotoUrls...),
+		},
+		categoryID: categoryID,
+	}
+	s.linkTags(row, pet.Tags)
+	s.pets[row.pet.ID] = row
+
+	pet.ID = row.pet.ID
+	return nil
+}
+
+// UpdatePet - обновляет питомца с семантикой SQLAdapter.UpdatePet: пустые поля не меняются,
+// фотографии заменяются, если список передан, теги - если список не пустой
+func (s *Store) UpdatePet(ctx context.Context, pet *models.Pet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var categoryID int
+	if pet.Category.Name != "" {
+		categoryID = s.categoryByName(pet.Category.Name)
+		if categoryID == 0 {
+			return myerrors.ErrCategoryNotFound
+		}
+	}
+	if err := s.checkMicrochip(pet.MicrochipID, pet.ID); err != nil {
+		return err
+	}
+	row, err := s.versionedPet(pet.ID, pet.Version)
+	if err != nil {
+		return err
+	}
+
+	p := &row.pet
+	keep(&p.Name, pet.Name)
+	keep(&p.Status, pet.Status)
+	keep(&p.Breed, pet.Breed)
+	keep(&p.BirthDate, birthDate(pet.BirthDate))
+	keep(&p.Sex, pet.Sex)
+	keep(&p.Color, pet.Color)
+	keep(&p.MicrochipID, pet.MicrochipID)
+	keep(&p.Description, pet.Description)
+	if pet.Weight != 0 {
+		p.Weight = pet.Weight
+	}
+	if categoryID != 0 {
+		row.categoryID = categoryID
+	}
+	if pet.PhotoUrls != nil {
+		p.PhotoUrls = append([]string{}, pet.PhotoUrls...)
+	}
+	if len(pet.Tags) != 0 {
+		row.tagIDs = nil
+		s.linkTags(row, pet.Tags)
+	}
+	p.Version++
+
+	pet.Version = p.Version
+	return nil
+}
+
+// keep - записывает value в field, если value не пустое
+func keep(field *string, value string) {
+	if value != "" {
+		*field = value
+	}
+}
+
+// PatchPet - частично обновляет питомца: меняются только поля патча с Set
+func (s *Store) PatchPet(ctx context.Context, patch *models.PetPatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var categoryID int
+	if patch.Category.Set {
+		categoryID = s.categoryByName(patch.Category.String)
+		if categoryID == 0 {
+			return myerrors.ErrCategoryNotFound
+		}
+	}
+	if patch.MicrochipID.Set {
+		if err := s.checkMicrochip(patch.MicrochipID.String, patch.ID); err != nil {
+			return err
+		}
+	}
+	row, err := s.versionedPet(patch.ID, patch.Version)
+	if err != nil {
+		return err
+	}
+
+	p := &row.pet
+	fields := []struct {
+		set   bool
+		field *string
+		value string
+	}{
+		{patch.Name.Set, &p.Name, patch.Name.String},
+		{patch.Status.Set, &p.Status, patch.Status.String},
+		{patch.Breed.Set, &p.Breed, patch.Breed.String},
+		{patch.BirthDate.Set, &p.BirthDate, birthDate(patch.BirthDate.String)},
+		{patch.Sex.Set, &p.Sex, patch.Sex.String},
+		{patch.Color.Set, &p.Color, patch.Color.String},
+		{patch.MicrochipID.Set, &p.MicrochipID, patch.MicrochipID.String},
+		{patch.Description.Set, &p.Description, patch.Description.String},
+	}
+	for _, f := range fields {
+		if f.set {
+			*f.field = f.value
+		}
+	}
+	if patch.Weight.Set {
+		p.Weight = patch.Weight.Float64
+	}
+	if categoryID != 0 {
+		row.categoryID = categoryID
+	}
+	if patch.PhotoUrls.Set {
+		p.PhotoUrls = append([]string{}, patch.PhotoUrls.Value...)
+	}
+	if patch.Tags.Set {
+		row.tagIDs = nil
+		s.linkTags(row, patch.Tags.Value)
+	}
+	p.Version++
+
+	patch.Version = p.Version
+	return nil
+}
+
+// UpdatePetForm - меняет непустые имя и статус питомца и возвращает его новую версию
+func (s *Store) UpdatePetForm(ctx context.Context, name, status string, petID, version int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row, err := s.versionedPet(petID, version)
+	if err != nil {
+		return 0, err
+	}
+	keep(&row.pet.Name, name)
+	keep(&row.pet.Status, status)
+	row.pet.Version++
+
+	return row.pet.Version, nil
+}
+
+func (s *Store) FindPetbyID(ctx context.Context, petID int) (models.Pet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	row, ok := s.pets[petID]
+	if !ok || row.deleted() {
+		return models.Pet{}, myerrors.ErrPetNotFound
+	}
+
+	return s.petView(row), nil
+}
+
+func (s *Store) FindPetbyStatus(ctx context.Context, statuses []string, page models.PetPage) ([]models.Pet, error) {
+	return s.SearchPets(ctx, models.PetFilter{Statuses: statuses}, page)
+}
+
+// FindPetbyTags - питомцы хотя бы с одним (matchAll = false) или со всеми тегами в порядке id
+func (s *Store) FindPetbyTags(ctx context.Context, tags []string, matchAll bool) ([]models.Pet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pets := []models.Pet{}
+	for _, row := range s.pets {
+		if !row.deleted() && s.hasTags(row, tags, matchAll) {
+			pets = append(pets, s.petView(row))
+		}
+	}
+	sort.Slice(pets, func(i, j int) bool { return pets[i].ID < pets[j].ID })
+
+	return pets, nil
+}
+
+// SearchPets - страница питомцев, подходящих под все условия фильтра, с семантикой SQLAdapter.SearchPets
+func (s *Store) SearchPets(ctx context.Context, filter models.PetFilter, page models.PetPage) ([]models.Pet, error) {
+	var text textQuery
+	if filter.Query != "" {
+		text = parseTextQuery(filter.Query)
+	} else if page.Sort == models.PetSortRank {
+		return nil, fmt.Errorf("sort by rank requires a text query")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pets := []models.Pet{}
+	for _, row := range s.pets {
+		if row.deleted() || !s.matchesFilter(row, filter) {
+			continue
+		}
+		pet := s.petView(row)
+		if filter.Query != "" {
+			doc := s.petDocument(row)
+			if !text.match(doc) {
+				continue
+			}
+			pet.Rank = text.rank(doc)
+			pet.Highlight = text.highlight(doc.text)
+		}
+		if page.After != nil && !afterCursor(pet, page) {
+			continue
+		}
+		pets = append(pets, pet)
+	}
+
+	sort.Slice(pets, func(i, j int) bool {
+		c := comparePets(pets[i], pets[j], page.Sort)
+		if page.Desc {
+			return c > 0
+		}
+		return c < 0
+	})
+	if page.Limit > 0 && len(pets) > page.Limit {
+		pets = pets[:page.Limit]
+	}
+
+	return pets, nil
+}
+
+// matchesFilter - питомец подходит под все условия фильтра, кроме полнотекстового запроса.
+// Пустое необязательное поле питомца, как NULL в SQL, не подходит ни под одно условие на это поле
+func (s *Store) matchesFilter(row *petRow, filter models.PetFilter) bool {
+	p := row.pet
+	if filter.Name != "" && !strings.Contains(strings.ToLower(p.Name), strings.ToLower(filter.Name)) {
+		return false
+	}
+	if filter.Category != "" && s.categories[row.categoryID] != filter.Category {
+		return false
+	}
+	if len(filter.Statuses) > 0 && !contains(filter.Statuses, p.Status) {
+		return false
+	}
+	if len(filter.Tags) > 0 && !s.hasTags(row, filter.Tags, filter.MatchAllTags) {
+		return false
+	}
+	if !filter.CreatedFrom.IsZero() && p.CreatedAt.Before(filter.CreatedFrom) {
+		return false
+	}
+	if !filter.CreatedTo.IsZero() && !p.CreatedAt.Before(filter.CreatedTo) {
+		return false
+	}
+	if filter.Breed != "" && (p.Breed == "" || !strings.EqualFold(p.Breed, filter.Breed)) {
+		return false
+	}
+	if filter.Sex != "" && p.Sex != filter.Sex {
+		return false
+	}
+	if filter.Color != "" && (p.Color == "" || !strings.EqualFold(p.Color, filter.Color)) {
+		return false
+	}
+	if filter.MicrochipID != "" && p.MicrochipID != filter.MicrochipID {
+		return false
+	}
+	if !filter.BornFrom.IsZero() || !filter.BornTo.IsZero() {
+		born, err := time.Parse(models.PetDateLayout, p.BirthDate)
+		if err != nil {
+			return false
+		}
+		if !filter.BornFrom.IsZero() && born.Before(filter.BornFrom) {
+			return false
+		}
+		if !filter.BornTo.IsZero() && born.After(filter.BornTo) {
+			return false
+		}
+	}
+	if filter.WeightMin > 0 && (p.Weight == 0 || p.Weight < filter.WeightMin) {
+		return false
+	}
+	if filter.WeightMax > 0 && (p.Weight == 0 || p.Weight > filter.WeightMax) {
+		return false
+	}
+
+	return true
+}
+
+// hasTags - у питомца есть хотя бы один из тегов или, при matchAll, столько разных тегов из списка,
+// сколько имён в списке
+func (s *Store) hasTags(row *petRow, names []string, matchAll bool) bool {
+	matched := 0
+	for _, id := range row.tagIDs {
+		if contains(names, s.tags[id]) {
+			matched++
+		}
+	}
+	if matchAll {
+		return matched == len(names)
+	}
+
+	return matched > 0
+}
+
+// comparePets - порядок питомцев по ключу сортировки, при равных ключах - по id
+func comparePets(a, b models.Pet, sortKey string) int {
+	var c int
+	switch sortKey {
+	case models.PetSortName:
+		c = strings.Compare(a.Name, b.Name)
+	case models.PetSortCreated:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	case models.PetSortRank:
+		c = compareFloat(a.Rank, b.Rank)
+	}
+	if c != 0 {
+		return c
+	}
+
+	return compareInt(a.ID, b.ID)
+}
+
+// afterCursor - питомец идёт после курсора page.After в порядке страницы
+func afterCursor(pet models.Pet, page models.PetPage) bool {
+	cursor := models.Pet{
+		ID:        page.After.ID,
+		Name:      page.After.Name,
+		CreatedAt: page.After.CreatedAt,
+		Rank:      page.After.Rank,
+	}
+	c := comparePets(pet, cursor, page.Sort)
+	if page.Desc {
+		return c < 0
+	}
+
+	return c > 0
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// DeletePet - мягкое удаление питомца
+func (s *Store) DeletePet(ctx context.Context, petID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row, ok := s.pets[petID]
+	if !ok || row.deleted() {
+		return myerrors.ErrPetNotFound
+	}
+	row.deletedAt = s.timestamp()
+	row.pet.Version++
+
+	return nil
+}
+
+// RestorePet - восстановление мягко удалённого питомца
+func (s *Store) RestorePet(ctx context.Context, petID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row, ok := s.pets[petID]
+	if !ok || !row.deleted() {
+		return myerrors.ErrPetNotFound
+	}
+	row.deletedAt = time.Time{}
+	row.pet.Version++
+
+	return nil
+}
+
+// PurgeDeletedPets - окончательное удаление питомцев, удалённых раньше deletedBefore, вместе с их изображениями.
+// Возвращает число удалённых и ключи их изображений
+func (s *Store) PurgeDeletedPets(ctx context.Context, deletedBefore time.Time) (int, []string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int
+	for id, row := range s.pets {
+		if row.deleted() && row.deletedAt.Before(deletedBefore) {
+			delete(s.pets, id)
+			purged++
+		}
+	}
+	var keys []string
+	for id, image := range s.images {
+		if _, ok := s.pets[image.PetID]; !ok {
+			keys = append(keys, image.StorageKey)
+			delete(s.images, id)
+		}
+	}
+
+	return purged, keys, nil
+}
+
+// AddPetImage - сохраняет изображение, добавляет его ссылку в конец фотографий питомца и повышает версию питомца
+func (s *Store) AddPetImage(ctx context.Context, image *models.PetImage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row, ok := s.pets[image.PetID]
+	if !ok || row.deleted() {
+		return myerrors.ErrPetNotFound
+	}
+	for _, existing := range s.images {
+		if existing.Name == image.Name {
+			return fmt.Errorf("error addPetImage, image %s already exists", image.Name)
+		}
+	}
+
+	s.imageSeq++
+	image.ID = s.imageSeq
+	image.CreatedAt = s.timestamp()
+	s.images[image.ID] = *image
+	row.pet.PhotoUrls = append(row.pet.PhotoUrls, image.URL)
+	row.pet.Version++
+
+	return nil
+}
+
+// FindPetImage - изображение не удалённого питомца по имени файла
+func (s *Store) FindPetImage(ctx context.Context, petID int, name string) (models.PetImage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	row, ok := s.pets[petID]
+	if !ok || row.deleted() {
+		return models.PetImage{}, myerrors.ErrPetImageNotFound
+	}
+	for _, image := range s.images {
+		if image.PetID == petID && image.Name == name {
+			return image, nil
+		}
+	}
+
+	return models.PetImage{}, myerrors.ErrPetImageNotFound
+}
+
+// versionedPet - не удалённый питомец для изменения: ErrPetNotFound, если его нет,
+// ErrPetVersion, если version не 0 и не равна его текущей версии
+func (s *Store) versionedPet(petID, version int) (*petRow, error) {
+	row, ok := s.pets[petID]
+	if !ok || row.deleted() {
+		return nil, myerrors.ErrPetNotFound
+	}
+	if version != 0 && version != row.pet.Version {
+		return nil, myerrors.ErrPetVersion
+	}
+
+	return row, nil
+}
+
+// checkMicrochip - ErrMicrochipExists, если номер чипа уже принадлежит другому питомцу, в том числе удалённому
+func (s *Store) checkMicrochip(microchipID string, petID int) error {
+	if microchipID == "" {
+		return nil
+	}
+	for id, row := range s.pets {
+		if id != petID && row.pet.MicrochipID == microchipID {
+			return myerrors.ErrMicrochipExists
+		}
+	}
+
+	return nil
+}
+
+// linkTags - привязывает к питомцу теги по именам, создавая недостающие. Повторы не дублируются
+func (s *Store) linkTags(row *petRow, tags []models.Tag) {
+	for _, tag := range tags {
+		id := s.tagByName(tag.Name)
+		if id == 0 {
+			s.tagSeq++
+			id = s.tagSeq
+			s.tags[id] = tag.Name
+		}
+		if !containsInt(row.tagIDs, id) {
+			row.tagIDs = append(row.tagIDs, id)
+		}
+	}
+}
+
+// petView - копия питомца с категорией, тегами в порядке id и фотографиями
+func (s *Store) petView(row *petRow) models.Pet {
+	pet := row.pet
+	pet.Category = models.Category{ID: row.categoryID, Name: s.categories[row.categoryID]}
+	pet.PhotoUrls = append([]string{}, row.pet.PhotoUrls...)
+
+	ids := append([]int{}, row.tagIDs...)
+	sort.Ints(ids)
+	pet.Tags = make([]models.Tag, 0, len(ids))
+	for _, id := range ids {
+		pet.Tags = append(pet.Tags, models.Tag{ID: id, Name: s.tags[id]})
+	}
+
+	return pet
+}
+
+func (s *Store) categoryByName(name string) int {
+	for id, category := range s.categories {
+		if category == name {
+			return id
+		}
+	}
+	return 0
+}
+
+func (s *Store) tagByName(name string) int {
+	for id, tag := range s.tags {
+		if tag == name {
+			return id
+		}
+	}
+	return 0
+}
+
+// birthDate - дата рождения в формате PetDateLayout или пустая строка, если дата не разбирается
+func birthDate(date string) string {
+	if _, err := time.Parse(models.PetDateLayout, date); err != nil {
+		return ""
+	}
+	return date
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(list []int, value int) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"pet-store/internal/models"
+	"sync"
+	"time"
+)
+
+// Store - хранилище всех данных приложения в памяти процесса, замена SQLAdapter для запуска без БД.
+// Реализует хранилища питомцев, заказов, пользователей, категорий и тегов и повторяет поведение
+// SQL-адаптера: те же ошибки, мягкое удаление, версии питомцев, уникальность имён.
+// Каждый метод выполняется под одной блокировкой, поэтому изменения из нескольких шагов атомарны,
+// как транзакции в БД
+type Store struct {
+	mu  sync.RWMutex
+	now func() time.Time
+
+	pets       map[int]*petRow
+	categories map[int]string
+	tags       map[int]string
+	images     map[int]models.PetImage
+	orders     map[int]models.Order
+	history    []models.OrderStatusChange
+	users      map[int]models.UserDTO
+
+	// Последние выданные идентификаторы, как у serial-колонок
+	petSeq, categorySeq, tagSeq, imageSeq, orderSeq, historySeq, userSeq int
+}
+
+// petRow - строка питомца: категория и теги хранятся ссылками, как в таблицах pet и pet_tags.
+// Пустые строки и нулевой вес в pet соответствуют NULL
+type petRow struct {
+	pet        models.Pet
+	categoryID int
+	tagIDs     []int
+	deletedAt  time.Time
+}
+
+func (r *petRow) deleted() bool {
+	return !r.deletedAt.IsZero()
+}
+
+// NewStore - конструктор пустого хранилища в памяти
+func NewStore() *Store {
+	return &Store{
+		now:        time.Now,
+		pets:       make(map[int]*petRow),
+		categories: make(map[int]string),
+		tags:       make(map[int]string),
+		images:     make(map[int]models.PetImage),
+		orders:     make(map[int]models.Order),
+		users:      make(map[int]models.UserDTO),
+	}
+}
+
+// timestamp - текущее время с точностью колонки TIMESTAMP
+func (s *Store) timestamp() time.Time {
+	return s.now().UTC().Truncate(time.Microsecond)
+}
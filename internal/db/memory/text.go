@@ -0,0 +1,215 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Полнотекстовый поиск повторяет конфигурацию 'simple' из миграции pet_search: слова приводятся
+// к нижнему регистру без стемминга, совпадение в имени весит больше, чем в категории, а в категории -
+// больше, чем в тегах. Релевантность сохраняет порядок, но не точные значения ts_rank
+
+// Веса частей документа, как у ts_rank для весов A, B и C
+const (
+	weightName     = 1.0
+	weightCategory = 0.4
+	weightTags     = 0.2
+)
+
+// textDocument - документ поиска питомца: исходный текст для подсветки и слова с весами по порядку
+type textDocument struct {
+	text  string
+	words []textWord
+}
+
+type textWord struct {
+	word   string
+	weight float64
+}
+
+// textTerm - слово или фраза запроса, not - слово или фраза исключены через "-"
+type textTerm struct {
+	words []string
+	not   bool
+}
+
+// textQuery - запрос в синтаксисе websearch_to_tsquery: группы, разделённые or,
+// внутри группы должны выполняться все условия
+type textQuery [][]textTerm
+
+// petDocument - документ питомца: имя, категория и теги в порядке имён, как search_text в БД
+func (s *Store) petDocument(row *petRow) textDocument {
+	tags := make([]string, 0, len(row.tagIDs))
+	for _, id := range row.tagIDs {
+		tags = append(tags, s.tags[id])
+	}
+	sort.Strings(tags)
+
+	var doc textDocument
+	var parts []string
+	add := func(text string, weight float64) {
+		if text == "" {
+			return
+		}
+		parts = append(parts, text)
+		for _, word := range textWords(text) {
+			doc.words = append(doc.words, textWord{word: word, weight: weight})
+		}
+	}
+	add(row.pet.Name, weightName)
+	add(s.categories[row.categoryID], weightCategory)
+	for _, tag := range tags {
+		add(tag, weightTags)
+	}
+	doc.text = strings.Join(parts, " ")
+
+	return doc
+}
+
+// parseTextQuery - разбирает запрос: слова, фразы в кавычках, or между условиями и "-" перед исключёнными
+func parseTextQuery(query string) textQuery {
+	var q textQuery
+	var group []textTerm
+	add := func(text string, not bool) {
+		if words := textWords(text); len(words) > 0 {
+			group = append(group, textTerm{words: words, not: not})
+		}
+	}
+
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		switch {
+		case unicode.IsSpace(runes[i]):
+			i++
+		case runes[i] == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			add(string(runes[i+1:end]), false)
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+				end++
+			}
+			token := string(runes[i:end])
+			i = end
+			switch {
+			case strings.EqualFold(token, "or"):
+				if len(group) > 0 {
+					q = append(q, group)
+					group = nil
+				}
+			case strings.HasPrefix(token, "-"):
+				add(token[1:], true)
+			default:
+				add(token, false)
+			}
+		}
+	}
+	if len(group) > 0 {
+		q = append(q, group)
+	}
+
+	return q
+}
+
+// textWords - слова текста в нижнем регистре, разделители - всё, кроме букв и цифр
+func textWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// match - документ подходит хотя бы под одну группу запроса. Пустой запрос не находит ничего
+func (q textQuery) match(doc textDocument) bool {
+	for _, group := range q {
+		matched := true
+		for _, term := range group {
+			if (len(term.positions(doc)) > 0) == term.not {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rank - сумма весов вхождений искомых слов и фраз
+func (q textQuery) rank(doc textDocument) float64 {
+	var rank float64
+	for _, group := range q {
+		for _, term := range group {
+			if term.not {
+				continue
+			}
+			for _, pos := range term.positions(doc) {
+				rank += doc.words[pos].weight
+			}
+		}
+	}
+
+	// ts_rank возвращает real, поэтому значение округляется до float32
+	return float64(float32(rank))
+}
+
+// highlight - текст документа, в котором искомые слова обёрнуты в <mark>, как у ts_headline с HighlightAll
+func (q textQuery) highlight(text string) string {
+	words := make(map[string]bool)
+	for _, group := range q {
+		for _, term := range group {
+			for _, word := range term.words {
+				if !term.not {
+					words[word] = true
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		end := i
+		for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end])) {
+			end++
+		}
+		if end == i {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		word := string(runes[i:end])
+		if words[strings.ToLower(word)] {
+			word = "<mark>" + word + "</mark>"
+		}
+		b.WriteString(word)
+		i = end
+	}
+
+	return b.String()
+}
+
+// positions - позиции документа, с которых начинается слово или фраза
+func (t textTerm) positions(doc textDocument) []int {
+	var found []int
+	for start := 0; start+len(t.words) <= len(doc.words); start++ {
+		matched := true
+		for i, word := range t.words {
+			if doc.words[start+i].word != word {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			found = append(found, start)
+		}
+	}
+
+	return found
+}
@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	myerrors "pet-store/internal/infrastructure/errors"
+	"pet-store/internal/models"
+)
+
+// Create - создание пользователя. Имя пользователя и email уникальны, пароль обязателен
+func (s *Store) Create(ctx context.Context, u models.UserDTO) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !u.UserName.Valid || !u.Email.Valid || !u.Password.Valid {
+		return 0, fmt.Errorf("username, email and password are required")
+	}
+	for _, user := range s.users {
+		if user.UserName.String == u.UserName.String {
+			return 0, fmt.Errorf("user with username %s already exists", u.UserName.String)
+		}
+		if user.Email.String == u.Email.String {
+			return 0, fmt.Errorf("user with email %s already exists", u.Email.String)
+		}
+	}
+
+	s.userSeq++
+	u.ID = s.userSeq
+	u.Status = 0
+	s.users[u.ID] = u
+
+	return u.ID, nil
+}
+
+func (s *Store) GetByEmail(ctx context.Context, email string) (models.UserDTO, error) {
+	return s.findUser(func(u models.UserDTO) bool { return u.Email.String == email }, "email")
+}
+
+func (s *Store) GetByUsername(ctx context.Context, username string) (models.UserDTO, error) {
+	return s.findUser(func(u models.UserDTO) bool { return u.UserName.String == username }, "username")
+}
+
+func (s *Store) GetByID(ctx context.Context, id int) (models.UserDTO, error) {
+	return s.findUser(func(u models.UserDTO) bool { return u.ID == id }, "id")
+}
+
+// findUser - пользователь, подходящий под match. Как и SQL-адаптер, при отсутствии
+// пользователя возвращает обёрнутую sql.ErrNoRows
+func (s *Store) findUser(match func(u models.UserDTO) bool, by string) (models.UserDTO, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, user := range s.users {
+		if match(user) {
+			return user, nil
+		}
+	}
+
+	return models.UserDTO{}, fmt.Errorf("failed to get user by %s: %w", by, sql.ErrNoRows)
+}
+
+// UpdateUser - меняет имя, фамилию, телефон и пароль пользователя
+func (s *Store) UpdateUser(ctx context.Context, u models.UserDTO) error {
+	return s.updateUser(u.UserName.String, func(user *models.UserDTO) {
+		user.FirstName = u.FirstName
+		user.LastName = u.LastName
+		user.Phone = u.Phone
+		user.Password = u.Password
+	})
+}
+
+// PatchUser - меняет только поля патча с Set
+func (s *Store) PatchUser(ctx context.Context, patch models.UserPatch) error {
+	return s.updateUser(patch.UserName, func(user *models.UserDTO) {
+		if patch.FirstName.Set {
+			user.FirstName = patch.FirstName.NullString
+		}
+		if patch.LastName.Set {
+			user.LastName = patch.LastName.NullString
+		}
+		if patch.Phone.Set {
+			user.Phone = patch.Phone.NullString
+		}
+		if patch.Password.Set {
+			user.Password = patch.Password.NullString
+		}
+	})
+}
+
+// UpdateRole - роль пользователя хранится в Status, как в колонке status
+func (s *Store) UpdateRole(ctx context.Context, username string, role int) error {
+	return s.updateUser(username, func(user *models.UserDTO) {
+		user.Status = role
+	})
+}
+
+func (s *Store) updateUser(username string, update func(user *models.UserDTO)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, user := range s.users {
+		if user.UserName.String == username {
+			update(&user)
+			s.users[id] = user
+			return nil
+		}
+	}
+
+	return myerrors.ErrUserNotFound
+}
@@ -2,18 +2,61 @@ package db
 
 import (
 	"database/sql"
-	"errors"
 	"fmt"
+	"net"
 	"pet-store/config"
 	"pet-store/internal/db/adapter"
 	"time"
 
 	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
+const (
+	// DriverMemory - значение DB_DRIVER, при котором данные хранятся в памяти процесса без подключения к БД
+	DriverMemory = "memory"
+	// DriverSQLite - значение DB_DRIVER для SQLite без cgo, DB_NAME - путь к файлу БД
+	DriverSQLite = "sqlite"
+	// DriverMySQL - значение DB_DRIVER для MySQL 8
+	DriverMySQL = "mysql"
+)
+
+// SQLiteDSN - строка подключения к файлу SQLite: внешние ключи включены, время пишется
+// в формате, который SQLite сравнивает как строку, а журнал WAL не блокирует чтение во время записи
+func SQLiteDSN(path string) string {
+	return fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite", path)
+}
+
+// PostgresDSN - строка подключения к Postgres в формате ключ=значение
+func PostgresDSN(dbConf config.DB) string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", dbConf.Host, dbConf.Port, dbConf.User, dbConf.Password, dbConf.Name)
+}
+
+// MySQLDSN - строка подключения к MySQL: DATETIME читается в time.Time, сессия работает в UTC,
+// а обратный слэш в строковых литералах не экранирует, как в Postgres и SQLite
+func MySQLDSN(dbConf config.DB) string {
+	conf := mysql.NewConfig()
+	conf.User = dbConf.User
+	conf.Passwd = dbConf.Password
+	conf.Net = "tcp"
+	conf.Addr = net.JoinHostPort(dbConf.Host, dbConf.Port)
+	conf.DBName = dbConf.Name
+	conf.ParseTime = true
+	// UPDATE, который ничего не меняет, должен считаться затронувшим строку, как в Postgres и SQLite
+	conf.ClientFoundRows = true
+	conf.Loc = time.UTC
+	conf.Params = map[string]string{
+		"time_zone": "'+00:00'",
+		"sql_mode":  "CONCAT(@@sql_mode, ',NO_BACKSLASH_ESCAPES')",
+	}
+
+	return conf.FormatDSN()
+}
+
 func NewSqlDB(dbConf config.DB, logger *zap.Logger) (*sqlx.DB, *adapter.SQLAdapter, error) {
 	var dsn string
 	var err error
@@ -21,9 +64,13 @@ func NewSqlDB(dbConf config.DB, logger *zap.Logger) (*sqlx.DB, *adapter.SQLAdapt
 
 	switch dbConf.Driver {
 	case "postgres":
-		dsn = fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", dbConf.Host, dbConf.Port, dbConf.User, dbConf.Password, dbConf.Name)
-	case "mysql":
-		return nil, nil, errors.New("don't mysql, only postgres")
+		dsn = PostgresDSN(dbConf)
+	case DriverMySQL:
+		dsn = MySQLDSN(dbConf)
+	case DriverSQLite:
+		dsn = SQLiteDSN(dbConf.Name)
+	case DriverMemory:
+		return nil, nil, nil
 	}
 
 	ticker := time.NewTicker(1 * time.Second)
@@ -42,8 +89,14 @@ func NewSqlDB(dbConf config.DB, logger *zap.Logger) (*sqlx.DB, *adapter.SQLAdapt
 			err = dbRaw.Ping()
 			if err == nil {
 				db := sqlx.NewDb(dbRaw, dbConf.Driver)
-				db.SetMaxOpenConns(50)
-				db.SetMaxIdleConns(50)
+				maxConns := 50
+				// SQLite пишет в файл по одному: с единственным соединением транзакции
+				// выстраиваются в очередь вместо ошибок SQLITE_BUSY
+				if dbConf.Driver == DriverSQLite {
+					maxConns = 1
+				}
+				db.SetMaxOpenConns(maxConns)
+				db.SetMaxIdleConns(maxConns)
 				sqlAdapter := adapter.NewSqlAdapter(db)
 				return db, sqlAdapter, nil
 			}
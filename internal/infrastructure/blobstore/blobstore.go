@@ -0,0 +1,20 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Storer - хранилище бинарных объектов (изображений и т.п.) по ключу.
+// Реализации: локальная файловая система, в дальнейшем S3-совместимое хранилище.
+type Storer interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+var (
+	ErrNotFound   = errors.New("blobstore: object not found")
+	ErrInvalidKey = errors.New("blobstore: invalid object key")
+)
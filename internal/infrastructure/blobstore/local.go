@@ -0,0 +1,93 @@
+package blobstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage - хранилище объектов в каталоге локальной файловой системы
+type LocalStorage struct {
+	root string
+}
+
+// NewLocalStorage - конструктор локального хранилища, создаёт корневой каталог при необходимости
+func NewLocalStorage(root string) (*LocalStorage, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("blobstore: create root dir: %w", err)
+	}
+	return &LocalStorage{root: root}, nil
+}
+
+// Put - записывает объект во временный файл и атомарно переименовывает его
+func (l *LocalStorage) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("blobstore: create dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("blobstore: create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("blobstore: write object: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("blobstore: close object: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get - открывает объект на чтение, ErrNotFound если объекта нет
+func (l *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("blobstore: open object: %w", err)
+	}
+
+	return f, nil
+}
+
+// Delete - удаляет объект, отсутствие объекта ошибкой не считается
+func (l *LocalStorage) Delete(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("blobstore: delete object: %w", err)
+	}
+
+	return nil
+}
+
+// path - переводит ключ в путь внутри корневого каталога, не допуская выхода за его пределы
+func (l *LocalStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.Join(l.root, filepath.FromSlash(clean)), nil
+}
@@ -1 +1,6 @@
+DROP TABLE pet_tags;
+DROP TABLE pet;
 DROP TABLE users;
+DROP TABLE orders;
+DROP TABLE category;
+DROP TABLE tags;
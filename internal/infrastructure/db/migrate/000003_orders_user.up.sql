@@ -0,0 +1,4 @@
+ALTER TABLE orders ADD COLUMN user_id int;
+ALTER TABLE orders ADD FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX orders_user_id_idx ON orders (user_id);
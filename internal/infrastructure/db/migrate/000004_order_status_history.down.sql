@@ -0,0 +1,6 @@
+DROP TABLE order_status_history;
+
+UPDATE orders SET status = 'placed' WHERE status IN ('cancelled','returned');
+ALTER TABLE orders DROP CONSTRAINT orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK(status IN ('placed','approved','delivered'));
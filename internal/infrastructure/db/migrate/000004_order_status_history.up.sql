@@ -0,0 +1,17 @@
+ALTER TABLE orders DROP CONSTRAINT orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK(status IN ('placed','approved','delivered','cancelled','returned'));
+
+CREATE TABLE order_status_history
+(
+    id serial PRIMARY KEY,
+    order_id int NOT NULL,
+    from_status VARCHAR(10),
+    to_status VARCHAR(10) NOT NULL,
+    changed_by int,
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY(order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    FOREIGN KEY(changed_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX order_status_history_order_id_idx ON order_status_history (order_id);
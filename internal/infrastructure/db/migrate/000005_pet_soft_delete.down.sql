@@ -0,0 +1 @@
+ALTER TABLE pet DROP COLUMN deleted_at;
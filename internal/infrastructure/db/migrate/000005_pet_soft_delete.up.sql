@@ -0,0 +1,3 @@
+ALTER TABLE pet ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL;
+
+CREATE INDEX pet_deleted_at_idx ON pet (deleted_at);
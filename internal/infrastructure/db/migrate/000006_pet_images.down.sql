@@ -0,0 +1,4 @@
+-- photoUrls остаётся TEXT: обратное сужение до VARCHAR(255) упало бы на длинных списках ссылок,
+-- а TEXT принимает всё, что помещалось в прежнюю колонку
+
+DROP TABLE pet_images;
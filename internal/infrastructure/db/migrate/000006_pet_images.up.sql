@@ -0,0 +1,18 @@
+CREATE TABLE pet_images
+(
+    id serial PRIMARY KEY,
+    pet_id int NOT NULL,
+    name VARCHAR(64) NOT NULL UNIQUE,
+    storage_key VARCHAR(255) NOT NULL,
+    original_name VARCHAR(255),
+    content_type VARCHAR(100) NOT NULL,
+    size bigint NOT NULL,
+    additional_metadata TEXT,
+    url VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY(pet_id) REFERENCES pet(id) ON DELETE CASCADE
+);
+
+CREATE INDEX pet_images_pet_id_idx ON pet_images (pet_id);
+
+ALTER TABLE pet ALTER COLUMN photoUrls TYPE TEXT;
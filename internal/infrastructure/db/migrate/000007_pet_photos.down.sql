@@ -0,0 +1,10 @@
+ALTER TABLE pet ADD COLUMN photoUrls TEXT;
+
+UPDATE pet p
+SET photoUrls = (
+    SELECT string_agg(ph.url, ', ' ORDER BY ph.position)
+    FROM pet_photos ph
+    WHERE ph.pet_id = p.id
+);
+
+DROP TABLE pet_photos;
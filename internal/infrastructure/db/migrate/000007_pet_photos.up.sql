@@ -0,0 +1,18 @@
+CREATE TABLE pet_photos
+(
+    pet_id int NOT NULL,
+    position int NOT NULL,
+    url TEXT NOT NULL,
+    FOREIGN KEY(pet_id) REFERENCES pet(id) ON DELETE CASCADE,
+    PRIMARY KEY(pet_id, position)
+);
+
+-- Переносим ссылки из старой колонки, где они были склеены через ", ": делим по тому же разделителю,
+-- чтобы не разрезать ссылки, в которых есть запятая
+INSERT INTO pet_photos (pet_id, position, url)
+SELECT p.id, u.ord - 1, btrim(u.url)
+FROM pet p
+CROSS JOIN LATERAL unnest(string_to_array(p.photoUrls, ', ')) WITH ORDINALITY AS u(url, ord)
+WHERE btrim(u.url) <> '';
+
+ALTER TABLE pet DROP COLUMN photoUrls;
@@ -0,0 +1,2 @@
+ALTER TABLE category DROP CONSTRAINT category_name_key;
+ALTER TABLE category ALTER COLUMN name DROP NOT NULL;
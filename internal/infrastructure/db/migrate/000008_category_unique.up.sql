@@ -0,0 +1,18 @@
+-- Питомцев из категорий-дубликатов переносим в первую категорию с тем же именем
+UPDATE pet p
+SET category = d.keep_id
+FROM (
+    SELECT id, MIN(id) OVER (PARTITION BY name) AS keep_id
+    FROM category
+) d
+WHERE p.category = d.id AND d.id <> d.keep_id;
+
+DELETE FROM category c
+USING category k
+WHERE c.name = k.name AND c.id > k.id;
+
+-- Категориям без имени даём имя по идентификатору
+UPDATE category SET name = 'category ' || id WHERE name IS NULL;
+
+ALTER TABLE category ALTER COLUMN name SET NOT NULL;
+ALTER TABLE category ADD CONSTRAINT category_name_key UNIQUE (name);
@@ -0,0 +1,5 @@
+DROP INDEX pet_status_created_at_idx;
+DROP INDEX pet_status_name_idx;
+DROP INDEX pet_status_id_idx;
+
+ALTER TABLE pet DROP COLUMN created_at;
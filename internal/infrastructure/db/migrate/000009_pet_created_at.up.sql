@@ -0,0 +1,6 @@
+ALTER TABLE pet ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+-- Индексы под постраничную выборку по статусу с сортировкой по id, имени и времени создания
+CREATE INDEX pet_status_id_idx ON pet (status, id);
+CREATE INDEX pet_status_name_idx ON pet (status, COALESCE(name, ''), id);
+CREATE INDEX pet_status_created_at_idx ON pet (status, created_at, id);
@@ -0,0 +1,12 @@
+DROP TRIGGER category_search_refresh ON category;
+DROP FUNCTION category_search_refresh();
+DROP TRIGGER tags_search_refresh ON tags;
+DROP FUNCTION tags_search_refresh();
+DROP TRIGGER pet_tags_search_refresh ON pet_tags;
+DROP FUNCTION pet_search_refresh_tags();
+DROP TRIGGER pet_search_document ON pet;
+DROP FUNCTION pet_search_document();
+
+DROP INDEX pet_search_vector_idx;
+ALTER TABLE pet DROP COLUMN search_vector;
+ALTER TABLE pet DROP COLUMN search_text;
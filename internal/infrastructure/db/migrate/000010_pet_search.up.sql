@@ -0,0 +1,73 @@
+-- Документ полнотекстового поиска питомца: имя, категория и теги.
+-- Категория и теги лежат в других таблицах, а генерируемая колонка может ссылаться только
+-- на свою строку, поэтому документ пересчитывается триггерами
+ALTER TABLE pet ADD COLUMN search_text TEXT NOT NULL DEFAULT '';
+ALTER TABLE pet ADD COLUMN search_vector tsvector NOT NULL DEFAULT ''::tsvector;
+
+CREATE INDEX pet_search_vector_idx ON pet USING GIN (search_vector);
+
+-- Совпадение в имени весит больше, чем в категории, а в категории - больше, чем в тегах
+CREATE FUNCTION pet_search_document() RETURNS trigger AS $$
+DECLARE
+    category_name TEXT;
+    tag_names TEXT;
+BEGIN
+    SELECT name INTO category_name FROM category WHERE id = NEW.category;
+    SELECT string_agg(t.name, ' ' ORDER BY t.name) INTO tag_names
+    FROM pet_tags pt
+    JOIN tags t ON pt.tag_id = t.id
+    WHERE pt.pet_id = NEW.id;
+
+    NEW.search_text := concat_ws(' ', NEW.name, category_name, tag_names);
+    NEW.search_vector := setweight(to_tsvector('simple', COALESCE(NEW.name, '')), 'A')
+        || setweight(to_tsvector('simple', COALESCE(category_name, '')), 'B')
+        || setweight(to_tsvector('simple', COALESCE(tag_names, '')), 'C');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER pet_search_document
+BEFORE INSERT OR UPDATE OF name, category ON pet
+FOR EACH ROW EXECUTE FUNCTION pet_search_document();
+
+-- Остальные триггеры пересчитывают документ через UPDATE pet SET name = name:
+-- он запускает триггер pet_search_document для затронутых питомцев
+CREATE FUNCTION pet_search_refresh_tags() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        UPDATE pet SET name = name WHERE id = OLD.pet_id;
+        RETURN OLD;
+    END IF;
+    UPDATE pet SET name = name WHERE id = NEW.pet_id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER pet_tags_search_refresh
+AFTER INSERT OR DELETE ON pet_tags
+FOR EACH ROW EXECUTE FUNCTION pet_search_refresh_tags();
+
+CREATE FUNCTION tags_search_refresh() RETURNS trigger AS $$
+BEGIN
+    UPDATE pet SET name = name WHERE id IN (SELECT pet_id FROM pet_tags WHERE tag_id = NEW.id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tags_search_refresh
+AFTER UPDATE OF name ON tags
+FOR EACH ROW EXECUTE FUNCTION tags_search_refresh();
+
+CREATE FUNCTION category_search_refresh() RETURNS trigger AS $$
+BEGIN
+    UPDATE pet SET name = name WHERE category = NEW.id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER category_search_refresh
+AFTER UPDATE OF name ON category
+FOR EACH ROW EXECUTE FUNCTION category_search_refresh();
+
+-- Заполняем документ для уже существующих питомцев
+UPDATE pet SET name = name;
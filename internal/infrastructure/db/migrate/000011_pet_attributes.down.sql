@@ -0,0 +1,11 @@
+DROP INDEX pet_breed_idx;
+DROP INDEX pet_microchip_id_key;
+
+ALTER TABLE pet
+    DROP COLUMN description,
+    DROP COLUMN microchip_id,
+    DROP COLUMN color,
+    DROP COLUMN weight,
+    DROP COLUMN sex,
+    DROP COLUMN birth_date,
+    DROP COLUMN breed;
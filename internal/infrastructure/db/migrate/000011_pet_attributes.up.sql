@@ -0,0 +1,15 @@
+ALTER TABLE pet
+    ADD COLUMN breed VARCHAR(100),
+    ADD COLUMN birth_date DATE,
+    ADD COLUMN sex VARCHAR(10),
+    ADD COLUMN weight NUMERIC(6, 2),
+    ADD COLUMN color VARCHAR(50),
+    ADD COLUMN microchip_id VARCHAR(15),
+    ADD COLUMN description TEXT;
+
+ALTER TABLE pet ADD CONSTRAINT pet_sex_check CHECK (sex IN ('male', 'female'));
+ALTER TABLE pet ADD CONSTRAINT pet_weight_check CHECK (weight > 0);
+
+-- Номер чипа однозначно определяет животное
+CREATE UNIQUE INDEX pet_microchip_id_key ON pet (microchip_id);
+CREATE INDEX pet_breed_idx ON pet (LOWER(breed));
@@ -0,0 +1 @@
+ALTER TABLE pet DROP COLUMN version;
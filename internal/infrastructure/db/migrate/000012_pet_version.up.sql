@@ -0,0 +1,2 @@
+-- Версия строки питомца для оптимистичной блокировки: увеличивается при каждом изменении питомца
+ALTER TABLE pet ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
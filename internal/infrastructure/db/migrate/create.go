@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+var (
+	migrationFile = regexp.MustCompile(`^(\d+)_.+\.(up|down)\.sql$`)
+	migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+)
+
+// Create - создаёт пустые файлы миграции name во всех каталогах dirs и возвращает их пути.
+// Номер следующий за наибольшим во всех каталогах, чтобы версия схемы означала одно и то же для всех БД
+func Create(dirs []string, name string) ([]string, error) {
+	if !migrationName.MatchString(name) {
+		return nil, fmt.Errorf("migrations: name %q must contain only a-z, 0-9 and _", name)
+	}
+
+	var last uint64
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			match := migrationFile.FindStringSubmatch(entry.Name())
+			if match == nil {
+				continue
+			}
+			version, err := strconv.ParseUint(match[1], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			if version > last {
+				last = version
+			}
+		}
+	}
+
+	var files []string
+	for _, dir := range dirs {
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(dir, fmt.Sprintf("%06d_%s.%s.sql", last+1, name, direction))
+			file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+			if err != nil {
+				return files, err
+			}
+			file.Close()
+			files = append(files, path)
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreate(t *testing.T) {
+	postgres, sqlite := t.TempDir(), t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(postgres, "000003_orders.up.sql"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(sqlite, "000012_init.up.sql"), nil, 0o644))
+
+	files, err := Create([]string{postgres, sqlite}, "pet_owner")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(postgres, "000013_pet_owner.up.sql"),
+		filepath.Join(postgres, "000013_pet_owner.down.sql"),
+		filepath.Join(sqlite, "000013_pet_owner.up.sql"),
+		filepath.Join(sqlite, "000013_pet_owner.down.sql"),
+	}, files)
+
+	_, err = Create([]string{postgres}, "Pet Owner")
+	assert.Error(t, err)
+}
@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"pet-store/config"
+	"pet-store/internal/db"
+)
+
+// lockName - имя блокировки, под которой реплики по очереди применяют миграции при запуске.
+// Оно отличается от блокировки golang-migrate: ту он берёт сам на время каждой команды и ждёт не дольше 15 секунд
+const lockName = "pet-store:migrations"
+
+var errNotLocked = errors.New("migrations: lock is not acquired")
+
+// acquireLock - ждёт сессионную блокировку БД без ограничения по времени: пока одна реплика применяет
+// миграции, остальные ждут, а затем видят, что применять нечего. Блокировка принадлежит соединению,
+// поэтому оно держится до вызова возвращённой функции и при падении процесса снимается вместе с ним.
+// Файл SQLite не делят несколько серверов, и для него блокировка не нужна
+func acquireLock(ctx context.Context, conf config.DB) (func(), error) {
+	var dsn, lockQuery, unlockQuery string
+	switch conf.Driver {
+	case db.DriverSQLite:
+		return func() {}, nil
+	case db.DriverMySQL:
+		dsn = db.MySQLDSN(conf)
+		lockQuery = "SELECT GET_LOCK(?, -1) = 1"
+		unlockQuery = "SELECT RELEASE_LOCK(?)"
+	default:
+		dsn = db.PostgresDSN(conf)
+		lockQuery = "SELECT true FROM pg_advisory_lock(hashtext($1))"
+		unlockQuery = "SELECT pg_advisory_unlock(hashtext($1))"
+	}
+
+	instance, err := sql.Open(conf.Driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := instance.Conn(ctx)
+	if err != nil {
+		instance.Close()
+		return nil, err
+	}
+
+	var locked sql.NullBool
+	if err := conn.QueryRowContext(ctx, lockQuery, lockName).Scan(&locked); err != nil || !locked.Bool {
+		conn.Close()
+		instance.Close()
+		if err == nil {
+			err = errNotLocked
+		}
+		return nil, err
+	}
+
+	return func() {
+		// Ошибку снятия можно не проверять: блокировка всё равно снимается при закрытии соединения
+		conn.ExecContext(context.Background(), unlockQuery, lockName)
+		conn.Close()
+		instance.Close()
+	}, nil
+}
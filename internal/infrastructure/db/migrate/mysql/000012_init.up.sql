@@ -0,0 +1,207 @@
+-- Схема MySQL 8 целиком, в том виде, какой она стала в Postgres после миграции 000012.
+-- Номер совпадает, чтобы версия схемы означала одно и то же для всех БД: следующие миграции
+-- добавляются во все каталоги под одним номером.
+-- Таблицы в utf8mb4_bin, чтобы имена сравнивались с учётом регистра, как в Postgres.
+-- Время хранится в UTC: сессия приложения открывается с time_zone = '+00:00'
+
+CREATE TABLE users
+(
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    username VARCHAR(20) NOT NULL UNIQUE,
+    firstname VARCHAR(20),
+    lastname VARCHAR(20),
+    phone VARCHAR(55),
+    email VARCHAR(25) NOT NULL UNIQUE,
+    status INT,
+    password VARCHAR(255) NOT NULL,
+    deleted_at DATETIME(6) NULL DEFAULT NULL
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE category
+(
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(55) NOT NULL UNIQUE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE tags
+(
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) UNIQUE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+-- Внешние ключи только табличными ограничениями: REFERENCES в описании колонки MySQL пропускает
+CREATE TABLE pet
+(
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    category INT,
+    name VARCHAR(55),
+    status VARCHAR(10) CHECK (status IN ('available', 'pending', 'sold')),
+    deleted_at DATETIME(6) NULL DEFAULT NULL,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    breed VARCHAR(100),
+    birth_date DATE,
+    sex VARCHAR(10) CHECK (sex IN ('male', 'female')),
+    weight DECIMAL(6, 2) CHECK (weight > 0),
+    color VARCHAR(50),
+    microchip_id VARCHAR(15) UNIQUE,
+    description TEXT,
+    version INT NOT NULL DEFAULT 1,
+    FOREIGN KEY (category) REFERENCES category(id)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE INDEX pet_deleted_at_idx ON pet (deleted_at);
+CREATE INDEX pet_status_id_idx ON pet (status, id);
+CREATE INDEX pet_status_name_idx ON pet (status, (COALESCE(name, '')), id);
+CREATE INDEX pet_status_created_at_idx ON pet (status, created_at, id);
+CREATE INDEX pet_breed_idx ON pet ((LOWER(breed)));
+
+CREATE TABLE pet_tags
+(
+    pet_id INT NOT NULL,
+    tag_id INT NOT NULL,
+    PRIMARY KEY (pet_id, tag_id),
+    FOREIGN KEY (pet_id) REFERENCES pet(id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE pet_photos
+(
+    pet_id INT NOT NULL,
+    position INT NOT NULL,
+    url TEXT NOT NULL,
+    PRIMARY KEY (pet_id, position),
+    FOREIGN KEY (pet_id) REFERENCES pet(id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE TABLE pet_images
+(
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    pet_id INT NOT NULL,
+    name VARCHAR(64) NOT NULL UNIQUE,
+    storage_key VARCHAR(255) NOT NULL,
+    original_name VARCHAR(255),
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    additional_metadata TEXT,
+    url VARCHAR(255) NOT NULL,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (pet_id) REFERENCES pet(id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE INDEX pet_images_pet_id_idx ON pet_images (pet_id);
+
+CREATE TABLE orders
+(
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    petid INT,
+    quantity INT,
+    shipdate DATETIME(6),
+    status VARCHAR(10) DEFAULT 'placed'
+        CHECK (status IN ('placed', 'approved', 'delivered', 'cancelled', 'returned')),
+    complete BOOLEAN,
+    user_id INT,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE INDEX orders_user_id_idx ON orders (user_id);
+
+CREATE TABLE order_status_history
+(
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    order_id INT NOT NULL,
+    from_status VARCHAR(10),
+    to_status VARCHAR(10) NOT NULL,
+    changed_by INT,
+    changed_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    FOREIGN KEY (changed_by) REFERENCES users(id) ON DELETE SET NULL
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE INDEX order_status_history_order_id_idx ON order_status_history (order_id);
+
+CREATE TABLE revoked_tokens
+(
+    jti VARCHAR(64) PRIMARY KEY,
+    expires_at DATETIME(6) NOT NULL,
+    revoked_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+CREATE INDEX revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
+
+-- Документ полнотекстового поиска питомца: имя, категория и теги в порядке имён.
+-- Вместо search_vector из Postgres - отдельная таблица: полнотекстовый индекс строится только
+-- по колонкам одной таблицы. Индекс по всему документу нужен для поиска, по каждой колонке - для весов.
+-- Поиск не учитывает регистр, поэтому у таблицы регистронезависимое сравнение
+CREATE TABLE pet_search
+(
+    pet_id INT PRIMARY KEY,
+    name VARCHAR(55) NOT NULL,
+    category VARCHAR(55) NOT NULL,
+    tags TEXT NOT NULL,
+    FULLTEXT INDEX pet_search_document_idx (name, category, tags),
+    FULLTEXT INDEX pet_search_name_idx (name),
+    FULLTEXT INDEX pet_search_category_idx (category),
+    FULLTEXT INDEX pet_search_tags_idx (tags),
+    FOREIGN KEY (pet_id) REFERENCES pet(id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_0900_as_ci;
+
+CREATE VIEW pet_search_document AS
+SELECT p.id,
+    COALESCE(p.name, '') AS name,
+    COALESCE(c.name, '') AS category,
+    COALESCE((
+        SELECT GROUP_CONCAT(t.name ORDER BY t.name SEPARATOR ' ')
+        FROM pet_tags pt
+        JOIN tags t ON pt.tag_id = t.id
+        WHERE pt.pet_id = p.id
+    ), '') AS tags
+FROM pet p
+LEFT JOIN category c ON p.category = c.id;
+
+-- Триггеры пересчитывают документ одним REPLACE. Удалённого питомца документ
+-- покидает вместе с ним по внешнему ключу
+CREATE TRIGGER pet_search_insert AFTER INSERT ON pet
+FOR EACH ROW
+    REPLACE INTO pet_search (pet_id, name, category, tags)
+    SELECT id, name, category, tags FROM pet_search_document WHERE id = NEW.id;
+
+CREATE TRIGGER pet_search_update AFTER UPDATE ON pet
+FOR EACH ROW
+    REPLACE INTO pet_search (pet_id, name, category, tags)
+    SELECT id, name, category, tags FROM pet_search_document
+    WHERE id = NEW.id AND NOT (NEW.name <=> OLD.name AND NEW.category <=> OLD.category);
+
+CREATE TRIGGER pet_tags_search_insert AFTER INSERT ON pet_tags
+FOR EACH ROW
+    REPLACE INTO pet_search (pet_id, name, category, tags)
+    SELECT id, name, category, tags FROM pet_search_document WHERE id = NEW.pet_id;
+
+CREATE TRIGGER pet_tags_search_delete AFTER DELETE ON pet_tags
+FOR EACH ROW
+    REPLACE INTO pet_search (pet_id, name, category, tags)
+    SELECT id, name, category, tags FROM pet_search_document WHERE id = OLD.pet_id;
+
+CREATE TRIGGER tags_search_update AFTER UPDATE ON tags
+FOR EACH ROW
+    REPLACE INTO pet_search (pet_id, name, category, tags)
+    SELECT id, name, category, tags FROM pet_search_document
+    WHERE id IN (SELECT pet_id FROM pet_tags WHERE tag_id = NEW.id) AND NOT (NEW.name <=> OLD.name);
+
+-- Каскадное удаление связей тега не запускает триггеры pet_tags, поэтому тег
+-- убирается из документов его питомцев до удаления
+CREATE TRIGGER tags_search_delete BEFORE DELETE ON tags
+FOR EACH ROW
+    UPDATE pet_search s
+    SET s.tags = COALESCE((
+        SELECT GROUP_CONCAT(t.name ORDER BY t.name SEPARATOR ' ')
+        FROM pet_tags pt
+        JOIN tags t ON pt.tag_id = t.id
+        WHERE pt.pet_id = s.pet_id AND t.id <> OLD.id
+    ), '')
+    WHERE s.pet_id IN (SELECT pet_id FROM pet_tags WHERE tag_id = OLD.id);
+
+CREATE TRIGGER category_search_update AFTER UPDATE ON category
+FOR EACH ROW
+    REPLACE INTO pet_search (pet_id, name, category, tags)
+    SELECT id, name, category, tags FROM pet_search_document
+    WHERE id IN (SELECT id FROM pet WHERE category = NEW.id) AND NOT (NEW.name <=> OLD.name);
@@ -0,0 +1,163 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"pet-store/internal/db"
+	"strings"
+	"sync"
+
+	"github.com/golang-migrate/migrate/database"
+)
+
+// Драйвер sqlite3 из golang-migrate работает через cgo, поэтому для SQLite без cgo
+// используется свой драйвер поверх modernc.org/sqlite
+func init() {
+	database.Register(db.DriverSQLite, &SQLite{})
+}
+
+const sqliteMigrationsTable = "schema_migrations"
+
+// SQLite - драйвер golang-migrate для SQLite. Блокировка действует в пределах процесса:
+// SQLite-файл обслуживает один экземпляр приложения
+type SQLite struct {
+	db       *sql.DB
+	mu       sync.Mutex
+	isLocked bool
+}
+
+// WithSQLiteInstance - драйвер миграций поверх открытого соединения с SQLite
+func WithSQLiteInstance(instance *sql.DB) (database.Driver, error) {
+	if err := instance.Ping(); err != nil {
+		return nil, err
+	}
+	d := &SQLite{db: instance}
+	if err := d.ensureVersionTable(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Open - открывает БД по адресу вида sqlite://путь/к/файлу.db
+func (d *SQLite) Open(url string) (database.Driver, error) {
+	instance, err := sql.Open(db.DriverSQLite, db.SQLiteDSN(strings.TrimPrefix(url, db.DriverSQLite+"://")))
+	if err != nil {
+		return nil, err
+	}
+
+	return WithSQLiteInstance(instance)
+}
+
+func (d *SQLite) Close() error {
+	return d.db.Close()
+}
+
+func (d *SQLite) Lock() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isLocked {
+		return database.ErrLocked
+	}
+	d.isLocked = true
+	return nil
+}
+
+func (d *SQLite) Unlock() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.isLocked = false
+	return nil
+}
+
+// Run - выполняет файл миграции в одной транзакции
+func (d *SQLite) Run(migration io.Reader) error {
+	query, err := io.ReadAll(migration)
+	if err != nil {
+		return err
+	}
+
+	return d.exec(string(query))
+}
+
+func (d *SQLite) SetVersion(version int, dirty bool) error {
+	query := "DELETE FROM " + sqliteMigrationsTable
+	if version >= 0 {
+		query += fmt.Sprintf(";\nINSERT INTO %s (version, dirty) VALUES (%d, %t)", sqliteMigrationsTable, version, dirty)
+	}
+
+	return d.exec(query)
+}
+
+func (d *SQLite) Version() (int, bool, error) {
+	var version int
+	var dirty bool
+	query := "SELECT version, dirty FROM " + sqliteMigrationsTable + " LIMIT 1"
+	err := d.db.QueryRow(query).Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return database.NilVersion, false, nil
+	}
+	if err != nil {
+		return 0, false, &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+
+	return version, dirty, nil
+}
+
+// Drop - удаляет все таблицы и представления, таблица версий создаётся заново пустой
+func (d *SQLite) Drop() error {
+	query := `SELECT type, name FROM sqlite_master
+		WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%'
+		ORDER BY type = 'table', sql LIKE 'CREATE VIRTUAL%' DESC, rowid DESC`
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+	var drops []string
+	for rows.Next() {
+		var kind, name string
+		if err := rows.Scan(&kind, &name); err != nil {
+			rows.Close()
+			return err
+		}
+		drops = append(drops, fmt.Sprintf(`DROP %s IF EXISTS "%s"`, strings.ToUpper(kind), name))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(drops) == 0 {
+		return d.ensureVersionTable()
+	}
+
+	// Сначала удаляются представления и таблицы FTS5 вместе со своими служебными таблицами (отсюда IF EXISTS),
+	// затем остальные таблицы в порядке, обратном созданию: зависимые раньше тех, на кого они ссылаются
+	if err := d.exec(strings.Join(drops, ";\n")); err != nil {
+		return err
+	}
+
+	return d.ensureVersionTable()
+}
+
+func (d *SQLite) ensureVersionTable() error {
+	return d.exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (version INTEGER NOT NULL, dirty BOOLEAN NOT NULL)`, sqliteMigrationsTable))
+}
+
+func (d *SQLite) exec(query string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction start failed"}
+	}
+	if _, err := tx.Exec(query); err != nil {
+		tx.Rollback()
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+	if err := tx.Commit(); err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction commit failed"}
+	}
+
+	return nil
+}
@@ -0,0 +1,12 @@
+DROP TABLE pet_search;
+DROP VIEW pet_search_document;
+DROP TABLE revoked_tokens;
+DROP TABLE order_status_history;
+DROP TABLE orders;
+DROP TABLE pet_images;
+DROP TABLE pet_photos;
+DROP TABLE pet_tags;
+DROP TABLE pet;
+DROP TABLE tags;
+DROP TABLE category;
+DROP TABLE users;
@@ -0,0 +1,190 @@
+-- Схема SQLite целиком, в том виде, какой она стала в Postgres после миграции 000012.
+-- Номер совпадает, чтобы версия схемы означала одно и то же для обеих БД: следующие миграции
+-- добавляются в оба каталога под одним номером.
+-- Время хранится текстом в UTC в формате _time_format=sqlite драйвера, поэтому значения
+-- по умолчанию записываются тем же форматом, а не CURRENT_TIMESTAMP
+
+CREATE TABLE users
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(20) NOT NULL UNIQUE,
+    firstname VARCHAR(20),
+    lastname VARCHAR(20),
+    phone VARCHAR(55),
+    email VARCHAR(25) NOT NULL UNIQUE,
+    status INTEGER,
+    password VARCHAR(255) NOT NULL,
+    deleted_at TIMESTAMP NULL DEFAULT NULL
+);
+
+CREATE TABLE category
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(55) NOT NULL UNIQUE
+);
+
+CREATE TABLE tags
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) UNIQUE
+);
+
+CREATE TABLE pet
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    category INTEGER REFERENCES category(id),
+    name VARCHAR(55),
+    status VARCHAR(10) CHECK (status IN ('available', 'pending', 'sold')),
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    breed VARCHAR(100),
+    birth_date DATE,
+    sex VARCHAR(10) CHECK (sex IN ('male', 'female')),
+    weight REAL CHECK (weight > 0),
+    color VARCHAR(50),
+    microchip_id VARCHAR(15) UNIQUE,
+    description TEXT,
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE INDEX pet_deleted_at_idx ON pet (deleted_at);
+CREATE INDEX pet_status_id_idx ON pet (status, id);
+CREATE INDEX pet_status_name_idx ON pet (status, COALESCE(name, ''), id);
+CREATE INDEX pet_status_created_at_idx ON pet (status, created_at, id);
+CREATE INDEX pet_breed_idx ON pet (LOWER(breed));
+
+CREATE TABLE pet_tags
+(
+    pet_id INTEGER REFERENCES pet(id) ON DELETE CASCADE,
+    tag_id INTEGER REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (pet_id, tag_id)
+);
+
+CREATE TABLE pet_photos
+(
+    pet_id INTEGER NOT NULL REFERENCES pet(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    PRIMARY KEY (pet_id, position)
+);
+
+CREATE TABLE pet_images
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pet_id INTEGER NOT NULL REFERENCES pet(id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL UNIQUE,
+    storage_key VARCHAR(255) NOT NULL,
+    original_name VARCHAR(255),
+    content_type VARCHAR(100) NOT NULL,
+    size INTEGER NOT NULL,
+    additional_metadata TEXT,
+    url VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX pet_images_pet_id_idx ON pet_images (pet_id);
+
+CREATE TABLE orders
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    petid INTEGER,
+    quantity INTEGER,
+    shipdate TIMESTAMP,
+    status VARCHAR(10) DEFAULT 'placed'
+        CHECK (status IN ('placed', 'approved', 'delivered', 'cancelled', 'returned')),
+    complete BOOLEAN,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX orders_user_id_idx ON orders (user_id);
+
+CREATE TABLE order_status_history
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_status VARCHAR(10),
+    to_status VARCHAR(10) NOT NULL,
+    changed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX order_status_history_order_id_idx ON order_status_history (order_id);
+
+CREATE TABLE revoked_tokens
+(
+    jti VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX revoked_tokens_expires_at_idx ON revoked_tokens (expires_at);
+
+-- Документ полнотекстового поиска питомца: имя, категория и теги в порядке имён, rowid - id питомца.
+-- Вместо search_vector из Postgres - таблица FTS5, её пересчитывают триггеры ниже
+CREATE VIRTUAL TABLE pet_search USING fts5(name, category, tags, tokenize = 'unicode61 remove_diacritics 0');
+
+CREATE VIEW pet_search_document AS
+SELECT p.id,
+    COALESCE(p.name, '') AS name,
+    COALESCE(c.name, '') AS category,
+    COALESCE((
+        SELECT group_concat(name, ' ')
+        FROM (
+            SELECT t.name
+            FROM pet_tags pt
+            JOIN tags t ON pt.tag_id = t.id
+            WHERE pt.pet_id = p.id
+            ORDER BY t.name
+        )
+    ), '') AS tags
+FROM pet p
+LEFT JOIN category c ON p.category = c.id;
+
+CREATE TRIGGER pet_search_insert AFTER INSERT ON pet
+BEGIN
+    INSERT INTO pet_search (rowid, name, category, tags)
+    SELECT id, name, category, tags FROM pet_search_document WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER pet_search_update AFTER UPDATE OF name, category ON pet
+BEGIN
+    DELETE FROM pet_search WHERE rowid = NEW.id;
+    INSERT INTO pet_search (rowid, name, category, tags)
+    SELECT id, name, category, tags FROM pet_search_document WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER pet_search_delete AFTER DELETE ON pet
+BEGIN
+    DELETE FROM pet_search WHERE rowid = OLD.id;
+END;
+
+CREATE TRIGGER pet_tags_search_insert AFTER INSERT ON pet_tags
+BEGIN
+    DELETE FROM pet_search WHERE rowid = NEW.pet_id;
+    INSERT INTO pet_search (rowid, name, category, tags)
+    SELECT id, name, category, tags FROM pet_search_document WHERE id = NEW.pet_id;
+END;
+
+-- Срабатывает и при каскадном удалении связей вместе с питомцем или тегом
+CREATE TRIGGER pet_tags_search_delete AFTER DELETE ON pet_tags
+BEGIN
+    DELETE FROM pet_search WHERE rowid = OLD.pet_id;
+    INSERT INTO pet_search (rowid, name, category, tags)
+    SELECT id, name, category, tags FROM pet_search_document WHERE id = OLD.pet_id;
+END;
+
+CREATE TRIGGER tags_search_update AFTER UPDATE OF name ON tags
+BEGIN
+    DELETE FROM pet_search WHERE rowid IN (SELECT pet_id FROM pet_tags WHERE tag_id = NEW.id);
+    INSERT INTO pet_search (rowid, name, category, tags)
+    SELECT id, name, category, tags FROM pet_search_document
+    WHERE id IN (SELECT pet_id FROM pet_tags WHERE tag_id = NEW.id);
+END;
+
+CREATE TRIGGER category_search_update AFTER UPDATE OF name ON category
+BEGIN
+    DELETE FROM pet_search WHERE rowid IN (SELECT id FROM pet WHERE category = NEW.id);
+    INSERT INTO pet_search (rowid, name, category, tags)
+    SELECT id, name, category, tags FROM pet_search_document
+    WHERE id IN (SELECT id FROM pet WHERE category = NEW.id);
+END;
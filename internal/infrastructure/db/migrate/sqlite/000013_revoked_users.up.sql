@@ -0,0 +1,7 @@
+-- Момент, раньше которого выпущенные токены пользователя недействительны. Внешнего ключа нет:
+-- запись переживает удаление пользователя, и его старые токены не оживут
+CREATE TABLE revoked_users
+(
+    user_id INTEGER PRIMARY KEY,
+    tokens_valid_after TIMESTAMP NOT NULL
+);
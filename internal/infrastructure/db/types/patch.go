@@ -0,0 +1,69 @@
+package types
+
+import (
+	"bytes"
+
+	"github.com/ptflp/godecoder"
+)
+
+// Поля JSON Merge Patch (RFC 7396). Set = false - поля не было в патче, и оно не меняется,
+// Set = true без значения - поле явно очищено через null
+
+var jsonNull = []byte("null")
+
+// isJSONNull - значение JSON - литерал null
+func isJSONNull(data []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(data), jsonNull)
+}
+
+// PatchString - строковое поле патча. Пустая строка, как и в NullString, очищает поле
+type PatchString struct {
+	NullString
+	Set bool
+}
+
+func (x *PatchString) UnmarshalJSON(data []byte) error {
+	x.Set = true
+	if isJSONNull(data) {
+		x.NullString = NullString{}
+		return nil
+	}
+
+	return x.NullString.UnmarshalJSON(data)
+}
+
+// PatchFloat64 - числовое поле патча
+type PatchFloat64 struct {
+	NullFloat64
+	Set bool
+}
+
+func (x *PatchFloat64) UnmarshalJSON(data []byte) error {
+	x.Set = true
+	if isJSONNull(data) {
+		x.NullFloat64 = NullFloat64{}
+		return nil
+	}
+
+	return x.NullFloat64.UnmarshalJSON(data)
+}
+
+// Patch - поле патча произвольного типа: массивы заменяются целиком, объекты - тоже.
+// Null = true - поле явно очищено, Value при этом нулевое
+type Patch[T any] struct {
+	Value T
+	Set   bool
+	Null  bool
+}
+
+func (x *Patch[T]) UnmarshalJSON(data []byte) error {
+	var value T
+	x.Set = true
+	x.Null = isJSONNull(data)
+	x.Value = value
+	if x.Null {
+		return nil
+	}
+
+	return godecoder.NewDecoder().Decode(bytes.NewBuffer(data), &x.Value)
+}
@@ -0,0 +1,38 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ptflp/godecoder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPatch struct {
+	Name   PatchString      `json:"name"`
+	Color  PatchString      `json:"color"`
+	Breed  PatchString      `json:"breed"`
+	Weight PatchFloat64     `json:"weight"`
+	Tags   Patch[[]string]  `json:"tags"`
+	Owner  Patch[testOwner] `json:"owner"`
+	Photos Patch[[]string]  `json:"photos"`
+}
+
+type testOwner struct {
+	Name string `json:"name"`
+}
+
+func TestPatchUnmarshal(t *testing.T) {
+	var p testPatch
+	body := `{"name": "Rex", "color": null, "breed": "", "weight": null, "tags": ["calm"], "owner": null}`
+	require.NoError(t, godecoder.NewDecoder().Decode(strings.NewReader(body), &p))
+
+	assert.Equal(t, PatchString{NullString: NewNullString("Rex"), Set: true}, p.Name)
+	assert.Equal(t, PatchString{Set: true}, p.Color, "null clears the field")
+	assert.Equal(t, PatchString{Set: true}, p.Breed, "empty string clears the field")
+	assert.Equal(t, PatchFloat64{Set: true}, p.Weight)
+	assert.Equal(t, Patch[[]string]{Value: []string{"calm"}, Set: true}, p.Tags)
+	assert.Equal(t, Patch[testOwner]{Set: true, Null: true}, p.Owner)
+	assert.False(t, p.Photos.Set, "absent field stays unchanged")
+}
@@ -6,4 +6,19 @@ var (
 	ErrTokenType        = fmt.Errorf("token type unknown")
 	ErrTokenExtractUser = fmt.Errorf("type assertion to user err")
 	ErrPetNotFound      = fmt.Errorf("no pet found with the provided id")
+	ErrTokenKind        = fmt.Errorf("token kind mismatch")
+	ErrOrderNotFound    = fmt.Errorf("no order found with the provided id")
+	ErrOrderStatusStale = fmt.Errorf("order status has been changed concurrently")
+	ErrPetNotAvailable  = fmt.Errorf("pet is not available for order")
+	ErrPetImageNotFound = fmt.Errorf("no image found for the pet")
+	ErrCategoryNotFound = fmt.Errorf("no category found")
+	ErrCategoryExists   = fmt.Errorf("category with this name already exists")
+	ErrCategoryInUse    = fmt.Errorf("category still has pets")
+	ErrTagNotFound      = fmt.Errorf("no tag found")
+	ErrTagExists        = fmt.Errorf("tag with this name already exists")
+	ErrMicrochipExists  = fmt.Errorf("pet with this microchip id already exists")
+	ErrPetVersion       = fmt.Errorf("pet has been changed concurrently")
+	ErrUserNotFound     = fmt.Errorf("no user found with the provided username")
+
+	ErrReassignCategoryNotFound = fmt.Errorf("no category found to reassign pets to")
 )
@@ -43,4 +43,62 @@ const (
 	DeleteOrderByIDErrorIDLessZero
 	OrderServiceDeleteByIDNotFoundID
 	OrderServiceDeleteByIDIternalErr
+	AuthServiceRefreshTokenInvalidErr
+	AuthServiceTokenRevokedErr
+	AuthServiceRevokeTokenErr
+	AuthServiceCheckRevokedErr
+	UserServiceUnknownRoleErr
+	UserServiceForbiddenErr
+	OrderServiceForbiddenErr
+	FindOrdersBadRequest
+	OrderServiceFindOrdersErr
+	OrderServiceInventoryErr
+	ChangeOrderStatusBadRequest
+	OrderServiceUnknownStatusErr
+	OrderServiceIllegalTransitionErr
+	OrderServiceStatusConflictErr
+	OrderServiceChangeStatusErr
+	OrderServiceHistoryErr
+	OrderServicePetNotFoundErr
+	OrderServicePetNotAvailableErr
+	PetIDErrorDuringConversion
+	PetServiceDeletePetErr
+	PetServiceRestorePetErr
+	PetServicePurgeErr
+	UploadPetImageBadRequest
+	PetServiceImageTooLargeErr
+	PetServiceImageTypeErr
+	PetServiceUploadImageErr
+	PetServiceImageNotFoundErr
+	PetServiceGetImageErr
+	PetServiceCategoryNotFoundErr
+	CategoryBadRequest
+	CategoryServiceNotFoundErr
+	CategoryServiceExistsErr
+	CategoryServiceInUseErr
+	CategoryServiceReassignNotFoundErr
+	CategoryServiceCreateErr
+	CategoryServiceListErr
+	CategoryServiceRenameErr
+	CategoryServiceDeleteErr
+	FindPetbyTagsBadRequest
+	PetServiceFindPetbyTags
+	TagBadRequest
+	TagServiceNotFoundErr
+	TagServiceExistsErr
+	TagServiceListErr
+	TagServiceRenameErr
+	TagServiceMergeErr
+	TagServiceDeleteOrphansErr
+	FindPetbyStatusBadRequest
+	SearchPetsBadRequest
+	PetServiceSearchPetsErr
+	PetAttributesBadRequest
+	PetServiceMicrochipExistsErr
+	PetIfMatchRequired
+	PetServiceVersionMismatchErr
+	PetPatchBadRequest
+	UserPatchBadRequest
+	AuthServiceAccessTokenInvalidErr
+	UserServiceNotFoundErr
 )
@@ -2,14 +2,15 @@ package router
 
 import (
 	"pet-store/internal/infrastructure/component"
+	"pet-store/internal/middleware"
 	"pet-store/internal/modules"
 	"pet-store/internal/router"
 
 	"github.com/go-chi/chi"
 )
 
-func NewRouter(controllers *modules.Controllers, components *component.Components) *chi.Mux {
+func NewRouter(controllers *modules.Controllers, components *component.Components, token *middleware.Token) *chi.Mux {
 	r := chi.NewRouter()
-	r.Mount("/", router.NewApiRouter(controllers, components))
+	r.Mount("/", router.NewApiRouter(controllers, components, token))
 	return r
 }
@@ -1,12 +1,17 @@
 package cryptography
 
 import (
+	"context"
 	"pet-store/config"
+	"pet-store/internal/infrastructure/errors"
+	"pet-store/internal/models"
+	"strconv"
 
 	"time"
 
 	"github.com/go-chi/jwtauth"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 )
 
 const (
@@ -16,12 +21,14 @@ const (
 
 type TokenManager interface {
 	GetAccessSecret() *jwtauth.JWTAuth
-	CreateToken(userID, groups string, ttl time.Duration, kind int) (string, error)
+	CreateToken(userID, role, groups string, ttl time.Duration, kind int) (string, error)
+	ParseToken(token string, kind int) (UserFromClaims, error)
 }
 
 type TokenJWT struct {
 	AccessSecret  *jwtauth.JWTAuth
 	RefreshSecret []byte
+	refreshAuth   *jwtauth.JWTAuth
 }
 
 func (t *TokenJWT) GetAccessSecret() *jwtauth.JWTAuth {
@@ -30,7 +37,8 @@ func (t *TokenJWT) GetAccessSecret() *jwtauth.JWTAuth {
 
 func NewTokenJWT(token config.Token) TokenManager {
 	accSec := jwtauth.New("HS256", []byte(token.AccessSecret), nil)
-	return &TokenJWT{AccessSecret: accSec, RefreshSecret: []byte(token.RefreshSecret)}
+	refSec := jwtauth.New("HS256", []byte(token.RefreshSecret), nil)
+	return &TokenJWT{AccessSecret: accSec, RefreshSecret: []byte(token.RefreshSecret), refreshAuth: refSec}
 }
 
 // UserClaims include custom claims on jwt.
@@ -43,27 +51,100 @@ type UserClaims struct {
 }
 
 type UserFromClaims struct {
-	ID     int
-	Role   int
-	Groups []int
-	Layers []int
+	ID        int
+	Role      int
+	Groups    []int
+	Layers    []int
+	TokenID   string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
 }
 
 // CreateToken create new token with parameters.
-func (o *TokenJWT) CreateToken(userID, groups string, ttl time.Duration, kind int) (string, error) {
+func (o *TokenJWT) CreateToken(userID, role, groups string, ttl time.Duration, kind int) (string, error) {
+	// Токены доступа и обновления подписываются разными секретами
+	auth, err := o.authByKind(kind)
+	if err != nil {
+		return "", err
+	}
 	// Создание данных для токена
 	claims := map[string]interface{}{
 		"user_id": userID,
+		"role":    role,
 		"groups":  groups,
 		"kind":    kind,
+		"jti":     uuid.NewString(),           // Идентификатор токена для отзыва
+		"iat":     time.Now().Unix(),          // Время выпуска для отзыва всех токенов пользователя
 		"exp":     time.Now().Add(ttl).Unix(), // Устанавливаем время истечения токена
 	}
 
 	// Генерация токена с указанными данными
-	_, token, err := o.AccessSecret.Encode(claims)
+	_, token, err := auth.Encode(claims)
 	if err != nil {
 		return "", err
 	}
 
 	return token, nil
 }
+
+// ParseToken проверяет подпись и срок действия токена указанного вида и возвращает данные пользователя.
+func (o *TokenJWT) ParseToken(tokenString string, kind int) (UserFromClaims, error) {
+	auth, err := o.authByKind(kind)
+	if err != nil {
+		return UserFromClaims{}, err
+	}
+
+	token, err := jwtauth.VerifyToken(auth, tokenString)
+	if err != nil {
+		return UserFromClaims{}, err
+	}
+	claims, err := token.AsMap(context.Background())
+	if err != nil {
+		return UserFromClaims{}, err
+	}
+
+	// Токен другого вида (например, access вместо refresh) не принимаем
+	tokenKind, ok := claims["kind"].(float64)
+	if !ok || int(tokenKind) != kind {
+		return UserFromClaims{}, errors.ErrTokenKind
+	}
+
+	return ClaimsToUser(claims)
+}
+
+// ClaimsToUser - извлекает данные пользователя из claims проверенного токена.
+func ClaimsToUser(claims map[string]interface{}) (UserFromClaims, error) {
+	userID, ok := claims["user_id"].(string)
+	if !ok {
+		return UserFromClaims{}, errors.ErrTokenExtractUser
+	}
+	id, err := strconv.Atoi(userID)
+	if err != nil {
+		return UserFromClaims{}, errors.ErrTokenExtractUser
+	}
+
+	roleName, _ := claims["role"].(string)
+	role, _ := models.RoleFromName(roleName)
+	tokenID, _ := claims["jti"].(string)
+	issuedAt, _ := claims["iat"].(time.Time)
+	expiresAt, _ := claims["exp"].(time.Time)
+
+	return UserFromClaims{
+		ID:        id,
+		Role:      role,
+		TokenID:   tokenID,
+		IssuedAt:  issuedAt,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (o *TokenJWT) authByKind(kind int) (*jwtauth.JWTAuth, error) {
+	switch kind {
+	case AccessToken:
+		return o.AccessSecret, nil
+	case RefreshToken:
+		return o.refreshAuth, nil
+	}
+
+	return nil, errors.ErrTokenType
+}
@@ -0,0 +1,29 @@
+package cryptography
+
+import (
+	"context"
+	"pet-store/internal/models"
+)
+
+type userCtxKey struct{}
+
+// NewUserContext - кладёт данные пользователя из токена в контекст запроса
+func NewUserContext(ctx context.Context, user UserFromClaims) context.Context {
+	return context.WithValue(ctx, userCtxKey{}, user)
+}
+
+// UserFromContext - достаёт данные пользователя, положенные middleware в контекст запроса
+func UserFromContext(ctx context.Context) (UserFromClaims, bool) {
+	user, ok := ctx.Value(userCtxKey{}).(UserFromClaims)
+	return user, ok
+}
+
+// IsAdmin - проверяет, есть ли у пользователя роль администратора
+func (u UserFromClaims) IsAdmin() bool {
+	return u.Role == models.RoleAdmin
+}
+
+// CanAccess - пользователь может работать со своими записями, администратор - с любыми
+func (u UserFromClaims) CanAccess(ownerID int) bool {
+	return u.IsAdmin() || u.ID == ownerID
+}
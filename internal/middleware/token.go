@@ -1,18 +1,113 @@
 package middleware
 
 import (
+	"errors"
+	"net/http"
+	myerrors "pet-store/internal/infrastructure/errors"
 	"pet-store/internal/infrastructure/responder"
 	"pet-store/internal/infrastructure/tools/cryptography"
+	aservice "pet-store/internal/modules/auth/service"
+
+	"github.com/go-chi/jwtauth"
+)
+
+var (
+	errTokenRevoked = errors.New("token has been revoked")
+	errForbidden    = errors.New("insufficient permissions")
 )
 
 type Token struct {
 	responder.Responder
-	jwt cryptography.TokenManager
+	jwt  cryptography.TokenManager
+	auth aservice.Auther
 }
 
-func NewTokenManager(responder responder.Responder, jwt cryptography.TokenManager) *Token {
+func NewTokenManager(responder responder.Responder, jwt cryptography.TokenManager, auth aservice.Auther) *Token {
 	return &Token{
 		Responder: responder,
 		jwt:       jwt,
+		auth:      auth,
+	}
+}
+
+// CheckRevoked - отклоняет запросы с отозванными токенами, в том числе выпущенными до отзыва
+// всех токенов пользователя. Должен стоять после jwtauth.Verifier и jwtauth.Authenticator.
+func (t *Token) CheckRevoked(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, claims, err := jwtauth.FromContext(r.Context())
+		if err != nil {
+			t.ErrorUnauthorized(w, err)
+			return
+		}
+
+		user, err := cryptography.ClaimsToUser(claims)
+		if err != nil {
+			t.ErrorUnauthorized(w, err)
+			return
+		}
+		if user.TokenID == "" {
+			t.ErrorUnauthorized(w, myerrors.ErrTokenType)
+			return
+		}
+
+		out := t.auth.IsRevoked(r.Context(), aservice.RevokedIn{
+			TokenID:  user.TokenID,
+			UserID:   user.ID,
+			IssuedAt: user.IssuedAt,
+		})
+		if out.ErrorCode != myerrors.NoError {
+			t.ErrorInternal(w, errors.New("check token revoked error"))
+			return
+		}
+		if out.Revoked {
+			t.ErrorUnauthorized(w, errTokenRevoked)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ExtractUser - кладёт ID и роль пользователя из токена в контекст запроса.
+// Должен стоять после jwtauth.Verifier и jwtauth.Authenticator.
+func (t *Token) ExtractUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, claims, err := jwtauth.FromContext(r.Context())
+		if err != nil {
+			t.ErrorUnauthorized(w, err)
+			return
+		}
+
+		user, err := cryptography.ClaimsToUser(claims)
+		if err != nil {
+			t.ErrorUnauthorized(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(cryptography.NewUserContext(r.Context(), user)))
+	})
+}
+
+// RequireRole - пропускает запрос, только если роль из токена входит в список разрешённых.
+// Должен стоять после jwtauth.Verifier и jwtauth.Authenticator.
+func (t *Token) RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, claims, err := jwtauth.FromContext(r.Context())
+			if err != nil {
+				t.ErrorUnauthorized(w, err)
+				return
+			}
+
+			role, _ := claims["role"].(string)
+			for i := range roles {
+				if roles[i] == role {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			t.ErrorForbidden(w, errForbidden)
+		})
 	}
 }
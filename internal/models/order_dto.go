@@ -2,11 +2,46 @@ package models
 
 import "time"
 
+// Статусы заказа
+const (
+	OrderStatusPlaced    = "placed"
+	OrderStatusApproved  = "approved"
+	OrderStatusDelivered = "delivered"
+	OrderStatusCancelled = "cancelled"
+	OrderStatusReturned  = "returned"
+)
+
+// OrderReservesPet - заказ в статусе status держит питомца в pending: он ещё не доставлен и не отменён
+func OrderReservesPet(status string) bool {
+	return status == OrderStatusPlaced || status == OrderStatusApproved
+}
+
 type Order struct {
 	ID       int       `db:"id"`
 	PetID    int       `db:"petid"`
+	UserID   int       `db:"user_id"`
 	Quantity int       `db:"quantity"`
 	ShipDate time.Time `db:"shipdate"`
 	Status   string    `db:"status"`
 	Complete bool      `db:"complete"`
 }
+
+// OrderFilter - параметры выборки заказов
+type OrderFilter struct {
+	UserID       int
+	Statuses     []string
+	ShipDateFrom time.Time
+	ShipDateTo   time.Time
+	Limit        int
+	Offset       int
+}
+
+// OrderStatusChange - запись истории смены статуса заказа
+type OrderStatusChange struct {
+	ID         int       `db:"id"`
+	OrderID    int       `db:"order_id"`
+	FromStatus string    `db:"from_status"`
+	ToStatus   string    `db:"to_status"`
+	ChangedBy  int       `db:"changed_by"`
+	ChangedAt  time.Time `db:"changed_at"`
+}
@@ -1,12 +1,120 @@
 package models
 
+import (
+	"pet-store/internal/infrastructure/db/types"
+	"time"
+)
+
+// Статусы питомца
+const (
+	PetStatusAvailable = "available"
+	PetStatusPending   = "pending"
+	PetStatusSold      = "sold"
+)
+
+// PetStatuses - все допустимые статусы питомца
+var PetStatuses = []string{PetStatusAvailable, PetStatusPending, PetStatusSold}
+
+// Пол питомца
+const (
+	PetSexMale   = "male"
+	PetSexFemale = "female"
+)
+
+// PetDateLayout - формат даты рождения питомца
+const PetDateLayout = "2006-01-02"
+
 type Pet struct {
-	ID        int      `json:"id" db:"id"`
-	Category  Category `json:"category" db:"category"`
-	Name      string   `json:"name" db:"name"`
-	PhotoUrls []string `json:"photourls" db:"photourls"`
-	Tags      []Tag    `json:"tags" db:"tags"`
-	Status    string   `json:"status" db:"status"`
+	ID        int       `json:"id" db:"id"`
+	Category  Category  `json:"category" db:"category"`
+	Name      string    `json:"name" db:"name"`
+	PhotoUrls []string  `json:"photourls" db:"photourls"`
+	Tags      []Tag     `json:"tags" db:"tags"`
+	Status    string    `json:"status" db:"status"`
+	CreatedAt time.Time `json:"createdat" db:"created_at"`
+	// Version - номер версии питомца, растёт при каждом изменении
+	Version int `json:"version" db:"version"`
+	// Необязательные сведения о питомце, пустое значение - не указано.
+	// BirthDate - дата в формате PetDateLayout, Description - описание в markdown
+	Breed       string  `json:"breed,omitempty" db:"breed"`
+	BirthDate   string  `json:"birthdate,omitempty" db:"birth_date"`
+	Sex         string  `json:"sex,omitempty" db:"sex"`
+	Weight      float64 `json:"weight,omitempty" db:"weight"`
+	Color       string  `json:"color,omitempty" db:"color"`
+	MicrochipID string  `json:"microchipid,omitempty" db:"microchip_id"`
+	Description string  `json:"description,omitempty" db:"description"`
+	// Rank и Highlight заполняются только при полнотекстовом поиске
+	Rank      float64 `json:"rank,omitempty" db:"-"`
+	Highlight string  `json:"highlight,omitempty" db:"-"`
+}
+
+// PetPatch - частичное изменение питомца по JSON Merge Patch: меняются только поля с Set,
+// необязательные поля без значения очищаются. Category - имя категории,
+// Version - ожидаемая версия питомца, 0 - любая
+type PetPatch struct {
+	ID          int
+	Version     int
+	Name        types.PatchString
+	Category    types.PatchString
+	Status      types.PatchString
+	PhotoUrls   types.Patch[[]string]
+	Tags        types.Patch[[]Tag]
+	Breed       types.PatchString
+	BirthDate   types.PatchString
+	Sex         types.PatchString
+	Weight      types.PatchFloat64
+	Color       types.PatchString
+	MicrochipID types.PatchString
+	Description types.PatchString
+}
+
+// Ключи сортировки списков питомцев
+const (
+	PetSortID      = "id"
+	PetSortName    = "name"
+	PetSortCreated = "created"
+	// PetSortRank - релевантность полнотекстового поиска, доступна только вместе с PetFilter.Query
+	PetSortRank = "rank"
+)
+
+// PetPage - параметры постраничной выборки питомцев.
+// After - последний питомец предыдущей страницы, nil для первой страницы
+type PetPage struct {
+	Sort  string
+	Desc  bool
+	Limit int
+	After *PetCursor
+}
+
+// PetFilter - условия поиска питомцев, пустые поля не ограничивают выборку.
+// Query - слова для полнотекстового поиска по имени, категории и тегам.
+// CreatedTo не входит в интервал, границы даты рождения и веса входят.
+// Порода и окрас сравниваются без учёта регистра
+type PetFilter struct {
+	Query        string
+	Name         string
+	Category     string
+	Tags         []string
+	MatchAllTags bool
+	Statuses     []string
+	CreatedFrom  time.Time
+	CreatedTo    time.Time
+	Breed        string
+	Sex          string
+	Color        string
+	MicrochipID  string
+	BornFrom     time.Time
+	BornTo       time.Time
+	WeightMin    float64
+	WeightMax    float64
+}
+
+// PetCursor - значения ключа сортировки, после которых начинается следующая страница
+type PetCursor struct {
+	ID        int
+	Name      string
+	CreatedAt time.Time
+	Rank      float64
 }
 
 type Category struct {
@@ -14,7 +122,33 @@ type Category struct {
 	Name string `json:"name" db:"name"`
 }
 
+// CategoryWithCount - категория с количеством питомцев в ней
+type CategoryWithCount struct {
+	Category
+	PetCount int `json:"petcount" db:"pet_count"`
+}
+
 type Tag struct {
 	ID   int    `json:"id" db:"id"`
 	Name string `json:"name" db:"name"`
 }
+
+// PetImage - метаданные загруженного изображения питомца
+type PetImage struct {
+	ID                 int       `json:"id" db:"id"`
+	PetID              int       `json:"petid" db:"pet_id"`
+	Name               string    `json:"name" db:"name"`
+	StorageKey         string    `json:"-" db:"storage_key"`
+	OriginalName       string    `json:"originalname" db:"original_name"`
+	ContentType        string    `json:"contenttype" db:"content_type"`
+	Size               int64     `json:"size" db:"size"`
+	AdditionalMetadata string    `json:"additionalmetadata" db:"additional_metadata"`
+	URL                string    `json:"url" db:"url"`
+	CreatedAt          time.Time `json:"createdat" db:"created_at"`
+}
+
+// TagWithCount - тег с количеством питомцев, к которым он привязан
+type TagWithCount struct {
+	Tag
+	PetCount int `json:"petcount" db:"pet_count"`
+}
@@ -0,0 +1,35 @@
+package models
+
+// Роли пользователей хранятся в колонке users.status
+const (
+	RoleUser = iota
+	RoleAdmin
+)
+
+const (
+	RoleNameUser  = "user"
+	RoleNameAdmin = "admin"
+)
+
+var roleNames = map[int]string{
+	RoleUser:  RoleNameUser,
+	RoleAdmin: RoleNameAdmin,
+}
+
+// RoleName - возвращает имя роли по её коду, неизвестные коды считаются обычным пользователем
+func RoleName(role int) string {
+	if name, ok := roleNames[role]; ok {
+		return name
+	}
+	return RoleNameUser
+}
+
+// RoleFromName - возвращает код роли по её имени
+func RoleFromName(name string) (int, bool) {
+	for role, roleName := range roleNames {
+		if roleName == name {
+			return role, true
+		}
+	}
+	return 0, false
+}
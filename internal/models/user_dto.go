@@ -106,3 +106,13 @@ func (s *UserDTO) SetDeletedAt(deletedAt time.Time) *UserDTO {
 func (s *UserDTO) GetDeletedAt() time.Time {
 	return s.DeletedAt.Time.Time
 }
+
+// UserPatch - частичное изменение профиля по JSON Merge Patch: меняются только поля с Set,
+// поля без значения очищаются. Password - уже захешированный пароль
+type UserPatch struct {
+	UserName  string
+	FirstName types.PatchString
+	LastName  types.PatchString
+	Phone     types.PatchString
+	Password  types.PatchString
+}
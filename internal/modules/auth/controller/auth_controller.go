@@ -1,12 +1,15 @@
 package controller
 
 import (
+	"io"
 	"net/http"
 	"pet-store/internal/infrastructure/component"
 	"pet-store/internal/infrastructure/errors"
 	"pet-store/internal/infrastructure/responder"
 	"pet-store/internal/modules/auth/service"
 
+	"github.com/go-chi/chi"
+	"github.com/go-chi/jwtauth"
 	"github.com/go-playground/validator"
 	"github.com/ptflp/godecoder"
 )
@@ -14,6 +17,9 @@ import (
 type Auther interface {
 	CreateUser(w http.ResponseWriter, r *http.Request)
 	Login(w http.ResponseWriter, r *http.Request)
+	Refresh(w http.ResponseWriter, r *http.Request)
+	Logout(w http.ResponseWriter, r *http.Request)
+	RevokeTokens(w http.ResponseWriter, r *http.Request)
 }
 
 type Auth struct {
@@ -140,3 +146,161 @@ func (a *Auth) Login(w http.ResponseWriter, r *http.Request) {
 		},
 	})
 }
+
+// @Summary Refresh tokens
+// @Tags user
+// @Description exchange a refresh token for a new access/refresh pair
+// @ID refresh
+// @Accept  json
+// @Produce  json
+// @Param input body RefreshRequest true "refresh token"
+// @Success 200 {object} AuthResponse "new tokens"
+// @Failure 401 {object} AuthResponse "invalid refresh token"
+// @Failure 500 {object} AuthResponse "error loading the user or revoking the token"
+// @Router /user/refresh [post]
+func (a *Auth) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	err := a.Decode(r.Body, &req)
+	if err != nil {
+		a.ErrorBadRequest(w, err)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		a.ErrorBadRequest(w, err)
+		return
+	}
+
+	out := a.auth.Refresh(r.Context(), service.RefreshIn{
+		RefreshToken: req.RefreshToken,
+	})
+
+	if out.ErrorCode != errors.NoError {
+		msg := "refresh error"
+		switch out.ErrorCode {
+		case errors.AuthServiceRefreshTokenInvalidErr:
+			w.WriteHeader(http.StatusUnauthorized)
+			msg = "refresh token is invalid or expired"
+		case errors.AuthServiceTokenRevokedErr:
+			w.WriteHeader(http.StatusUnauthorized)
+			msg = "refresh token has already been used"
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		a.OutputJSON(w, AuthResponse{
+			Success:   false,
+			ErrorCode: out.ErrorCode,
+			Data: LoginData{
+				Message: msg,
+			},
+		})
+		return
+	}
+
+	a.OutputJSON(w, AuthResponse{
+		Success: true,
+		Data: LoginData{
+			Message:      "tokens refreshed",
+			AccessToken:  out.AccessToken,
+			RefreshToken: out.RefreshToken,
+		},
+	})
+}
+
+// @Summary Logout
+// @Security ApiKeyAuth
+// @Tags user
+// @Description revoke the current access token and, if provided, the refresh token
+// @ID logout
+// @Accept  json
+// @Produce  json
+// @Param input body LogoutRequest false "refresh token to revoke"
+// @Success 200 {object} RegisterResponse "logged out"
+// @Failure 401 {object} RegisterResponse "invalid token"
+// @Router /user/logout [post]
+func (a *Auth) Logout(w http.ResponseWriter, r *http.Request) {
+	var req LogoutRequest
+	// Тело запроса необязательно: без него отзывается только токен доступа
+	err := a.Decode(r.Body, &req)
+	if err != nil && err != io.EOF {
+		a.ErrorBadRequest(w, err)
+		return
+	}
+
+	out := a.auth.Logout(r.Context(), service.LogoutIn{
+		AccessToken:  jwtauth.TokenFromHeader(r),
+		RefreshToken: req.RefreshToken,
+	})
+
+	if out.ErrorCode != errors.NoError {
+		msg := "logout error"
+		switch out.ErrorCode {
+		case errors.AuthServiceAccessTokenInvalidErr:
+			w.WriteHeader(http.StatusUnauthorized)
+			msg = "access token is invalid or expired"
+		case errors.AuthServiceRefreshTokenInvalidErr:
+			w.WriteHeader(http.StatusUnauthorized)
+			msg = "refresh token is invalid or expired"
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		a.OutputJSON(w, RegisterResponse{
+			Success:   false,
+			ErrorCode: out.ErrorCode,
+			Data: Data{
+				Message: msg,
+			},
+		})
+		return
+	}
+
+	a.OutputJSON(w, RegisterResponse{
+		Success: true,
+		Data: Data{
+			Message: "you have been logged out",
+		},
+	})
+}
+
+// @Summary Revoke user tokens
+// @Security ApiKeyAuth
+// @Tags user
+// @Description revoke every access and refresh token issued to the user so far, the user has to log in again. Requires the admin role.
+// @ID RevokeTokens
+// @Produce  json
+// @Param username path string true "Username of the user"
+// @Success 200 {object} RegisterResponse "tokens revoked"
+// @Failure 404 {object} RegisterResponse "user not found"
+// @Router /user/{username}/revoke [post]
+func (a *Auth) RevokeTokens(w http.ResponseWriter, r *http.Request) {
+	out := a.auth.RevokeUserTokens(r.Context(), chi.URLParam(r, "username"))
+	if out.ErrorCode != errors.NoError {
+		msg := "revoke tokens error"
+		switch out.ErrorCode {
+		case errors.UserServiceNotFoundErr:
+			w.WriteHeader(http.StatusNotFound)
+			msg = "user not found"
+		case errors.UserServiceForbiddenErr:
+			w.WriteHeader(http.StatusForbidden)
+			msg = "access to another user's profile is forbidden"
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		a.OutputJSON(w, RegisterResponse{
+			Success:   false,
+			ErrorCode: out.ErrorCode,
+			Data: Data{
+				Message: msg,
+			},
+		})
+		return
+	}
+
+	a.OutputJSON(w, RegisterResponse{
+		Success: true,
+		Data: Data{
+			Message: "user tokens have been revoked",
+		},
+	})
+}
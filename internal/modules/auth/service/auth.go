@@ -8,8 +8,10 @@ import (
 	"pet-store/internal/infrastructure/errors"
 	"pet-store/internal/infrastructure/tools/cryptography"
 	"pet-store/internal/models"
+	"pet-store/internal/modules/auth/storage"
 	uservice "pet-store/internal/modules/user/service"
 	"strconv"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -17,15 +19,17 @@ import (
 type Auth struct {
 	conf         config.AppConf
 	user         uservice.Userer
+	revoker      storage.Revoker
 	tokenManager cryptography.TokenManager
 	hash         cryptography.Hasher
 	logger       *zap.Logger
 }
 
-func NewAuth(user uservice.Userer, components *component.Components) *Auth {
+func NewAuth(user uservice.Userer, revoker storage.Revoker, components *component.Components) *Auth {
 	return &Auth{
 		conf:         components.Conf,
 		user:         user,
+		revoker:      revoker,
 		tokenManager: components.TokenManager,
 		hash:         components.Hash,
 		logger:       components.Logger,
@@ -102,9 +106,157 @@ func (a *Auth) AuthorizeEmail(ctx context.Context, in AuthorizeEmailIn) Authoriz
 	}
 }
 
+func (a *Auth) Refresh(ctx context.Context, in RefreshIn) AuthorizeOut {
+	// 1. проверяем refresh токен, подписанный своим секретом
+	claims, err := a.tokenManager.ParseToken(in.RefreshToken, cryptography.RefreshToken)
+	if err != nil {
+		a.logger.Info("auth: parse refresh token err", zap.Error(err))
+		return AuthorizeOut{
+			ErrorCode: errors.AuthServiceRefreshTokenInvalidErr,
+		}
+	}
+
+	// 2. refresh токен, выпущенный до отзыва всех токенов пользователя, больше не действует
+	revoked, err := a.revoker.IsRevoked(ctx, claims.TokenID, claims.ID, claims.IssuedAt)
+	if err != nil {
+		a.logger.Error("auth: check refresh token revoked err", zap.Error(err))
+		return AuthorizeOut{
+			ErrorCode: errors.AuthServiceCheckRevokedErr,
+		}
+	}
+	if revoked {
+		return AuthorizeOut{
+			ErrorCode: errors.AuthServiceTokenRevokedErr,
+		}
+	}
+
+	// 3. перечитываем пользователя, чтобы в новые токены попала актуальная роль. Делаем это до отзыва:
+	// при сбое БД токен останется действительным и запрос можно будет повторить
+	userOut := a.user.GetByID(ctx, claims.ID)
+	if userOut.ErrorCode == errors.UserServiceNotFoundErr {
+		return AuthorizeOut{
+			ErrorCode: errors.AuthServiceRefreshTokenInvalidErr,
+		}
+	}
+	if userOut.ErrorCode != errors.NoError {
+		return AuthorizeOut{
+			ErrorCode: userOut.ErrorCode,
+		}
+	}
+	user := userOut.User
+
+	// 4. refresh токен одноразовый: использованный отзываем, а если он уже был отозван,
+	// в том числе параллельным запросом с тем же токеном, новую пару не выдаём
+	revoked, err = a.revoker.Revoke(ctx, claims.TokenID, claims.ExpiresAt)
+	if err != nil {
+		a.logger.Error("auth: revoke refresh token err", zap.Error(err))
+		return AuthorizeOut{
+			ErrorCode: errors.AuthServiceRevokeTokenErr,
+		}
+	}
+	if !revoked {
+		return AuthorizeOut{
+			ErrorCode: errors.AuthServiceTokenRevokedErr,
+		}
+	}
+
+	// 5. выпускаем новую пару токенов
+	accessToken, refreshToken, errorCode := a.generateTokens(user)
+	if errorCode != errors.NoError {
+		return AuthorizeOut{
+			ErrorCode: errorCode,
+		}
+	}
+
+	return AuthorizeOut{
+		UserID:       user.ID,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}
+}
+
+func (a *Auth) Logout(ctx context.Context, in LogoutIn) LogoutOut {
+	// 1. отзываем токен доступа, с которым пришёл запрос
+	accessClaims, err := a.tokenManager.ParseToken(in.AccessToken, cryptography.AccessToken)
+	if err != nil {
+		a.logger.Info("auth: parse access token err", zap.Error(err))
+		return LogoutOut{
+			ErrorCode: errors.AuthServiceAccessTokenInvalidErr,
+		}
+	}
+	_, err = a.revoker.Revoke(ctx, accessClaims.TokenID, accessClaims.ExpiresAt)
+	if err != nil {
+		a.logger.Error("auth: revoke access token err", zap.Error(err))
+		return LogoutOut{
+			ErrorCode: errors.AuthServiceRevokeTokenErr,
+		}
+	}
+
+	// 2. если передан refresh токен, отзываем и его
+	if in.RefreshToken == "" {
+		return LogoutOut{}
+	}
+	refreshClaims, err := a.tokenManager.ParseToken(in.RefreshToken, cryptography.RefreshToken)
+	if err != nil {
+		a.logger.Info("auth: parse refresh token err", zap.Error(err))
+		return LogoutOut{
+			ErrorCode: errors.AuthServiceRefreshTokenInvalidErr,
+		}
+	}
+	if refreshClaims.ID != accessClaims.ID {
+		return LogoutOut{
+			ErrorCode: errors.AuthServiceRefreshTokenInvalidErr,
+		}
+	}
+	_, err = a.revoker.Revoke(ctx, refreshClaims.TokenID, refreshClaims.ExpiresAt)
+	if err != nil {
+		a.logger.Error("auth: revoke refresh token err", zap.Error(err))
+		return LogoutOut{
+			ErrorCode: errors.AuthServiceRevokeTokenErr,
+		}
+	}
+
+	return LogoutOut{}
+}
+
+func (a *Auth) IsRevoked(ctx context.Context, in RevokedIn) RevokedOut {
+	revoked, err := a.revoker.IsRevoked(ctx, in.TokenID, in.UserID, in.IssuedAt)
+	if err != nil {
+		a.logger.Error("auth: check token revoked err", zap.Error(err))
+		return RevokedOut{
+			ErrorCode: errors.AuthServiceCheckRevokedErr,
+		}
+	}
+
+	return RevokedOut{
+		Revoked: revoked,
+	}
+}
+
+// RevokeUserTokens - отзывает все выпущенные до этого момента токены пользователя: после него
+// пользователю нужно войти заново
+func (a *Auth) RevokeUserTokens(ctx context.Context, username string) RevokeUserTokensOut {
+	userOut := a.user.GetByUsername(ctx, username)
+	if userOut.ErrorCode != errors.NoError {
+		return RevokeUserTokensOut{
+			ErrorCode: userOut.ErrorCode,
+		}
+	}
+
+	if err := a.revoker.RevokeUser(ctx, userOut.User.ID, time.Now()); err != nil {
+		a.logger.Error("auth: revoke user tokens err", zap.Error(err))
+		return RevokeUserTokensOut{
+			ErrorCode: errors.AuthServiceRevokeTokenErr,
+		}
+	}
+
+	return RevokeUserTokensOut{}
+}
+
 func (a *Auth) generateTokens(user *models.User) (string, string, int) {
 	accessToken, err := a.tokenManager.CreateToken(
 		strconv.Itoa(user.ID),
+		models.RoleName(user.UserStatus),
 		"",
 		a.conf.Token.AccessTTL,
 		cryptography.AccessToken,
@@ -115,12 +267,13 @@ func (a *Auth) generateTokens(user *models.User) (string, string, int) {
 	}
 	refreshToken, err := a.tokenManager.CreateToken(
 		strconv.Itoa(user.ID),
+		models.RoleName(user.UserStatus),
 		"",
 		a.conf.Token.RefreshTTL,
 		cryptography.RefreshToken,
 	)
 	if err != nil {
-		a.logger.Error("auth: create access token err", zap.Error(err))
+		a.logger.Error("auth: create refresh token err", zap.Error(err))
 		return "", "", errors.AuthServiceRefreshTokenGenerationErr
 	}
 
@@ -1,10 +1,17 @@
 package service
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Auther interface {
 	CreateUser(ctx context.Context, in CreateUserIn) CreateUserOut
 	AuthorizeEmail(ctx context.Context, in AuthorizeEmailIn) AuthorizeOut
+	Refresh(ctx context.Context, in RefreshIn) AuthorizeOut
+	Logout(ctx context.Context, in LogoutIn) LogoutOut
+	IsRevoked(ctx context.Context, in RevokedIn) RevokedOut
+	RevokeUserTokens(ctx context.Context, username string) RevokeUserTokensOut
 }
 
 type CreateUserIn struct {
@@ -27,9 +34,36 @@ type AuthorizeEmailIn struct {
 	RetypePassword string
 }
 
+type RefreshIn struct {
+	RefreshToken string
+}
+
 type AuthorizeOut struct {
 	UserID       int
 	AccessToken  string
 	RefreshToken string
 	ErrorCode    int
-}
\ No newline at end of file
+}
+type LogoutIn struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+type LogoutOut struct {
+	ErrorCode int
+}
+
+type RevokedIn struct {
+	TokenID  string
+	UserID   int
+	IssuedAt time.Time
+}
+
+type RevokedOut struct {
+	Revoked   bool
+	ErrorCode int
+}
+
+type RevokeUserTokensOut struct {
+	ErrorCode int
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"pet-store/config"
+	"pet-store/internal/infrastructure/component"
+	"pet-store/internal/infrastructure/errors"
+	"pet-store/internal/infrastructure/tools/cryptography"
+	"pet-store/internal/models"
+	"pet-store/internal/modules/auth/storage"
+	uservice "pet-store/internal/modules/user/service"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// userStub - пользователь, которого Refresh перечитывает перед выпуском новых токенов
+type userStub struct {
+	uservice.Userer
+}
+
+func (u userStub) GetByID(ctx context.Context, id int) uservice.UserOut {
+	return uservice.UserOut{User: &models.User{ID: id}}
+}
+
+func TestRefreshConcurrent(t *testing.T) {
+	conf := config.AppConf{Token: config.Token{
+		AccessTTL:     time.Minute,
+		RefreshTTL:    time.Hour,
+		AccessSecret:  "access",
+		RefreshSecret: "refresh",
+	}}
+	tokenManager := cryptography.NewTokenJWT(conf.Token)
+	auth := NewAuth(userStub{}, storage.NewMemoryRevokeStorage(), &component.Components{
+		Conf:         conf,
+		TokenManager: tokenManager,
+		Logger:       zap.NewNop(),
+	})
+
+	refreshToken, err := tokenManager.CreateToken(strconv.Itoa(1), "user", "", time.Hour, cryptography.RefreshToken)
+	require.NoError(t, err)
+
+	// Одноразовый refresh токен обменивается на новую пару только одним из параллельных запросов
+	const requests = 20
+	codes := make([]int, requests)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = auth.Refresh(context.Background(), RefreshIn{RefreshToken: refreshToken}).ErrorCode
+		}(i)
+	}
+	wg.Wait()
+
+	var issued, revoked int
+	for _, code := range codes {
+		switch code {
+		case errors.NoError:
+			issued++
+		case errors.AuthServiceTokenRevokedErr:
+			revoked++
+		}
+	}
+	assert.Equal(t, 1, issued)
+	assert.Equal(t, requests-1, revoked)
+}
+
+func TestRefreshAfterUserRevoke(t *testing.T) {
+	conf := config.AppConf{Token: config.Token{
+		AccessTTL:     time.Minute,
+		RefreshTTL:    time.Hour,
+		AccessSecret:  "access",
+		RefreshSecret: "refresh",
+	}}
+	tokenManager := cryptography.NewTokenJWT(conf.Token)
+	revoker := storage.NewMemoryRevokeStorage()
+	auth := NewAuth(userStub{}, revoker, &component.Components{
+		Conf:         conf,
+		TokenManager: tokenManager,
+		Logger:       zap.NewNop(),
+	})
+
+	refreshToken, err := tokenManager.CreateToken(strconv.Itoa(1), "user", "", time.Hour, cryptography.RefreshToken)
+	require.NoError(t, err)
+
+	// Refresh токен, выпущенный до отзыва всех токенов пользователя, новую пару не даёт
+	require.NoError(t, revoker.RevokeUser(context.Background(), 1, time.Now().Add(time.Second)))
+	out := auth.Refresh(context.Background(), RefreshIn{RefreshToken: refreshToken})
+	assert.Equal(t, errors.AuthServiceTokenRevokedErr, out.ErrorCode)
+}
@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+	"pet-store/internal/db/adapter"
+	"time"
+)
+
+// RevokeStorage - хранилище отозванных токенов в БД
+type RevokeStorage struct {
+	adapter *adapter.SQLAdapter
+}
+
+// NewRevokeStorage - конструктор хранилища отозванных токенов
+func NewRevokeStorage(sqlAdapter *adapter.SQLAdapter) *RevokeStorage {
+	return &RevokeStorage{adapter: sqlAdapter}
+}
+
+func (s *RevokeStorage) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error) {
+	return s.adapter.RevokeToken(ctx, tokenID, expiresAt)
+}
+
+func (s *RevokeStorage) IsRevoked(ctx context.Context, tokenID string, userID int, issuedAt time.Time) (bool, error) {
+	return s.adapter.IsTokenRevoked(ctx, tokenID, userID, issuedAt)
+}
+
+func (s *RevokeStorage) RevokeUser(ctx context.Context, userID int, at time.Time) error {
+	return s.adapter.RevokeUserTokens(ctx, userID, at)
+}
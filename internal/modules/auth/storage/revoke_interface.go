@@ -0,0 +1,15 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+type Revoker interface {
+	// Revoke - отзывает токен, false - токен уже был отозван
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error)
+	// IsRevoked - токен отозван сам или вместе со всеми токенами пользователя, выпущенными до issuedAt
+	IsRevoked(ctx context.Context, tokenID string, userID int, issuedAt time.Time) (bool, error)
+	// RevokeUser - отзывает все токены пользователя, выпущенные раньше at
+	RevokeUser(ctx context.Context, userID int, at time.Time) error
+}
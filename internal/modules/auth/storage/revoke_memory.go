@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryRevokeStorage - хранилище отозванных токенов в памяти процесса
+type MemoryRevokeStorage struct {
+	mu     sync.RWMutex
+	tokens map[string]time.Time
+	users  map[int]time.Time
+}
+
+// NewMemoryRevokeStorage - конструктор хранилища отозванных токенов в памяти
+func NewMemoryRevokeStorage() *MemoryRevokeStorage {
+	return &MemoryRevokeStorage{tokens: make(map[string]time.Time), users: make(map[int]time.Time)}
+}
+
+func (s *MemoryRevokeStorage) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Заодно удаляем записи о токенах, срок действия которых уже истёк
+	now := time.Now()
+	for id, exp := range s.tokens {
+		if exp.Before(now) {
+			delete(s.tokens, id)
+		}
+	}
+	if _, ok := s.tokens[tokenID]; ok {
+		return false, nil
+	}
+	s.tokens[tokenID] = expiresAt
+
+	return true, nil
+}
+
+func (s *MemoryRevokeStorage) IsRevoked(ctx context.Context, tokenID string, userID int, issuedAt time.Time) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.tokens[tokenID]; ok {
+		return true, nil
+	}
+	validAfter, ok := s.users[userID]
+	return ok && validAfter.After(issuedAt), nil
+}
+
+func (s *MemoryRevokeStorage) RevokeUser(ctx context.Context, userID int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Время выпуска в токене хранится с точностью до секунды, как и в хранилище в БД
+	at = at.Truncate(time.Second)
+	if at.After(s.users[userID]) {
+		s.users[userID] = at
+	}
+
+	return nil
+}
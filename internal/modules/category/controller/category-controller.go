@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"net/http"
+	"pet-store/internal/infrastructure/component"
+	"pet-store/internal/infrastructure/errors"
+	"pet-store/internal/infrastructure/responder"
+	"pet-store/internal/modules/category/service"
+
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator"
+	"github.com/ptflp/godecoder"
+)
+
+type Categorer interface {
+	CreateCategory(w http.ResponseWriter, r *http.Request)
+	ListCategories(w http.ResponseWriter, r *http.Request)
+	RenameCategory(w http.ResponseWriter, r *http.Request)
+	DeleteCategory(w http.ResponseWriter, r *http.Request)
+}
+
+type Category struct {
+	service service.Categorer
+	responder.Responder
+	godecoder.Decoder
+}
+
+func NewCategory(service service.Categorer, components *component.Components) Categorer {
+	return &Category{service: service, Responder: components.Responder, Decoder: components.Decoder}
+}
+
+// @Summary Create Category
+// @Security ApiKeyAuth
+// @Tags category
+// @Description create a new pet category, the name must be unique
+// @ID CreateCategory
+// @Accept  json
+// @Produce  json
+// @Param input body service.RequestCategory true "Category"
+// @Success 200 {object} CreateCategoryResponse "Success"
+// @Failure 400 {object} CategoryResponseErr "Invalid name"
+// @Failure 409 {object} CategoryResponseErr "Category already exists"
+// @Router /category [post]
+func (c *Category) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var req service.RequestCategory
+	if err := c.Decode(r.Body, &req); err != nil {
+		c.ErrorBadRequest(w, err)
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		c.ErrorBadRequest(w, err)
+		return
+	}
+
+	out := c.service.CreateCategory(r.Context(), req)
+	if out.ErrorCode != errors.NoError {
+		c.outputCategoryError(w, out.ErrorCode, "error create category")
+		return
+	}
+
+	c.OutputJSON(w, CreateCategoryResponse{
+		Success:    true,
+		CategoryID: out.ID,
+	})
+}
+
+// @Summary List Categories
+// @Security ApiKeyAuth
+// @Tags category
+// @Description list all categories with the number of pets in each
+// @ID ListCategories
+// @Produce  json
+// @Success 200 {object} ListCategoriesResponse "Success"
+// @Router /category [get]
+func (c *Category) ListCategories(w http.ResponseWriter, r *http.Request) {
+	out := c.service.ListCategories(r.Context())
+	if out.ErrorCode != errors.NoError {
+		c.outputCategoryError(w, out.ErrorCode, "error list categories")
+		return
+	}
+
+	c.OutputJSON(w, ListCategoriesResponse{
+		Success:    true,
+		Categories: out.Categories,
+	})
+}
+
+// @Summary Rename Category
+// @Security ApiKeyAuth
+// @Tags category
+// @Description rename a category, the new name must be unique
+// @ID RenameCategory
+// @Accept  json
+// @Produce  json
+// @Param categoryId path string true "Category ID"
+// @Param input body service.RequestCategory true "New name"
+// @Success 200 {object} CategoryResponse "Success"
+// @Failure 400 {object} CategoryResponseErr "Invalid id or name"
+// @Failure 404 {object} CategoryResponseErr "Category not found"
+// @Failure 409 {object} CategoryResponseErr "Category already exists"
+// @Router /category/{categoryId} [put]
+func (c *Category) RenameCategory(w http.ResponseWriter, r *http.Request) {
+	var req service.RequestCategory
+	if err := c.Decode(r.Body, &req); err != nil {
+		c.ErrorBadRequest(w, err)
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		c.ErrorBadRequest(w, err)
+		return
+	}
+
+	out := c.service.RenameCategory(r.Context(), chi.URLParam(r, "categoryId"), req)
+	if out.ErrorCode != errors.NoError {
+		c.outputCategoryError(w, out.ErrorCode, "error rename category")
+		return
+	}
+
+	c.OutputJSON(w, CategoryResponse{
+		Success: true,
+	})
+}
+
+// @Summary Delete Category
+// @Security ApiKeyAuth
+// @Tags category
+// @Description delete a category. A category that still has pets can only be deleted with reassignTo, its pets are moved to that category.
+// @ID DeleteCategory
+// @Produce  json
+// @Param categoryId path string true "Category ID"
+// @Param reassignTo query string false "Category ID to move the pets to"
+// @Success 200 {object} CategoryResponse "Success"
+// @Failure 400 {object} CategoryResponseErr "Invalid id"
+// @Failure 404 {object} CategoryResponseErr "Category not found"
+// @Failure 409 {object} CategoryResponseErr "Category still has pets"
+// @Router /category/{categoryId} [delete]
+func (c *Category) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	out := c.service.DeleteCategory(r.Context(), chi.URLParam(r, "categoryId"), r.URL.Query().Get("reassignTo"))
+	if out.ErrorCode != errors.NoError {
+		c.outputCategoryError(w, out.ErrorCode, "error delete category")
+		return
+	}
+
+	c.OutputJSON(w, CategoryResponse{
+		Success: true,
+	})
+}
+
+// outputCategoryError - отвечает ошибкой с HTTP статусом, соответствующим коду ошибки сервиса
+func (c *Category) outputCategoryError(w http.ResponseWriter, errorCode int, msg string) {
+	switch errorCode {
+	case errors.CategoryBadRequest:
+		w.WriteHeader(http.StatusBadRequest)
+		msg = "invalid category id or name"
+	case errors.CategoryServiceNotFoundErr:
+		w.WriteHeader(http.StatusNotFound)
+		msg = "no category found with the provided id"
+	case errors.CategoryServiceReassignNotFoundErr:
+		w.WriteHeader(http.StatusBadRequest)
+		msg = "no category found to reassign pets to"
+	case errors.CategoryServiceExistsErr:
+		w.WriteHeader(http.StatusConflict)
+		msg = "category with this name already exists"
+	case errors.CategoryServiceInUseErr:
+		w.WriteHeader(http.StatusConflict)
+		msg = "category still has pets, pass reassignTo to move them"
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	c.OutputJSON(w, CategoryResponseErr{
+		Success:   false,
+		ErrorCode: errorCode,
+		Data: Data{
+			Message: msg,
+		},
+	})
+}
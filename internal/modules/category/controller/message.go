@@ -0,0 +1,27 @@
+package controller
+
+import "pet-store/internal/models"
+
+type CategoryResponseErr struct {
+	Success   bool
+	ErrorCode int
+	Data      Data
+}
+
+type Data struct {
+	Message string
+}
+
+type CreateCategoryResponse struct {
+	Success    bool
+	CategoryID int
+}
+
+type ListCategoriesResponse struct {
+	Success    bool
+	Categories []models.CategoryWithCount
+}
+
+type CategoryResponse struct {
+	Success bool
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"pet-store/internal/models"
+)
+
+type Categorer interface {
+	CreateCategory(ctx context.Context, req RequestCategory) ResponseCreateCategory
+	ListCategories(ctx context.Context) ResponseListCategories
+	RenameCategory(ctx context.Context, categoryIDstr string, req RequestCategory) ResponseCategory
+	DeleteCategory(ctx context.Context, categoryIDstr, reassignTostr string) ResponseCategory
+}
+
+type RequestCategory struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type ResponseCreateCategory struct {
+	Status    bool
+	ErrorCode int
+	ID        int
+}
+
+type ResponseListCategories struct {
+	Status     bool
+	ErrorCode  int
+	Categories []models.CategoryWithCount
+}
+
+type ResponseCategory struct {
+	Status    bool
+	ErrorCode int
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"pet-store/internal/infrastructure/errors"
+	"pet-store/internal/modules/category/storage"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+// maxCategoryNameLen - максимальная длина имени категории (размер колонки category.name)
+const maxCategoryNameLen = 55
+
+type CategoryService struct {
+	storage storage.Categorer
+	logger  *zap.Logger
+}
+
+func NewCategoryService(storage storage.Categorer, logger *zap.Logger) *CategoryService {
+	return &CategoryService{storage: storage, logger: logger}
+}
+
+func (c *CategoryService) CreateCategory(ctx context.Context, req RequestCategory) ResponseCreateCategory {
+	name, ok := normalizeName(req.Name)
+	if !ok {
+		return ResponseCreateCategory{
+			Status:    false,
+			ErrorCode: errors.CategoryBadRequest,
+		}
+	}
+
+	id, err := c.storage.CreateCategory(ctx, name)
+	if err != nil {
+		if err == errors.ErrCategoryExists {
+			return ResponseCreateCategory{
+				Status:    false,
+				ErrorCode: errors.CategoryServiceExistsErr,
+			}
+		}
+		c.logger.Error("Error CreateCategory:", zap.Error(err))
+		return ResponseCreateCategory{
+			Status:    false,
+			ErrorCode: errors.CategoryServiceCreateErr,
+		}
+	}
+
+	return ResponseCreateCategory{
+		Status: true,
+		ID:     id,
+	}
+}
+
+func (c *CategoryService) ListCategories(ctx context.Context) ResponseListCategories {
+	categories, err := c.storage.ListCategories(ctx)
+	if err != nil {
+		c.logger.Error("Error ListCategories:", zap.Error(err))
+		return ResponseListCategories{
+			Status:    false,
+			ErrorCode: errors.CategoryServiceListErr,
+		}
+	}
+
+	return ResponseListCategories{
+		Status:     true,
+		Categories: categories,
+	}
+}
+
+func (c *CategoryService) RenameCategory(ctx context.Context, categoryIDstr string, req RequestCategory) ResponseCategory {
+	categoryID, err := strconv.Atoi(categoryIDstr)
+	name, ok := normalizeName(req.Name)
+	if err != nil || categoryID <= 0 || !ok {
+		return ResponseCategory{
+			Status:    false,
+			ErrorCode: errors.CategoryBadRequest,
+		}
+	}
+
+	err = c.storage.RenameCategory(ctx, categoryID, name)
+	if err != nil {
+		c.logger.Error("Error RenameCategory:", zap.Error(err))
+		return ResponseCategory{
+			Status:    false,
+			ErrorCode: categoryErrorCode(err, errors.CategoryServiceRenameErr),
+		}
+	}
+
+	return ResponseCategory{
+		Status: true,
+	}
+}
+
+// DeleteCategory - удаляет категорию. Пустой reassignTostr запрещает удаление категории с питомцами,
+// иначе питомцы переносятся в указанную категорию
+func (c *CategoryService) DeleteCategory(ctx context.Context, categoryIDstr, reassignTostr string) ResponseCategory {
+	categoryID, err := strconv.Atoi(categoryIDstr)
+	if err != nil || categoryID <= 0 {
+		return ResponseCategory{
+			Status:    false,
+			ErrorCode: errors.CategoryBadRequest,
+		}
+	}
+	var reassignTo int
+	if reassignTostr != "" {
+		reassignTo, err = strconv.Atoi(reassignTostr)
+		if err != nil || reassignTo <= 0 || reassignTo == categoryID {
+			return ResponseCategory{
+				Status:    false,
+				ErrorCode: errors.CategoryBadRequest,
+			}
+		}
+	}
+
+	err = c.storage.DeleteCategory(ctx, categoryID, reassignTo)
+	if err != nil {
+		c.logger.Error("Error DeleteCategory:", zap.Error(err))
+		return ResponseCategory{
+			Status:    false,
+			ErrorCode: categoryErrorCode(err, errors.CategoryServiceDeleteErr),
+		}
+	}
+
+	return ResponseCategory{
+		Status: true,
+	}
+}
+
+// normalizeName - убирает пробелы по краям и проверяет длину имени категории
+func normalizeName(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxCategoryNameLen {
+		return "", false
+	}
+
+	return name, true
+}
+
+// categoryErrorCode - переводит ошибку хранилища в код ошибки сервиса
+func categoryErrorCode(err error, fallback int) int {
+	switch err {
+	case errors.ErrCategoryNotFound:
+		return errors.CategoryServiceNotFoundErr
+	case errors.ErrCategoryExists:
+		return errors.CategoryServiceExistsErr
+	case errors.ErrCategoryInUse:
+		return errors.CategoryServiceInUseErr
+	case errors.ErrReassignCategoryNotFound:
+		return errors.CategoryServiceReassignNotFoundErr
+	}
+
+	return fallback
+}
@@ -0,0 +1,13 @@
+package storage
+
+import (
+	"context"
+	"pet-store/internal/models"
+)
+
+type Categorer interface {
+	CreateCategory(ctx context.Context, name string) (int, error)
+	ListCategories(ctx context.Context) ([]models.CategoryWithCount, error)
+	RenameCategory(ctx context.Context, categoryID int, name string) error
+	DeleteCategory(ctx context.Context, categoryID, reassignTo int) error
+}
@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+	"pet-store/internal/db/adapter"
+	"pet-store/internal/models"
+)
+
+// CategoryStorage - хранилище категорий питомцев
+type CategoryStorage struct {
+	adapter *adapter.SQLAdapter
+}
+
+// NewCategoryStorage - конструктор хранилища категорий
+func NewCategoryStorage(sqlAdapter *adapter.SQLAdapter) *CategoryStorage {
+	return &CategoryStorage{adapter: sqlAdapter}
+}
+
+func (c *CategoryStorage) CreateCategory(ctx context.Context, name string) (int, error) {
+	return c.adapter.CreateCategory(ctx, name)
+}
+
+func (c *CategoryStorage) ListCategories(ctx context.Context) ([]models.CategoryWithCount, error) {
+	return c.adapter.ListCategories(ctx)
+}
+
+func (c *CategoryStorage) RenameCategory(ctx context.Context, categoryID int, name string) error {
+	return c.adapter.RenameCategory(ctx, categoryID, name)
+}
+
+func (c *CategoryStorage) DeleteCategory(ctx context.Context, categoryID, reassignTo int) error {
+	return c.adapter.DeleteCategory(ctx, categoryID, reassignTo)
+}
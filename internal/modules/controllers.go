@@ -3,16 +3,20 @@ package modules
 import (
 	"pet-store/internal/infrastructure/component"
 	acontroller "pet-store/internal/modules/auth/controller"
+	ccontroller "pet-store/internal/modules/category/controller"
 	ocontroller "pet-store/internal/modules/order/controller"
 	pcontroller "pet-store/internal/modules/pet/controller"
+	tcontroller "pet-store/internal/modules/tag/controller"
 	ucontroller "pet-store/internal/modules/user/controller"
 )
 
 type Controllers struct {
-	Auth  acontroller.Auther
-	User  ucontroller.Userer
-	Pet   pcontroller.Peter
-	Order ocontroller.Orderer
+	Auth     acontroller.Auther
+	User     ucontroller.Userer
+	Pet      pcontroller.Peter
+	Order    ocontroller.Orderer
+	Category ccontroller.Categorer
+	Tag      tcontroller.Tagger
 }
 
 func NewControllers(services *Services, components *component.Components) *Controllers {
@@ -20,10 +24,14 @@ func NewControllers(services *Services, components *component.Components) *Contr
 	userController := ucontroller.NewUser(services.User, components)
 	petController := pcontroller.NewPet(services.Pet, components)
 	orderController := ocontroller.NewOrder(services.Order, components)
+	categoryController := ccontroller.NewCategory(services.Category, components)
+	tagController := tcontroller.NewTag(services.Tag, components)
 	return &Controllers{
-		Auth:  authController,
-		User:  userController,
-		Pet:   petController,
-		Order: orderController,
+		Auth:     authController,
+		User:     userController,
+		Pet:      petController,
+		Order:    orderController,
+		Category: categoryController,
+		Tag:      tagController,
 	}
 }
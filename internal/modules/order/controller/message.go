@@ -25,3 +25,26 @@ type OrderFindByIDResponse struct {
 type OrderDeleteByIDResponse struct {
 	Success bool
 }
+
+type OrderListResponse struct {
+	Success bool
+	Orders  []models.Order
+	Limit   int
+	Offset  int
+}
+
+type InventoryResponse struct {
+	Success    bool
+	Inventory  map[string]int            `json:",omitempty"`
+	ByCategory map[string]map[string]int `json:",omitempty"`
+}
+
+type OrderChangeStatusResponse struct {
+	Success bool
+	Order   models.Order
+}
+
+type OrderHistoryResponse struct {
+	Success bool
+	History []models.OrderStatusChange
+}
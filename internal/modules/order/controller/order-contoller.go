@@ -2,12 +2,15 @@ package controller
 
 import (
 	"net/http"
+	"strconv"
 	"pet-store/internal/infrastructure/component"
 	"pet-store/internal/infrastructure/errors"
 	"pet-store/internal/infrastructure/responder"
+	"pet-store/internal/infrastructure/tools/cryptography"
 	"pet-store/internal/modules/order/service"
 
 	"github.com/go-chi/chi"
+	"github.com/go-playground/validator"
 	"github.com/ptflp/godecoder"
 )
 
@@ -15,6 +18,11 @@ type Orderer interface {
 	CreateOrder(w http.ResponseWriter, r *http.Request)
 	FindOrderByID(w http.ResponseWriter, r *http.Request)
 	DeleteOrderByID(w http.ResponseWriter, r *http.Request)
+	FindOrders(w http.ResponseWriter, r *http.Request)
+	FindUserOrders(w http.ResponseWriter, r *http.Request)
+	Inventory(w http.ResponseWriter, r *http.Request)
+	ChangeOrderStatus(w http.ResponseWriter, r *http.Request)
+	FindOrderHistory(w http.ResponseWriter, r *http.Request)
 }
 
 type Order struct {
@@ -28,6 +36,7 @@ func NewOrder(service service.Orderer, components *component.Components) Orderer
 }
 
 // @Summary Delete Order
+// @Security ApiKeyAuth
 // @Tags store
 // @Description delete order by ID
 // @ID Delete Order
@@ -43,8 +52,12 @@ func (o *Order) DeleteOrderByID(w http.ResponseWriter, r *http.Request) {
 	out := o.service.DeleteOrderByID(r.Context(), req)
 	if out.ErrorCode != errors.NoError {
 		msg := "error delete order by ID"
-		if out.ErrorCode == errors.OrderServiceDeleteByIDNotFoundID {
+		switch out.ErrorCode {
+		case errors.OrderServiceDeleteByIDNotFoundID:
 			msg = "id not found"
+		case errors.OrderServiceForbiddenErr:
+			w.WriteHeader(http.StatusForbidden)
+			msg = "access to another user's order is forbidden"
 		}
 		o.OutputJSON(w, OrderResponseErr{
 			Success:   false,
@@ -62,6 +75,7 @@ func (o *Order) DeleteOrderByID(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Find Order
+// @Security ApiKeyAuth
 // @Tags store
 // @Description find order by ID
 // @ID Find Order
@@ -77,8 +91,12 @@ func (o *Order) FindOrderByID(w http.ResponseWriter, r *http.Request) {
 	out := o.service.FindOrderByID(r.Context(), req)
 	if out.ErrorCode != errors.NoError {
 		msg := "error find order by ID"
-		if out.ErrorCode == errors.OrderServiceFindByIDNotFoundID {
+		switch out.ErrorCode {
+		case errors.OrderServiceFindByIDNotFoundID:
 			msg = "order id not found"
+		case errors.OrderServiceForbiddenErr:
+			w.WriteHeader(http.StatusForbidden)
+			msg = "access to another user's order is forbidden"
 		}
 		o.OutputJSON(w, OrderResponseErr{
 			Success:   false,
@@ -97,8 +115,9 @@ func (o *Order) FindOrderByID(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Create Order
+// @Security ApiKeyAuth
 // @Tags store
-// @Description create order 
+// @Description create order for an available pet, the pet is reserved until the order is delivered or cancelled
 // @ID Create Order
 // @Accept  json
 // @Produce  json
@@ -115,10 +134,26 @@ func (o *Order) CreateOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Заказ привязывается к пользователю из токена
+	caller, ok := cryptography.UserFromContext(r.Context())
+	if !ok {
+		o.ErrorUnauthorized(w, errors.ErrTokenExtractUser)
+		return
+	}
+	req.UserID = caller.ID
+
 	out := o.service.CreateOrder(r.Context(), req)
 
 	if out.ErrorCode != errors.NoError {
 		msg := "error create order"
+		switch out.ErrorCode {
+		case errors.OrderServicePetNotFoundErr:
+			w.WriteHeader(http.StatusNotFound)
+			msg = "pet not found"
+		case errors.OrderServicePetNotAvailableErr:
+			w.WriteHeader(http.StatusConflict)
+			msg = "pet is not available for order"
+		}
 		o.OutputJSON(w, OrderResponseErr{
 			Success:   false,
 			ErrorCode: out.ErrorCode,
@@ -134,3 +169,230 @@ func (o *Order) CreateOrder(w http.ResponseWriter, r *http.Request) {
 		OrderID: out.ID,
 	})
 }
+
+// @Summary List my orders
+// @Security ApiKeyAuth
+// @Tags store
+// @Description list orders of the current user, newest first
+// @ID List Orders
+// @Produce  json
+// @Param status query []string false "Order status filter" collectionFormat(multi)
+// @Param shipDateFrom query string false "Ship date lower bound (RFC3339)"
+// @Param shipDateTo query string false "Ship date upper bound (RFC3339)"
+// @Param limit query int false "Page size, 20 by default, at most 100"
+// @Param offset query int false "Number of orders to skip"
+// @Success 200 {object} OrderListResponse "Orders"
+// @Failure 400 {object} OrderResponseErr "Error"
+// @Router /store/order [get]
+func (o *Order) FindOrders(w http.ResponseWriter, r *http.Request) {
+	o.findOrders(w, r, "")
+}
+
+// @Summary List orders of a user
+// @Security ApiKeyAuth
+// @Tags store
+// @Description list orders of any user, newest first. Requires the admin role.
+// @ID List User Orders
+// @Produce  json
+// @Param userId path string true "ID of the user"
+// @Param status query []string false "Order status filter" collectionFormat(multi)
+// @Param shipDateFrom query string false "Ship date lower bound (RFC3339)"
+// @Param shipDateTo query string false "Ship date upper bound (RFC3339)"
+// @Param limit query int false "Page size, 20 by default, at most 100"
+// @Param offset query int false "Number of orders to skip"
+// @Success 200 {object} OrderListResponse "Orders"
+// @Failure 400 {object} OrderResponseErr "Error"
+// @Router /store/order/user/{userId} [get]
+func (o *Order) FindUserOrders(w http.ResponseWriter, r *http.Request) {
+	o.findOrders(w, r, chi.URLParam(r, "userId"))
+}
+
+func (o *Order) findOrders(w http.ResponseWriter, r *http.Request, userID string) {
+	query := r.URL.Query()
+
+	out := o.service.FindOrders(r.Context(), service.RequestFindOrders{
+		UserID:       userID,
+		Statuses:     query["status"],
+		ShipDateFrom: query.Get("shipDateFrom"),
+		ShipDateTo:   query.Get("shipDateTo"),
+		Limit:        query.Get("limit"),
+		Offset:       query.Get("offset"),
+	})
+	if out.ErrorCode != errors.NoError {
+		msg := "error find orders"
+		switch out.ErrorCode {
+		case errors.FindOrdersBadRequest:
+			w.WriteHeader(http.StatusBadRequest)
+			msg = "invalid query parameters"
+		case errors.OrderServiceForbiddenErr:
+			w.WriteHeader(http.StatusForbidden)
+			msg = "access to another user's orders is forbidden"
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		o.OutputJSON(w, OrderResponseErr{
+			Success:   false,
+			ErrorCode: out.ErrorCode,
+			Data: Data{
+				Message: msg,
+			},
+		})
+		return
+	}
+
+	o.OutputJSON(w, OrderListResponse{
+		Success: true,
+		Orders:  out.Orders,
+		Limit:   out.Limit,
+		Offset:  out.Offset,
+	})
+}
+
+// @Summary Store inventory
+// @Security ApiKeyAuth
+// @Tags store
+// @Description returns pet counts by status, optionally split by category
+// @ID Inventory
+// @Produce  json
+// @Param byCategory query bool false "Group counts by category"
+// @Success 200 {object} InventoryResponse "Inventory"
+// @Failure 400 {object} OrderResponseErr "Error"
+// @Router /store/inventory [get]
+func (o *Order) Inventory(w http.ResponseWriter, r *http.Request) {
+	var byCategory bool
+	if param := r.URL.Query().Get("byCategory"); param != "" {
+		var err error
+		byCategory, err = strconv.ParseBool(param)
+		if err != nil {
+			o.ErrorBadRequest(w, err)
+			return
+		}
+	}
+
+	out := o.service.Inventory(r.Context(), byCategory)
+	if out.ErrorCode != errors.NoError {
+		w.WriteHeader(http.StatusInternalServerError)
+		o.OutputJSON(w, OrderResponseErr{
+			Success:   false,
+			ErrorCode: out.ErrorCode,
+			Data: Data{
+				Message: "error get inventory",
+			},
+		})
+		return
+	}
+
+	o.OutputJSON(w, InventoryResponse{
+		Success:    true,
+		Inventory:  out.Inventory,
+		ByCategory: out.ByCategory,
+	})
+}
+
+// @Summary Change order status
+// @Security ApiKeyAuth
+// @Tags store
+// @Description moves an order to a new status. Allowed transitions: placed -> approved|cancelled, approved -> delivered|cancelled, delivered -> returned. Owners may only cancel their orders, other transitions require the admin role.
+// @ID Change Order Status
+// @Accept  json
+// @Produce  json
+// @Param orderId path string true "ID of the order"
+// @Param input body service.RequestChangeOrderStatus true "New status"
+// @Success 200 {object} OrderChangeStatusResponse "Success"
+// @Failure 400 {object} OrderResponseErr "Error"
+// @Failure 409 {object} OrderResponseErr "Illegal transition"
+// @Router /store/order/{orderId}/status [put]
+func (o *Order) ChangeOrderStatus(w http.ResponseWriter, r *http.Request) {
+	var req service.RequestChangeOrderStatus
+	err := o.Decode(r.Body, &req)
+	if err != nil {
+		o.ErrorBadRequest(w, err)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		o.ErrorBadRequest(w, err)
+		return
+	}
+
+	out := o.service.ChangeOrderStatus(r.Context(), chi.URLParam(r, "orderId"), req)
+	if out.ErrorCode != errors.NoError {
+		msg := "error change order status"
+		switch out.ErrorCode {
+		case errors.ChangeOrderStatusBadRequest, errors.OrderServiceUnknownStatusErr:
+			w.WriteHeader(http.StatusBadRequest)
+			msg = "invalid order id or status"
+		case errors.OrderServiceFindByIDNotFoundID:
+			w.WriteHeader(http.StatusNotFound)
+			msg = "order id not found"
+		case errors.OrderServiceForbiddenErr:
+			w.WriteHeader(http.StatusForbidden)
+			msg = "this status change is not allowed for you"
+		case errors.OrderServiceIllegalTransitionErr:
+			w.WriteHeader(http.StatusConflict)
+			msg = "illegal order status transition"
+		case errors.OrderServiceStatusConflictErr:
+			w.WriteHeader(http.StatusConflict)
+			msg = "order status has been changed concurrently, retry"
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		o.OutputJSON(w, OrderResponseErr{
+			Success:   false,
+			ErrorCode: out.ErrorCode,
+			Data: Data{
+				Message: msg,
+			},
+		})
+		return
+	}
+
+	o.OutputJSON(w, OrderChangeStatusResponse{
+		Success: true,
+		Order:   out.Order,
+	})
+}
+
+// @Summary Order status history
+// @Security ApiKeyAuth
+// @Tags store
+// @Description returns timestamped status transitions of an order
+// @ID Order History
+// @Produce  json
+// @Param orderId path string true "ID of the order"
+// @Success 200 {object} OrderHistoryResponse "History"
+// @Failure 400 {object} OrderResponseErr "Error"
+// @Router /store/order/{orderId}/history [get]
+func (o *Order) FindOrderHistory(w http.ResponseWriter, r *http.Request) {
+	out := o.service.FindOrderHistory(r.Context(), chi.URLParam(r, "orderId"))
+	if out.ErrorCode != errors.NoError {
+		msg := "error find order history"
+		switch out.ErrorCode {
+		case errors.FindOrderByIDErrorDuringConversion:
+			w.WriteHeader(http.StatusBadRequest)
+			msg = "invalid order id"
+		case errors.OrderServiceDeleteByIDNotFoundID:
+			w.WriteHeader(http.StatusNotFound)
+			msg = "order id not found"
+		case errors.OrderServiceForbiddenErr:
+			w.WriteHeader(http.StatusForbidden)
+			msg = "access to another user's order is forbidden"
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		o.OutputJSON(w, OrderResponseErr{
+			Success:   false,
+			ErrorCode: out.ErrorCode,
+			Data: Data{
+				Message: msg,
+			},
+		})
+		return
+	}
+
+	o.OutputJSON(w, OrderHistoryResponse{
+		Success: true,
+		History: out.History,
+	})
+}
@@ -10,14 +10,17 @@ type Orderer interface {
 	CreateOrder(ctx context.Context, order RequestCreateOrder) ResponseCreateOrder
 	FindOrderByID(ctx context.Context, orderIDstr string) ResponseFindOrderByID
 	DeleteOrderByID(ctx context.Context, orderIDstr string) ResponseDeleteOrderByID
+	FindOrders(ctx context.Context, req RequestFindOrders) ResponseFindOrders
+	Inventory(ctx context.Context, byCategory bool) ResponseInventory
+	ChangeOrderStatus(ctx context.Context, orderIDstr string, req RequestChangeOrderStatus) ResponseChangeOrderStatus
+	FindOrderHistory(ctx context.Context, orderIDstr string) ResponseOrderHistory
 }
 
 type RequestCreateOrder struct {
 	PetId    int       `json:"petid"`
+	UserID   int       `json:"-"`
 	Quantity int       `json:"quantity"`
 	ShipDate time.Time `json:"shipdate"`
-	Status   string    `json:"status"`
-	Complete bool      `json:"complete"`
 }
 
 type ResponseCreateOrder struct {
@@ -35,4 +38,45 @@ type ResponseFindOrderByID struct {
 type ResponseDeleteOrderByID struct {
 	Status    bool
 	ErrorCode int
-}
\ No newline at end of file
+}
+// RequestFindOrders - параметры списка заказов в том виде, в каком они пришли в запросе.
+// Пустой UserID означает заказы вызывающего пользователя.
+type RequestFindOrders struct {
+	UserID       string
+	Statuses     []string
+	ShipDateFrom string
+	ShipDateTo   string
+	Limit        string
+	Offset       string
+}
+
+type ResponseFindOrders struct {
+	Status    bool
+	ErrorCode int
+	Orders    []models.Order
+	Limit     int
+	Offset    int
+}
+
+type ResponseInventory struct {
+	Status     bool
+	ErrorCode  int
+	Inventory  map[string]int
+	ByCategory map[string]map[string]int
+}
+
+type RequestChangeOrderStatus struct {
+	Status string `json:"status" validate:"required"`
+}
+
+type ResponseChangeOrderStatus struct {
+	Status    bool
+	ErrorCode int
+	Order     models.Order
+}
+
+type ResponseOrderHistory struct {
+	Status    bool
+	ErrorCode int
+	History   []models.OrderStatusChange
+}
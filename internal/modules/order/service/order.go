@@ -4,13 +4,20 @@ import (
 	"context"
 	"database/sql"
 	"pet-store/internal/infrastructure/errors"
+	"pet-store/internal/infrastructure/tools/cryptography"
 	"pet-store/internal/models"
 	"pet-store/internal/modules/order/storage"
 	"strconv"
+	"time"
 
 	"go.uber.org/zap"
 )
 
+const (
+	defaultOrdersLimit = 20
+	maxOrdersLimit     = 100
+)
+
 type OrderService struct {
 	storage storage.Orderer
 	logger  *zap.Logger
@@ -37,10 +44,18 @@ func (o *OrderService) DeleteOrderByID(ctx context.Context, orderIDstr string) R
 		}
 	}
 
+	// Удалять чужие заказы может только администратор
+	if errorCode := o.checkAccess(ctx, orderID); errorCode != errors.NoError {
+		return ResponseDeleteOrderByID{
+			Status:    false,
+			ErrorCode: errorCode,
+		}
+	}
+
 	err = o.storage.DeleteOrderByID(ctx, orderID)
 	if err != nil {
 		o.logger.Error("Delete Order By ID:", zap.Error(err))
-		if err.Error() == "no order found with ID" {
+		if err == errors.ErrOrderNotFound {
 			return ResponseDeleteOrderByID{
 				Status:    false,
 				ErrorCode: errors.OrderServiceDeleteByIDNotFoundID,
@@ -89,6 +104,15 @@ func (o *OrderService) FindOrderByID(ctx context.Context, orderIDstr string) Res
 		}
 	}
 
+	// Смотреть чужие заказы может только администратор
+	caller, ok := cryptography.UserFromContext(ctx)
+	if !ok || !caller.CanAccess(order.UserID) {
+		return ResponseFindOrderByID{
+			Status:    false,
+			ErrorCode: errors.OrderServiceForbiddenErr,
+		}
+	}
+
 	return ResponseFindOrderByID{
 		Status: true,
 		Order:  order,
@@ -98,14 +122,29 @@ func (o *OrderService) FindOrderByID(ctx context.Context, orderIDstr string) Res
 func (o *OrderService) CreateOrder(ctx context.Context, order RequestCreateOrder) ResponseCreateOrder {
 	orderDto := models.Order{
 		PetID:    order.PetId,
+		UserID:   order.UserID,
 		Quantity: order.Quantity,
 		ShipDate: order.ShipDate,
-		Status:   order.Status,
-		Complete: order.Complete,
+		// Новый заказ всегда начинает жизненный цикл со статуса placed
+		Status:   models.OrderStatusPlaced,
+		Complete: false,
 	}
+	// Питомец проверяется и резервируется в одной транзакции с созданием заказа
 	ordID, err := o.storage.CreateOrder(ctx, orderDto)
 	if err != nil {
 		o.logger.Error("Error Create Order:", zap.Error(err))
+		switch err {
+		case errors.ErrPetNotFound:
+			return ResponseCreateOrder{
+				Status:    false,
+				ErrorCode: errors.OrderServicePetNotFoundErr,
+			}
+		case errors.ErrPetNotAvailable:
+			return ResponseCreateOrder{
+				Status:    false,
+				ErrorCode: errors.OrderServicePetNotAvailableErr,
+			}
+		}
 		return ResponseCreateOrder{
 			Status:    false,
 			ErrorCode: errors.OrderServiceCreateOrderErr,
@@ -117,3 +156,254 @@ func (o *OrderService) CreateOrder(ctx context.Context, order RequestCreateOrder
 		ID:     ordID,
 	}
 }
+
+func (o *OrderService) FindOrders(ctx context.Context, req RequestFindOrders) ResponseFindOrders {
+	caller, ok := cryptography.UserFromContext(ctx)
+	if !ok {
+		return ResponseFindOrders{
+			Status:    false,
+			ErrorCode: errors.OrderServiceForbiddenErr,
+		}
+	}
+
+	filter := models.OrderFilter{
+		UserID:   caller.ID,
+		Statuses: req.Statuses,
+		Limit:    defaultOrdersLimit,
+	}
+
+	// Заказы другого пользователя доступны только администратору
+	if req.UserID != "" {
+		userID, err := strconv.Atoi(req.UserID)
+		if err != nil || userID <= 0 {
+			o.logger.Error("Error during conversion:", zap.String("user_id", req.UserID))
+			return ResponseFindOrders{
+				Status:    false,
+				ErrorCode: errors.FindOrdersBadRequest,
+			}
+		}
+		if !caller.CanAccess(userID) {
+			return ResponseFindOrders{
+				Status:    false,
+				ErrorCode: errors.OrderServiceForbiddenErr,
+			}
+		}
+		filter.UserID = userID
+	}
+
+	var err error
+	if req.ShipDateFrom != "" {
+		filter.ShipDateFrom, err = time.Parse(time.RFC3339, req.ShipDateFrom)
+	}
+	if err == nil && req.ShipDateTo != "" {
+		filter.ShipDateTo, err = time.Parse(time.RFC3339, req.ShipDateTo)
+	}
+	if err == nil && req.Limit != "" {
+		filter.Limit, err = strconv.Atoi(req.Limit)
+	}
+	if err == nil && req.Offset != "" {
+		filter.Offset, err = strconv.Atoi(req.Offset)
+	}
+	if err != nil || filter.Limit <= 0 || filter.Offset < 0 {
+		o.logger.Error("Find Orders bad request:", zap.Error(err))
+		return ResponseFindOrders{
+			Status:    false,
+			ErrorCode: errors.FindOrdersBadRequest,
+		}
+	}
+	if filter.Limit > maxOrdersLimit {
+		filter.Limit = maxOrdersLimit
+	}
+
+	orders, err := o.storage.FindOrders(ctx, filter)
+	if err != nil {
+		o.logger.Error("Find Orders:", zap.Error(err))
+		return ResponseFindOrders{
+			Status:    false,
+			ErrorCode: errors.OrderServiceFindOrdersErr,
+		}
+	}
+
+	return ResponseFindOrders{
+		Status: true,
+		Orders: orders,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+}
+
+func (o *OrderService) Inventory(ctx context.Context, byCategory bool) ResponseInventory {
+	if byCategory {
+		inventory, err := o.storage.InventoryByCategory(ctx)
+		if err != nil {
+			o.logger.Error("Inventory By Category:", zap.Error(err))
+			return ResponseInventory{
+				Status:    false,
+				ErrorCode: errors.OrderServiceInventoryErr,
+			}
+		}
+		for category := range inventory {
+			fillStatuses(inventory[category])
+		}
+
+		return ResponseInventory{
+			Status:     true,
+			ByCategory: inventory,
+		}
+	}
+
+	inventory, err := o.storage.Inventory(ctx)
+	if err != nil {
+		o.logger.Error("Inventory:", zap.Error(err))
+		return ResponseInventory{
+			Status:    false,
+			ErrorCode: errors.OrderServiceInventoryErr,
+		}
+	}
+	fillStatuses(inventory)
+
+	return ResponseInventory{
+		Status:    true,
+		Inventory: inventory,
+	}
+}
+
+// fillStatuses - добавляет нулевые счётчики для статусов, по которым нет питомцев
+func fillStatuses(inventory map[string]int) {
+	for _, status := range models.PetStatuses {
+		if _, ok := inventory[status]; !ok {
+			inventory[status] = 0
+		}
+	}
+}
+
+func (o *OrderService) ChangeOrderStatus(ctx context.Context, orderIDstr string, req RequestChangeOrderStatus) ResponseChangeOrderStatus {
+	orderID, err := strconv.Atoi(orderIDstr)
+	if err != nil || orderID <= 0 {
+		o.logger.Error("Error during conversion:", zap.String("order_id", orderIDstr))
+		return ResponseChangeOrderStatus{
+			Status:    false,
+			ErrorCode: errors.ChangeOrderStatusBadRequest,
+		}
+	}
+	if !isKnownStatus(req.Status) {
+		return ResponseChangeOrderStatus{
+			Status:    false,
+			ErrorCode: errors.OrderServiceUnknownStatusErr,
+		}
+	}
+
+	order, err := o.storage.FindOrderByID(ctx, orderID)
+	if err != nil {
+		o.logger.Error("Change Order Status:", zap.Error(err))
+		if err == sql.ErrNoRows {
+			return ResponseChangeOrderStatus{
+				Status:    false,
+				ErrorCode: errors.OrderServiceFindByIDNotFoundID,
+			}
+		}
+		return ResponseChangeOrderStatus{
+			Status:    false,
+			ErrorCode: errors.OrderServiceChangeStatusErr,
+		}
+	}
+
+	// Владелец может только отменить свой заказ, остальные переходы выполняет администратор
+	caller, ok := cryptography.UserFromContext(ctx)
+	if !ok || !caller.CanAccess(order.UserID) ||
+		(!caller.IsAdmin() && req.Status != models.OrderStatusCancelled) {
+		return ResponseChangeOrderStatus{
+			Status:    false,
+			ErrorCode: errors.OrderServiceForbiddenErr,
+		}
+	}
+
+	if !canTransition(order.Status, req.Status) {
+		return ResponseChangeOrderStatus{
+			Status:    false,
+			ErrorCode: errors.OrderServiceIllegalTransitionErr,
+		}
+	}
+
+	complete := isComplete(req.Status)
+	err = o.storage.UpdateOrderStatus(ctx, orderID, order.Status, req.Status, complete, caller.ID, petStatusFor(req.Status))
+	if err != nil {
+		o.logger.Error("Change Order Status:", zap.Error(err))
+		if err == errors.ErrOrderStatusStale {
+			return ResponseChangeOrderStatus{
+				Status:    false,
+				ErrorCode: errors.OrderServiceStatusConflictErr,
+			}
+		}
+		return ResponseChangeOrderStatus{
+			Status:    false,
+			ErrorCode: errors.OrderServiceChangeStatusErr,
+		}
+	}
+
+	order.Status = req.Status
+	order.Complete = complete
+
+	return ResponseChangeOrderStatus{
+		Status: true,
+		Order:  order,
+	}
+}
+
+func (o *OrderService) FindOrderHistory(ctx context.Context, orderIDstr string) ResponseOrderHistory {
+	orderID, err := strconv.Atoi(orderIDstr)
+	if err != nil || orderID <= 0 {
+		o.logger.Error("Error during conversion:", zap.String("order_id", orderIDstr))
+		return ResponseOrderHistory{
+			Status:    false,
+			ErrorCode: errors.FindOrderByIDErrorDuringConversion,
+		}
+	}
+
+	// Историю чужих заказов может смотреть только администратор
+	if errorCode := o.checkAccess(ctx, orderID); errorCode != errors.NoError {
+		return ResponseOrderHistory{
+			Status:    false,
+			ErrorCode: errorCode,
+		}
+	}
+
+	history, err := o.storage.FindOrderHistory(ctx, orderID)
+	if err != nil {
+		o.logger.Error("Find Order History:", zap.Error(err))
+		return ResponseOrderHistory{
+			Status:    false,
+			ErrorCode: errors.OrderServiceHistoryErr,
+		}
+	}
+
+	return ResponseOrderHistory{
+		Status:  true,
+		History: history,
+	}
+}
+
+// checkAccess - проверяет, что вызывающий пользователь - владелец заказа или администратор
+func (o *OrderService) checkAccess(ctx context.Context, orderID int) int {
+	caller, ok := cryptography.UserFromContext(ctx)
+	if !ok {
+		return errors.OrderServiceForbiddenErr
+	}
+	if caller.IsAdmin() {
+		return errors.NoError
+	}
+
+	order, err := o.storage.FindOrderByID(ctx, orderID)
+	if err != nil {
+		o.logger.Error("Check Order Access:", zap.Error(err))
+		if err == sql.ErrNoRows {
+			return errors.OrderServiceDeleteByIDNotFoundID
+		}
+		return errors.OrderServiceDeleteByIDIternalErr
+	}
+	if order.UserID != caller.ID {
+		return errors.OrderServiceForbiddenErr
+	}
+
+	return errors.NoError
+}
@@ -0,0 +1,49 @@
+package service
+
+import "pet-store/internal/models"
+
+// orderTransitions - допустимые переходы между статусами заказа
+var orderTransitions = map[string][]string{
+	models.OrderStatusPlaced:    {models.OrderStatusApproved, models.OrderStatusCancelled},
+	models.OrderStatusApproved:  {models.OrderStatusDelivered, models.OrderStatusCancelled},
+	models.OrderStatusDelivered: {models.OrderStatusReturned},
+	models.OrderStatusCancelled: {},
+	models.OrderStatusReturned:  {},
+}
+
+// isKnownStatus - проверяет, что статус заказа существует
+func isKnownStatus(status string) bool {
+	_, ok := orderTransitions[status]
+	return ok
+}
+
+// canTransition - проверяет, что заказ можно перевести из статуса from в статус to
+func canTransition(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// isComplete - заказ считается завершённым, когда он доставлен, отменён или возвращён
+func isComplete(status string) bool {
+	switch status {
+	case models.OrderStatusDelivered, models.OrderStatusCancelled, models.OrderStatusReturned:
+		return true
+	}
+	return false
+}
+
+// petStatusFor - статус питомца после перехода заказа в указанный статус.
+// Пустая строка означает, что статус питомца не меняется.
+func petStatusFor(orderStatus string) string {
+	switch orderStatus {
+	case models.OrderStatusDelivered:
+		return models.PetStatusSold
+	case models.OrderStatusCancelled, models.OrderStatusReturned:
+		return models.PetStatusAvailable
+	}
+	return ""
+}
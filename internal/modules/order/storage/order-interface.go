@@ -9,4 +9,9 @@ type Orderer interface {
 	CreateOrder(ctx context.Context, order models.Order) (int, error)
 	FindOrderByID(ctx context.Context, orderID int) (models.Order, error)
 	DeleteOrderByID(ctx context.Context, orderID int)  error
+	FindOrders(ctx context.Context, filter models.OrderFilter) ([]models.Order, error)
+	Inventory(ctx context.Context) (map[string]int, error)
+	InventoryByCategory(ctx context.Context) (map[string]map[string]int, error)
+	UpdateOrderStatus(ctx context.Context, orderID int, from, to string, complete bool, changedBy int, petStatus string) error
+	FindOrderHistory(ctx context.Context, orderID int) ([]models.OrderStatusChange, error)
 }
@@ -26,4 +26,23 @@ func (o *OrderStorage) FindOrderByID(ctx context.Context, orderID int) (models.O
 
 func (o *OrderStorage) DeleteOrderByID(ctx context.Context, orderID int)  error {
 	return o.adapter.DeleteOrderByID(ctx, orderID)
-}
\ No newline at end of file
+}
+func (o *OrderStorage) FindOrders(ctx context.Context, filter models.OrderFilter) ([]models.Order, error) {
+	return o.adapter.FindOrders(ctx, filter)
+}
+
+func (o *OrderStorage) Inventory(ctx context.Context) (map[string]int, error) {
+	return o.adapter.PetInventory(ctx)
+}
+
+func (o *OrderStorage) InventoryByCategory(ctx context.Context) (map[string]map[string]int, error) {
+	return o.adapter.PetInventoryByCategory(ctx)
+}
+
+func (o *OrderStorage) UpdateOrderStatus(ctx context.Context, orderID int, from, to string, complete bool, changedBy int, petStatus string) error {
+	return o.adapter.UpdateOrderStatus(ctx, orderID, from, to, complete, changedBy, petStatus)
+}
+
+func (o *OrderStorage) FindOrderHistory(ctx context.Context, orderID int) ([]models.OrderStatusChange, error) {
+	return o.adapter.FindOrderHistory(ctx, orderID)
+}
@@ -20,6 +20,7 @@ type Data struct {
 type FindPetbyStatusResponse struct {
 	Success bool
 	Results []models.Pet
+	Next    string `json:",omitempty"`
 }
 
 type FindPetbyIDResponse struct {
@@ -30,3 +31,13 @@ type FindPetbyIDResponse struct {
 type SuccessRequest struct {
 	Success bool
 }
+
+type PurgeResponse struct {
+	Success bool
+	Purged  int
+}
+
+type UploadImageResponse struct {
+	Success bool
+	Result  models.PetImage
+}
@@ -1,15 +1,21 @@
 package controller
 
 import (
+	stderrors "errors"
+	"io"
 	"net/http"
 	"pet-store/internal/infrastructure/component"
 	"pet-store/internal/infrastructure/errors"
 	"pet-store/internal/infrastructure/responder"
 	"pet-store/internal/modules/pet/service"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi"
+	"github.com/go-playground/validator"
 	"github.com/ptflp/godecoder"
 	_ "github.com/vektra/mockery"
+	"go.uber.org/zap"
 )
 
 //go:generate mockery --name=Peter
@@ -17,33 +23,57 @@ import (
 type Peter interface {
 	AddPet(w http.ResponseWriter, r *http.Request)
 	UpdatePet(w http.ResponseWriter, r *http.Request)
+	PatchPet(w http.ResponseWriter, r *http.Request)
 	FindPetbyStatus(w http.ResponseWriter, r *http.Request)
+	FindPetbyTags(w http.ResponseWriter, r *http.Request)
+	SearchPets(w http.ResponseWriter, r *http.Request)
 	FindPetbyID(w http.ResponseWriter, r *http.Request)
 	UpdatePetForm(w http.ResponseWriter, r *http.Request)
+	DeletePet(w http.ResponseWriter, r *http.Request)
+	RestorePet(w http.ResponseWriter, r *http.Request)
+	PurgeDeletedPets(w http.ResponseWriter, r *http.Request)
+	UploadImage(w http.ResponseWriter, r *http.Request)
+	GetImage(w http.ResponseWriter, r *http.Request)
 }
 
+// multipartOverhead - запас на поля формы и заголовки частей сверх размера изображения
+const multipartOverhead = 1 << 20
+
 type Pet struct {
-	service service.Peter
+	service      service.Peter
+	maxImageSize int64
+	logger       *zap.Logger
 	responder.Responder
 	godecoder.Decoder
 }
 
 func NewPet(service service.Peter, components *component.Components) Peter {
-	return &Pet{service: service, Responder: components.Responder, Decoder: components.Decoder}
+	return &Pet{
+		service:      service,
+		maxImageSize: components.Conf.Pet.ImageMaxSize,
+		logger:       components.Logger,
+		Responder:    components.Responder,
+		Decoder:      components.Decoder,
+	}
 }
 
 // @Summary Update Pet
 // @Security ApiKeyAuth
 // @Tags pet
-// @Description Updates a pet in the store with form data
+// @Description Updates a pet in the store with form data. If-Match must hold the pet ETag from GET /pet/{petId} or "*".
 // @ID UpdatePet
 // @Accept  multipart/form-data
 // @Produce  json
 // @Param petId path string true "Pet ID to update"
+// @Param If-Match header string true "Pet ETag"
 // @Param name formData string false "Pet name"
 // @Param status formData string false "Pet status"
 // @Success 200 {object} SuccessRequest "Successfully updated pet"
+// @Header 200 {string} ETag "New pet ETag"
 // @Failure 400 {object} PetAddResponseErr "Error updating pet"
+// @Failure 404 {object} PetAddResponseErr "Pet not found"
+// @Failure 412 {object} PetAddResponseErr "Pet has been changed since ETag was issued"
+// @Failure 428 {object} PetAddResponseErr "If-Match is missing"
 // @Router /pet/{petId} [post]
 func (p *Pet) UpdatePetForm(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseForm()
@@ -51,25 +81,23 @@ func (p *Pet) UpdatePetForm(w http.ResponseWriter, r *http.Request) {
 		p.ErrorBadRequest(w, err)
 		return
 	}
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		p.outputPetError(w, errors.PetIfMatchRequired, "If-Match is required")
+		return
+	}
 
 	name := r.FormValue("name")
 	status := r.FormValue("status")
 	reqID := chi.URLParam(r, "petId")
 
-	out := p.service.UpdatePetForm(r.Context(), name, status, reqID)
+	out := p.service.UpdatePetForm(r.Context(), name, status, reqID, version)
 	if out.ErrorCode != errors.NoError {
-		w.WriteHeader(http.StatusInternalServerError)
-		msg := "error update pet"
-		p.OutputJSON(w, PetAddResponseErr{
-			Success:   false,
-			ErrorCode: out.ErrorCode,
-			Data: Data{
-				Message: msg,
-			},
-		})
+		p.outputPetError(w, out.ErrorCode, "error update pet")
 		return
 	}
 
+	w.Header().Set("ETag", petETag(out.Version))
 	p.OutputJSON(w, SuccessRequest{
 		Success: true,
 	})
@@ -83,6 +111,7 @@ func (p *Pet) UpdatePetForm(w http.ResponseWriter, r *http.Request) {
 // @Produce  json
 // @Param petId path string true "Pet ID to find"
 // @Success 200 {object} FindPetbyIDResponse "Successfully found pet"
+// @Header 200 {string} ETag "Pet ETag for If-Match"
 // @Router /pet/{petId} [get]
 func (p *Pet) FindPetbyID(w http.ResponseWriter, r *http.Request) {
 	req := chi.URLParam(r, "petId")
@@ -107,6 +136,7 @@ func (p *Pet) FindPetbyID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", petETag(out.Pet.Version))
 	p.OutputJSON(w, FindPetbyIDResponse{
 		Success: true,
 		Result:  out.Pet,
@@ -117,11 +147,16 @@ func (p *Pet) FindPetbyID(w http.ResponseWriter, r *http.Request) {
 // @Summary Find Pets by Status
 // @Security ApiKeyAuth
 // @Tags pet
-// @Description Find pets based on their status. The status parameter is required. Available values: available, pending, sold.
+// @Description Find pets based on their status. The status parameter is required and may be repeated or hold a comma-separated list. Available values: available, pending, sold. Results are paginated: pass Next from the response as cursor to get the following page.
 // @ID FindPetbyStatus
 // @Produce  json
 // @Param status query string true "Pet status to find" Enums(available, pending, sold)
+// @Param sort query string false "Sort key" Enums(id, name, created)
+// @Param order query string false "Sort direction" Enums(asc, desc)
+// @Param limit query int false "Page size, 20 by default, at most 100"
+// @Param cursor query string false "Cursor from Next of the previous page"
 // @Success 200 {object} FindPetbyStatusResponse "Successfully found pets"
+// @Failure 400 {object} PetAddResponseErr "Unknown status or invalid page parameters"
 // @Router /pet/findByStatus [get]
 func (p *Pet) FindPetbyStatus(w http.ResponseWriter, r *http.Request) {
 	req := r.URL.Query()["status"]
@@ -130,17 +165,42 @@ func (p *Pet) FindPetbyStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	out := p.service.FindPetbyStatus(r.Context(), req)
+	query := r.URL.Query()
+	out := p.service.FindPetbyStatus(r.Context(), req, service.PageRequest{
+		Cursor: query.Get("cursor"),
+		Limit:  query.Get("limit"),
+		Sort:   query.Get("sort"),
+		Order:  query.Get("order"),
+	})
 	if out.ErrorCode != errors.NoError {
-		w.WriteHeader(http.StatusInternalServerError)
-		msg := "Find pet by status error"
-		p.OutputJSON(w, PetAddResponseErr{
-			Success:   false,
-			ErrorCode: out.ErrorCode,
-			Data: Data{
-				Message: msg,
-			},
-		})
+		p.outputPetError(w, out.ErrorCode, "Find pet by status error")
+		return
+	}
+
+	p.OutputJSON(w, FindPetbyStatusResponse{
+		Success: true,
+		Results: out.Pets,
+		Next:    out.Next,
+	})
+}
+
+// @Summary Find Pets by Tags
+// @Security ApiKeyAuth
+// @Tags pet
+// @Description Find pets by tags. Tags can be passed as several parameters or comma separated. With match=any (default) a pet must have at least one of the tags, with match=all it must have all of them.
+// @ID FindPetbyTags
+// @Produce  json
+// @Param tags query []string true "Tags to filter by" collectionFormat(multi)
+// @Param match query string false "Matching mode" Enums(any, all)
+// @Success 200 {object} FindPetbyStatusResponse "Successfully found pets"
+// @Failure 400 {object} PetAddResponseErr "Invalid tags or match"
+// @Router /pet/findByTags [get]
+func (p *Pet) FindPetbyTags(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	out := p.service.FindPetbyTags(r.Context(), query["tags"], query.Get("match"))
+	if out.ErrorCode != errors.NoError {
+		p.outputPetError(w, out.ErrorCode, "Find pet by tags error")
 		return
 	}
 
@@ -150,6 +210,75 @@ func (p *Pet) FindPetbyStatus(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// @Summary Search Pets
+// @Security ApiKeyAuth
+// @Tags pet
+// @Description Search pets combining filters, every given filter must match. Results are paginated like findByStatus.
+// @Description With q pets are searched by words in the name, category and tags: results are ordered by relevance by default and matched words are wrapped in <mark> in highlight.
+// @ID SearchPets
+// @Produce  json
+// @Param q query string false "Full-text query in web search syntax: words, quoted phrases, or, -excluded"
+// @Param name query string false "Substring of the pet name, case insensitive"
+// @Param category query string false "Category name"
+// @Param tags query []string false "Tags" collectionFormat(multi)
+// @Param match query string false "Tags matching mode" Enums(any, all)
+// @Param status query []string false "Pet statuses" collectionFormat(multi)
+// @Param createdFrom query string false "Created at or after, RFC 3339"
+// @Param createdTo query string false "Created before, RFC 3339"
+// @Param breed query string false "Breed, case insensitive"
+// @Param sex query string false "Sex" Enums(male, female)
+// @Param color query string false "Color, case insensitive"
+// @Param microchipId query string false "Microchip ID"
+// @Param bornFrom query string false "Born on or after, YYYY-MM-DD"
+// @Param bornTo query string false "Born on or before, YYYY-MM-DD"
+// @Param weightMin query number false "Minimum weight, kg"
+// @Param weightMax query number false "Maximum weight, kg"
+// @Param sort query string false "Sort key, rank is available only with q" Enums(id, name, created, rank)
+// @Param order query string false "Sort direction" Enums(asc, desc)
+// @Param limit query int false "Page size, 20 by default, at most 100"
+// @Param cursor query string false "Cursor from Next of the previous page"
+// @Success 200 {object} FindPetbyStatusResponse "Successfully found pets"
+// @Failure 400 {object} PetAddResponseErr "Invalid filters or page parameters"
+// @Router /pet/search [get]
+func (p *Pet) SearchPets(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	out := p.service.SearchPets(r.Context(), service.RequestSearchPets{
+		Query:       query.Get("q"),
+		Name:        query.Get("name"),
+		Category:    query.Get("category"),
+		Tags:        query["tags"],
+		Match:       query.Get("match"),
+		Statuses:    query["status"],
+		CreatedFrom: query.Get("createdFrom"),
+		CreatedTo:   query.Get("createdTo"),
+		Breed:       query.Get("breed"),
+		Sex:         query.Get("sex"),
+		Color:       query.Get("color"),
+		MicrochipID: query.Get("microchipId"),
+		BornFrom:    query.Get("bornFrom"),
+		BornTo:      query.Get("bornTo"),
+		WeightMin:   query.Get("weightMin"),
+		WeightMax:   query.Get("weightMax"),
+		Page: service.PageRequest{
+			Cursor: query.Get("cursor"),
+			Limit:  query.Get("limit"),
+			Sort:   query.Get("sort"),
+			Order:  query.Get("order"),
+		},
+	})
+	if out.ErrorCode != errors.NoError {
+		p.outputPetError(w, out.ErrorCode, "Search pets error")
+		return
+	}
+
+	p.OutputJSON(w, FindPetbyStatusResponse{
+		Success: true,
+		Results: out.Pets,
+		Next:    out.Next,
+	})
+}
+
 // @Summary Add a new Pet
 // @Security ApiKeyAuth
 // @Tags pet
@@ -159,6 +288,8 @@ func (p *Pet) FindPetbyStatus(w http.ResponseWriter, r *http.Request) {
 // @Produce  json
 // @Param pet body service.PetAddRequest true "Pet to add"
 // @Success 200 {object} PetAddResponse "Successfully added pet"
+// @Failure 400 {object} PetAddResponseErr "Unknown category or invalid attributes"
+// @Failure 409 {object} PetAddResponseErr "Microchip ID belongs to another pet"
 // @Router /pet [post]
 func (p *Pet) AddPet(w http.ResponseWriter, r *http.Request) {
 	var req service.PetAddRequest
@@ -167,18 +298,15 @@ func (p *Pet) AddPet(w http.ResponseWriter, r *http.Request) {
 		p.ErrorBadRequest(w, err)
 		return
 	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		p.ErrorBadRequest(w, err)
+		return
+	}
 
 	out := p.service.AddPet(r.Context(), req)
 	if out.ErrorCode != errors.NoError {
-		w.WriteHeader(http.StatusInternalServerError)
-		msg := "AddPet error"
-		p.OutputJSON(w, PetAddResponseErr{
-			Success:   false,
-			ErrorCode: out.ErrorCode,
-			Data: Data{
-				Message: msg,
-			},
-		})
+		p.outputPetError(w, out.ErrorCode, "AddPet error")
 		return
 	}
 
@@ -194,11 +322,19 @@ func (p *Pet) AddPet(w http.ResponseWriter, r *http.Request) {
 // @Security ApiKeyAuth
 // @Tags pet
 // @Description Update an existing pet's details in the database based on the provided pet ID.
+// @Description If-Match must hold the pet ETag from GET /pet/{petId} or "*".
 // @ID UpdatePet
 // @Accept  json
 // @Produce  json
+// @Param If-Match header string true "Pet ETag"
 // @Param pet body service.PetUpdateRequest true "Pet data to update"
 // @Success 200 {object} PetAddResponse "Successfully updated pet"
+// @Header 200 {string} ETag "New pet ETag"
+// @Failure 400 {object} PetAddResponseErr "Unknown category or invalid attributes"
+// @Failure 404 {object} PetAddResponseErr "Pet not found"
+// @Failure 409 {object} PetAddResponseErr "Microchip ID belongs to another pet"
+// @Failure 412 {object} PetAddResponseErr "Pet has been changed since ETag was issued"
+// @Failure 428 {object} PetAddResponseErr "If-Match is missing"
 // @Router /pet [put]
 func (p *Pet) UpdatePet(w http.ResponseWriter, r *http.Request) {
 	var req service.PetUpdateRequest
@@ -207,21 +343,25 @@ func (p *Pet) UpdatePet(w http.ResponseWriter, r *http.Request) {
 		p.ErrorBadRequest(w, err)
 		return
 	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		p.ErrorBadRequest(w, err)
+		return
+	}
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		p.outputPetError(w, errors.PetIfMatchRequired, "If-Match is required")
+		return
+	}
+	req.Version = version
 
 	out := p.service.UpdatePet(r.Context(), req)
 	if out.ErrorCode != errors.NoError {
-		w.WriteHeader(http.StatusInternalServerError)
-		msg := "Update Pet error"
-		p.OutputJSON(w, PetAddResponseErr{
-			Success:   false,
-			ErrorCode: out.ErrorCode,
-			Data: Data{
-				Message: msg,
-			},
-		})
+		p.outputPetError(w, out.ErrorCode, "Update Pet error")
 		return
 	}
 
+	w.Header().Set("ETag", petETag(out.Version))
 	p.OutputJSON(w, PetAddResponse{
 		Success: true,
 		Data: Data{
@@ -229,3 +369,311 @@ func (p *Pet) UpdatePet(w http.ResponseWriter, r *http.Request) {
 		},
 	})
 }
+
+// @Summary Partially update a Pet
+// @Security ApiKeyAuth
+// @Tags pet
+// @Description Applies a JSON Merge Patch (RFC 7396) to the pet. Absent fields stay unchanged,
+// @Description null clears optional fields, photourls and tags are replaced as a whole.
+// @Description Status and category cannot be cleared. If-Match must hold the pet ETag or "*".
+// @ID PatchPet
+// @Accept  application/merge-patch+json
+// @Produce  json
+// @Param petId path string true "Pet ID to update"
+// @Param If-Match header string true "Pet ETag"
+// @Param pet body service.PetPatchRequest true "Pet fields to change"
+// @Success 200 {object} PetAddResponse "Successfully updated pet"
+// @Header 200 {string} ETag "New pet ETag"
+// @Failure 400 {object} PetAddResponseErr "Invalid patch, unknown category or invalid attributes"
+// @Failure 404 {object} PetAddResponseErr "Pet not found"
+// @Failure 409 {object} PetAddResponseErr "Microchip ID belongs to another pet"
+// @Failure 412 {object} PetAddResponseErr "Pet has been changed since ETag was issued"
+// @Failure 415 "Content-Type is not application/merge-patch+json"
+// @Failure 428 {object} PetAddResponseErr "If-Match is missing"
+// @Router /pet/{petId} [patch]
+func (p *Pet) PatchPet(w http.ResponseWriter, r *http.Request) {
+	petID, err := strconv.Atoi(chi.URLParam(r, "petId"))
+	if err != nil {
+		p.outputPetError(w, errors.PetIDErrorDuringConversion, "Patch Pet error")
+		return
+	}
+	var req service.PetPatchRequest
+	if err := p.Decode(r.Body, &req); err != nil {
+		p.ErrorBadRequest(w, err)
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(req.Attributes()); err != nil {
+		p.ErrorBadRequest(w, err)
+		return
+	}
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		p.outputPetError(w, errors.PetIfMatchRequired, "If-Match is required")
+		return
+	}
+	req.ID = petID
+	req.Version = version
+
+	out := p.service.PatchPet(r.Context(), req)
+	if out.ErrorCode != errors.NoError {
+		p.outputPetError(w, out.ErrorCode, "Patch Pet error")
+		return
+	}
+
+	w.Header().Set("ETag", petETag(out.Version))
+	p.OutputJSON(w, PetAddResponse{
+		Success: true,
+		Data: Data{
+			Message: "pet success updated",
+		},
+	})
+}
+
+// @Summary Delete Pet
+// @Security ApiKeyAuth
+// @Tags pet
+// @Description Soft-deletes a pet. The pet can be restored until the retention period expires.
+// @ID DeletePet
+// @Produce  json
+// @Param petId path string true "Pet ID to delete"
+// @Success 200 {object} PetAddResponse "Successfully deleted pet"
+// @Failure 404 {object} PetAddResponseErr "Pet not found"
+// @Router /pet/{petId} [delete]
+func (p *Pet) DeletePet(w http.ResponseWriter, r *http.Request) {
+	req := chi.URLParam(r, "petId")
+
+	out := p.service.DeletePet(r.Context(), req)
+	if out.ErrorCode != errors.NoError {
+		p.outputPetError(w, out.ErrorCode, "Delete pet error")
+		return
+	}
+
+	p.OutputJSON(w, PetAddResponse{
+		Success: true,
+		Data: Data{
+			Message: "pet deleted",
+		},
+	})
+}
+
+// @Summary Restore Pet
+// @Security ApiKeyAuth
+// @Tags pet
+// @Description Restores a soft-deleted pet.
+// @ID RestorePet
+// @Produce  json
+// @Param petId path string true "Pet ID to restore"
+// @Success 200 {object} PetAddResponse "Successfully restored pet"
+// @Failure 404 {object} PetAddResponseErr "No deleted pet found"
+// @Router /pet/{petId}/restore [post]
+func (p *Pet) RestorePet(w http.ResponseWriter, r *http.Request) {
+	req := chi.URLParam(r, "petId")
+
+	out := p.service.RestorePet(r.Context(), req)
+	if out.ErrorCode != errors.NoError {
+		p.outputPetError(w, out.ErrorCode, "Restore pet error")
+		return
+	}
+
+	p.OutputJSON(w, PetAddResponse{
+		Success: true,
+		Data: Data{
+			Message: "pet restored",
+		},
+	})
+}
+
+// @Summary Purge deleted Pets
+// @Security ApiKeyAuth
+// @Tags pet
+// @Description Permanently removes pets deleted longer than the retention period ago.
+// @ID PurgeDeletedPets
+// @Produce  json
+// @Success 200 {object} PurgeResponse "Number of purged pets"
+// @Router /pet/purge [post]
+func (p *Pet) PurgeDeletedPets(w http.ResponseWriter, r *http.Request) {
+	out := p.service.PurgeDeletedPets(r.Context())
+	if out.ErrorCode != errors.NoError {
+		p.outputPetError(w, out.ErrorCode, "Purge deleted pets error")
+		return
+	}
+
+	p.OutputJSON(w, PurgeResponse{
+		Success: true,
+		Purged:  out.Purged,
+	})
+}
+
+// @Summary Upload Pet image
+// @Security ApiKeyAuth
+// @Tags pet
+// @Description Uploads an image of a pet. Supported types: jpeg, png, gif, webp.
+// @ID UploadImage
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param petId path string true "Pet ID to upload the image for"
+// @Param file formData file true "Image file"
+// @Param additionalMetadata formData string false "Additional data to pass to server"
+// @Success 200 {object} UploadImageResponse "Successfully uploaded image"
+// @Failure 400 {object} PetAddResponseErr "Invalid request"
+// @Failure 404 {object} PetAddResponseErr "Pet not found"
+// @Failure 413 {object} PetAddResponseErr "Image is too large"
+// @Failure 415 {object} PetAddResponseErr "Unsupported image type"
+// @Router /pet/{petId}/uploadImage [post]
+func (p *Pet) UploadImage(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, p.maxImageSize+multipartOverhead)
+	err := r.ParseMultipartForm(multipartOverhead)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if stderrors.As(err, &maxBytesErr) {
+			p.outputPetError(w, errors.PetServiceImageTooLargeErr, "")
+			return
+		}
+		p.outputPetError(w, errors.UploadPetImageBadRequest, "invalid multipart form")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		p.outputPetError(w, errors.UploadPetImageBadRequest, "file is required")
+		return
+	}
+	defer file.Close()
+
+	out := p.service.UploadImage(r.Context(), service.UploadImageRequest{
+		PetID:              chi.URLParam(r, "petId"),
+		FileName:           header.Filename,
+		AdditionalMetadata: r.FormValue("additionalMetadata"),
+		Size:               header.Size,
+		File:               file,
+	})
+	if out.ErrorCode != errors.NoError {
+		p.outputPetError(w, out.ErrorCode, "Upload image error")
+		return
+	}
+
+	p.OutputJSON(w, UploadImageResponse{
+		Success: true,
+		Result:  out.Image,
+	})
+}
+
+// @Summary Download Pet image
+// @Security ApiKeyAuth
+// @Tags pet
+// @Description Returns an uploaded image of a pet.
+// @ID GetImage
+// @Produce  image/jpeg,image/png,image/gif,image/webp
+// @Param petId path string true "Pet ID"
+// @Param name path string true "Image file name"
+// @Success 200 {file} file "Image content"
+// @Failure 404 {object} PetAddResponseErr "Image not found"
+// @Router /pet/{petId}/images/{name} [get]
+func (p *Pet) GetImage(w http.ResponseWriter, r *http.Request) {
+	out := p.service.GetImage(r.Context(), chi.URLParam(r, "petId"), chi.URLParam(r, "name"))
+	if out.ErrorCode != errors.NoError {
+		p.outputPetError(w, out.ErrorCode, "Get image error")
+		return
+	}
+	defer out.File.Close()
+
+	w.Header().Set("Content-Type", out.Image.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(out.Image.Size, 10))
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	// Заголовки уже отправлены, поэтому ответить ошибкой нельзя: клиент получит обрезанный файл
+	if _, err := io.Copy(w, out.File); err != nil {
+		p.logger.Error("Error GetImage copy:", zap.Error(err))
+	}
+}
+
+// outputPetError - отвечает ошибкой с HTTP статусом, соответствующим коду ошибки сервиса
+func (p *Pet) outputPetError(w http.ResponseWriter, errorCode int, msg string) {
+	switch errorCode {
+	case errors.PetIDErrorDuringConversion, errors.UpdatePetFormErrorDuringConversion:
+		w.WriteHeader(http.StatusBadRequest)
+		msg = "invalid pet id"
+	case errors.PetServiceUpdatePetFormBadReuest:
+		w.WriteHeader(http.StatusBadRequest)
+		msg = "pet id or status is required"
+	case errors.PetServiceErrPetNotFound:
+		w.WriteHeader(http.StatusNotFound)
+		msg = "no pet found with the provided id"
+	case errors.UploadPetImageBadRequest:
+		w.WriteHeader(http.StatusBadRequest)
+	case errors.PetServiceImageTooLargeErr:
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		msg = "image is too large"
+	case errors.PetServiceImageTypeErr:
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		msg = "unsupported image type"
+	case errors.PetServiceImageNotFoundErr:
+		w.WriteHeader(http.StatusNotFound)
+		msg = "no image found for the pet"
+	case errors.FindPetbyStatusBadRequest:
+		w.WriteHeader(http.StatusBadRequest)
+		msg = "invalid status, cursor, limit, sort or order"
+	case errors.SearchPetsBadRequest:
+		w.WriteHeader(http.StatusBadRequest)
+		msg = "invalid search filters or page parameters"
+	case errors.FindPetbyTagsBadRequest:
+		w.WriteHeader(http.StatusBadRequest)
+		msg = "tags are required, match must be any or all"
+	case errors.PetServiceCategoryNotFoundErr:
+		w.WriteHeader(http.StatusBadRequest)
+		msg = "no category found with the provided name"
+	case errors.PetAttributesBadRequest:
+		w.WriteHeader(http.StatusBadRequest)
+		msg = "birthdate must be a past date in YYYY-MM-DD format"
+	case errors.PetServiceMicrochipExistsErr:
+		w.WriteHeader(http.StatusConflict)
+		msg = "pet with this microchip id already exists"
+	case errors.PetServiceVersionMismatchErr:
+		w.WriteHeader(http.StatusPreconditionFailed)
+		msg = "pet has been changed, fetch it again to get the current ETag"
+	case errors.PetIfMatchRequired:
+		w.WriteHeader(http.StatusPreconditionRequired)
+	case errors.PetPatchBadRequest:
+		w.WriteHeader(http.StatusBadRequest)
+		msg = "status must be a known pet status, category must have a name, neither can be null"
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	p.OutputJSON(w, PetAddResponseErr{
+		Success:   false,
+		ErrorCode: errorCode,
+		Data: Data{
+			Message: msg,
+		},
+	})
+}
+
+// petETag - значение заголовка ETag для версии питомца
+func petETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersion - ожидаемая версия питомца из заголовка If-Match, ok = false, если заголовка нет.
+// "*" подходит к любой версии и даёт 0. Значение, которое не может совпасть с ETag питомца
+// (слабый тег, список тегов, мусор), даёт -1, и изменение отклоняется как устаревшее
+func ifMatchVersion(r *http.Request) (int, bool) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return 0, false
+	}
+	if value == "*" {
+		return 0, true
+	}
+
+	unquoted, err := strconv.Unquote(value)
+	if err != nil || !strings.HasPrefix(value, `"`) {
+		return -1, true
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version <= 0 {
+		return -1, true
+	}
+
+	return version, true
+}
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"pet-store/internal/infrastructure/db/types"
 	"pet-store/internal/infrastructure/errors"
 	"pet-store/internal/infrastructure/responder"
 	"pet-store/internal/models"
@@ -30,21 +32,47 @@ func TestUpdatePetForm(t *testing.T) {
 		petId     string
 		namePet   string
 		statusPet string
+		ifMatch   string
+		version   int
 		respError string
 		mockError int
+		code      int
 	}{
 		{
 			nameTest:  "Success",
 			petId:     "12",
 			namePet:   "Jora",
 			statusPet: "available",
+			ifMatch:   `"3"`,
+			version:   3,
+			code:      http.StatusOK,
 		},
 		{
 			nameTest:  "Error in Mck",
 			petId:     "10",
 			namePet:   "Egorka",
 			statusPet: "available",
+			ifMatch:   "*",
 			mockError: 2022,
+			code:      http.StatusInternalServerError,
+		},
+		{
+			nameTest:  "Stale version",
+			petId:     "10",
+			namePet:   "Egorka",
+			statusPet: "available",
+			ifMatch:   `"2"`,
+			version:   2,
+			mockError: errors.PetServiceVersionMismatchErr,
+			code:      http.StatusPreconditionFailed,
+		},
+		{
+			nameTest:  "Without If-Match",
+			petId:     "10",
+			namePet:   "Egorka",
+			statusPet: "available",
+			respError: "If-Match is required",
+			code:      http.StatusPreconditionRequired,
 		},
 	}
 
@@ -61,21 +89,30 @@ func TestUpdatePetForm(t *testing.T) {
 			}
 
 			if tc.respError == "" && tc.mockError == 0 {
-				serviceUpdateSaveFormMock.On("UpdatePetForm", mock.Anything, tc.namePet, tc.statusPet, tc.petId).
-					Return(service.RequestOut{
+				serviceUpdateSaveFormMock.On("UpdatePetForm", mock.Anything, tc.namePet, tc.statusPet, tc.petId, tc.version).
+					Return(service.RequestOutWithVersion{
+						Version:   tc.version + 1,
 						Status:    true,
 						ErrorCode: errors.NoError,
 					}).
 					Once()
 			}
 			if tc.mockError == 2022 {
-				serviceUpdateSaveFormMock.On("UpdatePetForm", mock.Anything, tc.namePet, tc.statusPet, tc.petId).
-					Return(service.RequestOut{
+				serviceUpdateSaveFormMock.On("UpdatePetForm", mock.Anything, tc.namePet, tc.statusPet, tc.petId, tc.version).
+					Return(service.RequestOutWithVersion{
 						Status:    false,
 						ErrorCode: errors.UpdatePetFormError,
 					}).
 					Once()
 			}
+			if tc.mockError == errors.PetServiceVersionMismatchErr {
+				serviceUpdateSaveFormMock.On("UpdatePetForm", mock.Anything, tc.namePet, tc.statusPet, tc.petId, tc.version).
+					Return(service.RequestOutWithVersion{
+						Status:    false,
+						ErrorCode: errors.PetServiceVersionMismatchErr,
+					}).
+					Once()
+			}
 
 			decoder := godecoder.NewDecoder(jsoniter.Config{
 				EscapeHTML:             true,
@@ -111,6 +148,9 @@ func TestUpdatePetForm(t *testing.T) {
 
 			// Устанавливаем заголовок Content-Type
 			httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tc.ifMatch != "" {
+				httpReq.Header.Set("If-Match", tc.ifMatch)
+			}
 
 			// Запускаем HTTP-сервер
 			rr := httptest.NewRecorder()
@@ -125,14 +165,10 @@ func TestUpdatePetForm(t *testing.T) {
 			serviceUpdateSaveFormMock.AssertExpectations(t)
 
 			// Проверка ответа
-			if tc.mockError != 0 {
-				// Если произошла ошибка, проверяем ответ с ошибкой
-				if tc.mockError == 2022 {
-					assert.Equal(t, http.StatusInternalServerError, rr.Code)
-				}
-			} else {
-				// Если успешный ответ
-				assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tc.code, rr.Code)
+			if tc.code == http.StatusOK {
+				// Новая версия питомца возвращается в ETag
+				assert.Equal(t, fmt.Sprintf(`"%d"`, tc.version+1), rr.Header().Get("ETag"))
 			}
 		})
 	}
@@ -309,6 +345,19 @@ func TestAddPet(t *testing.T) {
 			},
 			mockError: errors.AddPetErr,
 		},
+		{
+			nameTest: "Invalid attributes",
+			req: service.PetAddRequest{
+				Category: service.Category{Name: "Dog"},
+				Name:     "Rex",
+				Status:   "available",
+				PetAttributes: service.PetAttributes{
+					Sex:         "unknown",
+					MicrochipID: "123",
+				},
+			},
+			mockError: errors.PetAttributesBadRequest,
+		},
 	}
 
 	for _, tc := range cases {
@@ -359,19 +408,8 @@ func TestAddPet(t *testing.T) {
 				Decoder:   decoder,
 			}
 
-			petRequest := service.PetAddRequest{
-				Category:  service.Category{Name: "Dog"},
-				Name:      "Rex",
-				PhotoUrls: []string{"http://example.com/photo1.jpg", "http://example.com/photo2.jpg"},
-				Tags: []service.Tag{
-					{Name: "Friendly"},
-					{Name: "Loyal"},
-				},
-				Status: "available",
-			}
-
 			// Сериализуем в JSON
-			jsonData, err := json.Marshal(petRequest)
+			jsonData, err := json.Marshal(tc.req)
 			if err != nil {
 				t.Fatalf("Error marshalling to JSON: %s", err)
 			}
@@ -400,6 +438,10 @@ func TestAddPet(t *testing.T) {
 				if tc.mockError == errors.AddPetErr {
 					assert.Equal(t, http.StatusInternalServerError, rr.Code)
 				}
+				// Некорректные сведения отклоняются валидацией до вызова сервиса
+				if tc.mockError == errors.PetAttributesBadRequest {
+					assert.Equal(t, http.StatusBadRequest, rr.Code)
+				}
 			} else {
 				// Если успешный ответ
 				assert.Equal(t, http.StatusOK, rr.Code)
@@ -466,7 +508,7 @@ func TestFindPetbyStatus(t *testing.T) {
 			}
 
 			if tc.mockError == 0 {
-				serviceMock.On("FindPetbyStatus", mock.Anything, tc.statusPet).
+				serviceMock.On("FindPetbyStatus", mock.Anything, tc.statusPet, service.PageRequest{}).
 					Return(service.RequestOutWithPets{
 						Status:    true,
 						ErrorCode: errors.NoError,
@@ -503,7 +545,7 @@ func TestFindPetbyStatus(t *testing.T) {
 			}
 			if tc.mockError != 0 {
 				if tc.mockError == errors.PetServiceFindPetbyStatus {
-					serviceMock.On("FindPetbyStatus", mock.Anything, tc.statusPet).
+					serviceMock.On("FindPetbyStatus", mock.Anything, tc.statusPet, service.PageRequest{}).
 						Return(service.RequestOutWithPets{
 							Status:    false,
 							ErrorCode: errors.PetServiceFindPetbyStatus,
@@ -570,6 +612,7 @@ func TestUpdatePet(t *testing.T) {
 	cases := []struct {
 		nameTest     string
 		updatePetReq service.PetUpdateRequest
+		ifMatch      string
 		mockError    int
 	}{
 		{
@@ -582,8 +625,10 @@ func TestUpdatePet(t *testing.T) {
 					{Name: "Friendly"},
 					{Name: "Loyal"},
 				},
-				Status: "available",
+				Status:  "available",
+				Version: 3,
 			},
+			ifMatch: `"3"`,
 		},
 		{
 			nameTest: "Error",
@@ -597,8 +642,13 @@ func TestUpdatePet(t *testing.T) {
 				},
 				Status: "available",
 			},
+			ifMatch:   "*",
 			mockError: errors.PetServiceUpdateErr,
 		},
+		{
+			nameTest:  "Without If-Match",
+			mockError: errors.PetIfMatchRequired,
+		},
 	}
 
 	for _, tc := range cases {
@@ -614,7 +664,8 @@ func TestUpdatePet(t *testing.T) {
 
 			if tc.mockError == 0 {
 				serviceMock.On("UpdatePet", mock.Anything, tc.updatePetReq).
-					Return(service.RequestOut{
+					Return(service.RequestOutWithVersion{
+						Version:   4,
 						Status:    true,
 						ErrorCode: errors.NoError,
 					}).
@@ -623,7 +674,7 @@ func TestUpdatePet(t *testing.T) {
 			if tc.mockError != 0 {
 				if tc.mockError == errors.PetServiceUpdateErr {
 					serviceMock.On("UpdatePet", mock.Anything, tc.updatePetReq).
-						Return(service.RequestOut{
+						Return(service.RequestOutWithVersion{
 							Status:    false,
 							ErrorCode: errors.PetServiceUpdateErr,
 						}).
@@ -671,6 +722,9 @@ func TestUpdatePet(t *testing.T) {
 				t.Fatal(err)
 			}
 			httpReq.Header.Set("Content-Type", "application/json")
+			if tc.ifMatch != "" {
+				httpReq.Header.Set("If-Match", tc.ifMatch)
+			}
 
 			// Запускаем HTTP-сервер
 			rr := httptest.NewRecorder()
@@ -690,10 +744,467 @@ func TestUpdatePet(t *testing.T) {
 				if tc.mockError == errors.PetServiceUpdateErr {
 					assert.Equal(t, http.StatusInternalServerError, rr.Code)
 				}
+				// Без If-Match сервис не вызывается
+				if tc.mockError == errors.PetIfMatchRequired {
+					assert.Equal(t, http.StatusPreconditionRequired, rr.Code)
+				}
 			} else {
 				// Если успешный ответ
 				assert.Equal(t, http.StatusOK, rr.Code)
+				assert.Equal(t, `"4"`, rr.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestPatchPet(t *testing.T) {
+	cleared := service.PetPatchRequest{
+		ID:      12,
+		Version: 3,
+		Name:    types.PatchString{NullString: types.NewNullString("Rex"), Set: true},
+		Color:   types.PatchString{Set: true},
+		Weight:  types.PatchFloat64{Set: true},
+		Tags:    types.Patch[[]service.Tag]{Set: true, Null: true},
+	}
+
+	cases := []struct {
+		nameTest   string
+		petId      string
+		body       string
+		ifMatch    string
+		patch      *service.PetPatchRequest
+		errorCode  int
+		statusCode int
+	}{
+		{
+			nameTest:   "Success",
+			petId:      "12",
+			body:       `{"name": "Rex", "color": null, "weight": null, "tags": null}`,
+			ifMatch:    `"3"`,
+			patch:      &cleared,
+			statusCode: http.StatusOK,
+		},
+		{
+			nameTest:   "Error Bad ID",
+			petId:      "abc",
+			body:       `{"name": "Rex"}`,
+			ifMatch:    `"3"`,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			nameTest:   "Invalid attributes",
+			petId:      "12",
+			body:       `{"sex": "unknown"}`,
+			ifMatch:    `"3"`,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			nameTest:   "Unknown field",
+			petId:      "12",
+			body:       `{"owner": "Bob"}`,
+			ifMatch:    `"3"`,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			nameTest:   "Without If-Match",
+			petId:      "12",
+			body:       `{"name": "Rex"}`,
+			statusCode: http.StatusPreconditionRequired,
+		},
+		{
+			nameTest:   "Null status",
+			petId:      "12",
+			body:       `{"status": null}`,
+			ifMatch:    "*",
+			patch:      &service.PetPatchRequest{ID: 12, Status: types.PatchString{Set: true}},
+			errorCode:  errors.PetPatchBadRequest,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			nameTest:   "Stale version",
+			petId:      "12",
+			body:       `{"name": "Rex", "color": null, "weight": null, "tags": null}`,
+			ifMatch:    `"3"`,
+			patch:      &cleared,
+			errorCode:  errors.PetServiceVersionMismatchErr,
+			statusCode: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.nameTest, func(t *testing.T) {
+			t.Parallel()
+
+			serviceMock := mocks.NewPeter(t)
+			if tc.patch != nil {
+				serviceMock.On("PatchPet", mock.Anything, *tc.patch).
+					Return(service.RequestOutWithVersion{
+						Version:   4,
+						Status:    tc.errorCode == errors.NoError,
+						ErrorCode: tc.errorCode,
+					}).
+					Once()
+			}
+
+			decoder := godecoder.NewDecoder(jsoniter.Config{
+				EscapeHTML:             true,
+				SortMapKeys:            true,
+				ValidateJsonRawMessage: true,
+				DisallowUnknownFields:  true,
+			})
+			logger, err := zap.NewProduction()
+			if err != nil {
+				t.Fatal(err)
+			}
+			responseManager := responder.NewResponder(decoder, logger)
+
+			petHandler := &Pet{
+				service:   serviceMock,
+				Responder: responseManager,
+				Decoder:   decoder,
+			}
+
+			httpReq, err := http.NewRequest(http.MethodPatch, "/pet/"+tc.petId, strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			httpReq.Header.Set("Content-Type", "application/merge-patch+json")
+			if tc.ifMatch != "" {
+				httpReq.Header.Set("If-Match", tc.ifMatch)
+			}
+
+			rr := httptest.NewRecorder()
+
+			router := chi.NewRouter()
+			router.Patch("/pet/{petId}", petHandler.PatchPet)
+
+			router.ServeHTTP(rr, httpReq)
+
+			serviceMock.AssertExpectations(t)
+			assert.Equal(t, tc.statusCode, rr.Code)
+			if tc.statusCode == http.StatusOK {
+				assert.Equal(t, `"4"`, rr.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestDeletePet(t *testing.T) {
+	cases := []struct {
+		nameTest   string
+		petId      string
+		errorCode  int
+		statusCode int
+	}{
+		{
+			nameTest:   "Success",
+			petId:      "12",
+			errorCode:  errors.NoError,
+			statusCode: http.StatusOK,
+		},
+		{
+			nameTest:   "Error Bad ID",
+			petId:      "abc",
+			errorCode:  errors.PetIDErrorDuringConversion,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			nameTest:   "Error Not Found",
+			petId:      "12",
+			errorCode:  errors.PetServiceErrPetNotFound,
+			statusCode: http.StatusNotFound,
+		},
+		{
+			nameTest:   "Error on server",
+			petId:      "12",
+			errorCode:  errors.PetServiceDeletePetErr,
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.nameTest, func(t *testing.T) {
+			t.Parallel()
+
+			serviceMock := mocks.NewPeter(t)
+			serviceMock.On("DeletePet", mock.Anything, tc.petId).
+				Return(service.RequestOut{
+					Status:    tc.errorCode == errors.NoError,
+					ErrorCode: tc.errorCode,
+				}).
+				Once()
+
+			decoder := godecoder.NewDecoder(jsoniter.Config{
+				EscapeHTML:             true,
+				SortMapKeys:            true,
+				ValidateJsonRawMessage: true,
+				DisallowUnknownFields:  true,
+			})
+			logger, err := zap.NewProduction()
+			if err != nil {
+				t.Fatal(err)
+			}
+			responseManager := responder.NewResponder(decoder, logger)
+
+			petHandler := &Pet{
+				service:   serviceMock,
+				Responder: responseManager,
+				Decoder:   decoder,
+			}
+
+			httpReq, err := http.NewRequest(http.MethodDelete, "/pet/"+tc.petId, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+
+			router := chi.NewRouter()
+			router.Delete("/pet/{petId}", petHandler.DeletePet)
+
+			router.ServeHTTP(rr, httpReq)
+
+			serviceMock.AssertExpectations(t)
+			assert.Equal(t, tc.statusCode, rr.Code)
+		})
+	}
+}
+
+func TestUploadImage(t *testing.T) {
+	cases := []struct {
+		nameTest   string
+		petId      string
+		withFile   bool
+		errorCode  int
+		statusCode int
+	}{
+		{
+			nameTest:   "Success",
+			petId:      "12",
+			withFile:   true,
+			errorCode:  errors.NoError,
+			statusCode: http.StatusOK,
+		},
+		{
+			nameTest:   "Error No File",
+			petId:      "12",
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			nameTest:   "Error Unsupported Type",
+			petId:      "12",
+			withFile:   true,
+			errorCode:  errors.PetServiceImageTypeErr,
+			statusCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			nameTest:   "Error Not Found",
+			petId:      "12",
+			withFile:   true,
+			errorCode:  errors.PetServiceErrPetNotFound,
+			statusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.nameTest, func(t *testing.T) {
+			t.Parallel()
+
+			serviceMock := mocks.NewPeter(t)
+			if tc.withFile {
+				serviceMock.On("UploadImage", mock.Anything, mock.MatchedBy(func(req service.UploadImageRequest) bool {
+					return req.PetID == tc.petId && req.FileName == "cat.png" && req.AdditionalMetadata == "front"
+				})).
+					Return(service.RequestOutWithImage{
+						Status:    tc.errorCode == errors.NoError,
+						ErrorCode: tc.errorCode,
+					}).
+					Once()
+			}
+
+			decoder := godecoder.NewDecoder(jsoniter.Config{
+				EscapeHTML:             true,
+				SortMapKeys:            true,
+				ValidateJsonRawMessage: true,
+				DisallowUnknownFields:  true,
+			})
+			logger, err := zap.NewProduction()
+			if err != nil {
+				t.Fatal(err)
+			}
+			responseManager := responder.NewResponder(decoder, logger)
+
+			petHandler := &Pet{
+				service:      serviceMock,
+				maxImageSize: 1 << 20,
+				Responder:    responseManager,
+				Decoder:      decoder,
+			}
+
+			// Формируем multipart-запрос с файлом и метаданными
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			if tc.withFile {
+				part, err := writer.CreateFormFile("file", "cat.png")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := part.Write([]byte("\x89PNG\r\n\x1a\n")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := writer.WriteField("additionalMetadata", "front"); err != nil {
+				t.Fatal(err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			httpReq, err := http.NewRequest(http.MethodPost, "/pet/"+tc.petId+"/uploadImage", body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			httpReq.Header.Set("Content-Type", writer.FormDataContentType())
+
+			rr := httptest.NewRecorder()
+
+			router := chi.NewRouter()
+			router.Post("/pet/{petId}/uploadImage", petHandler.UploadImage)
+
+			router.ServeHTTP(rr, httpReq)
+
+			serviceMock.AssertExpectations(t)
+			assert.Equal(t, tc.statusCode, rr.Code)
+		})
+	}
+}
+
+func TestFindPetbyTags(t *testing.T) {
+	cases := []struct {
+		nameTest   string
+		query      string
+		tags       []string
+		match      string
+		errorCode  int
+		statusCode int
+	}{
+		{
+			nameTest:   "Success any",
+			query:      "tags=frendly&tags=small",
+			tags:       []string{"frendly", "small"},
+			errorCode:  errors.NoError,
+			statusCode: http.StatusOK,
+		},
+		{
+			nameTest:   "Success all",
+			query:      "tags=frendly,small&match=all",
+			tags:       []string{"frendly,small"},
+			match:      "all",
+			errorCode:  errors.NoError,
+			statusCode: http.StatusOK,
+		},
+		{
+			nameTest:   "Error Bad Match",
+			query:      "tags=frendly&match=some",
+			tags:       []string{"frendly"},
+			match:      "some",
+			errorCode:  errors.FindPetbyTagsBadRequest,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			nameTest:   "Error on server",
+			query:      "tags=frendly",
+			tags:       []string{"frendly"},
+			errorCode:  errors.PetServiceFindPetbyTags,
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.nameTest, func(t *testing.T) {
+			t.Parallel()
+
+			serviceMock := mocks.NewPeter(t)
+			serviceMock.On("FindPetbyTags", mock.Anything, tc.tags, tc.match).
+				Return(service.RequestOutWithPets{
+					Pets:      []models.Pet{},
+					Status:    tc.errorCode == errors.NoError,
+					ErrorCode: tc.errorCode,
+				}).
+				Once()
+
+			decoder := godecoder.NewDecoder(jsoniter.Config{
+				EscapeHTML:             true,
+				SortMapKeys:            true,
+				ValidateJsonRawMessage: true,
+				DisallowUnknownFields:  true,
+			})
+			logger, err := zap.NewProduction()
+			if err != nil {
+				t.Fatal(err)
+			}
+			responseManager := responder.NewResponder(decoder, logger)
+
+			petHandler := &Pet{
+				service:   serviceMock,
+				Responder: responseManager,
+				Decoder:   decoder,
+			}
+
+			httpReq, err := http.NewRequest(http.MethodGet, "/pet/findByTags?"+tc.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+
+			router := chi.NewRouter()
+			router.Get("/pet/findByTags", petHandler.FindPetbyTags)
+
+			router.ServeHTTP(rr, httpReq)
+
+			serviceMock.AssertExpectations(t)
+			assert.Equal(t, tc.statusCode, rr.Code)
+		})
+	}
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	cases := []struct {
+		nameTest string
+		header   string
+		version  int
+		ok       bool
+	}{
+		{nameTest: "Missing"},
+		{nameTest: "Any", header: "*", ok: true},
+		{nameTest: "Strong", header: `"7"`, version: 7, ok: true},
+		{nameTest: "Weak", header: `W/"7"`, version: -1, ok: true},
+		{nameTest: "Unquoted", header: "7", version: -1, ok: true},
+		{nameTest: "List", header: `"6", "7"`, version: -1, ok: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.nameTest, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodPut, "/pet", nil)
+			if tc.header != "" {
+				r.Header.Set("If-Match", tc.header)
 			}
+			version, ok := ifMatchVersion(r)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.version, version)
 		})
 	}
 }
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"pet-store/internal/infrastructure/blobstore"
+	"pet-store/internal/infrastructure/errors"
+	"pet-store/internal/models"
+	"strconv"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// sniffLen - количество байт, по которым определяется тип содержимого
+const sniffLen = 512
+
+// imageTypes - допустимые типы изображений и расширения файлов для них
+var imageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// UploadImage - проверяет и сохраняет изображение питомца в хранилище объектов
+func (p *PetService) UploadImage(ctx context.Context, req UploadImageRequest) RequestOutWithImage {
+	petID, err := strconv.Atoi(req.PetID)
+	if err != nil {
+		p.logger.Error("Error during conversion:", zap.Error(err))
+		return RequestOutWithImage{
+			Status:    false,
+			ErrorCode: errors.PetIDErrorDuringConversion,
+		}
+	}
+	if req.Size > p.conf.ImageMaxSize {
+		return RequestOutWithImage{
+			Status:    false,
+			ErrorCode: errors.PetServiceImageTooLargeErr,
+		}
+	}
+
+	// Тип определяем по содержимому, а не по заголовку, присланному клиентом
+	file := bufio.NewReaderSize(req.File, sniffLen)
+	head, err := file.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		p.logger.Error("Error UploadImage read:", zap.Error(err))
+		return RequestOutWithImage{
+			Status:    false,
+			ErrorCode: errors.PetServiceUploadImageErr,
+		}
+	}
+	contentType := http.DetectContentType(head)
+	ext, ok := imageTypes[contentType]
+	if !ok {
+		return RequestOutWithImage{
+			Status:    false,
+			ErrorCode: errors.PetServiceImageTypeErr,
+		}
+	}
+
+	name := uuid.NewString() + ext
+	image := models.PetImage{
+		PetID:              petID,
+		Name:               name,
+		StorageKey:         fmt.Sprintf("pets/%d/%s", petID, name),
+		OriginalName:       req.FileName,
+		ContentType:        contentType,
+		Size:               req.Size,
+		AdditionalMetadata: req.AdditionalMetadata,
+		URL:                fmt.Sprintf("/pet/%d/images/%s", petID, name),
+	}
+
+	// Ограничиваем чтение, чтобы фактический размер не превысил заявленный лимит
+	err = p.blobs.Put(ctx, image.StorageKey, io.LimitReader(file, p.conf.ImageMaxSize))
+	if err != nil {
+		p.logger.Error("Error UploadImage put:", zap.Error(err))
+		return RequestOutWithImage{
+			Status:    false,
+			ErrorCode: errors.PetServiceUploadImageErr,
+		}
+	}
+
+	err = p.storage.AddPetImage(ctx, &image)
+	if err != nil {
+		p.logger.Error("Error UploadImage:", zap.Error(err))
+		// Метаданные не сохранились, файл больше никому не нужен
+		if err := p.blobs.Delete(ctx, image.StorageKey); err != nil {
+			p.logger.Error("Error UploadImage delete:", zap.Error(err))
+		}
+		errorcode := errors.PetServiceUploadImageErr
+		if err == errors.ErrPetNotFound {
+			errorcode = errors.PetServiceErrPetNotFound
+		}
+		return RequestOutWithImage{
+			Status:    false,
+			ErrorCode: errorcode,
+		}
+	}
+
+	return RequestOutWithImage{
+		Image:  image,
+		Status: true,
+	}
+}
+
+// GetImage - возвращает метаданные и содержимое изображения питомца.
+// Закрыть File должен вызывающий.
+func (p *PetService) GetImage(ctx context.Context, reqID, name string) RequestOutImageFile {
+	petID, err := strconv.Atoi(reqID)
+	if err != nil {
+		p.logger.Error("Error during conversion:", zap.Error(err))
+		return RequestOutImageFile{
+			Status:    false,
+			ErrorCode: errors.PetIDErrorDuringConversion,
+		}
+	}
+
+	image, err := p.storage.FindPetImage(ctx, petID, name)
+	if err != nil {
+		if err == errors.ErrPetImageNotFound {
+			return RequestOutImageFile{
+				Status:    false,
+				ErrorCode: errors.PetServiceImageNotFoundErr,
+			}
+		}
+		p.logger.Error("Error GetImage:", zap.Error(err))
+		return RequestOutImageFile{
+			Status:    false,
+			ErrorCode: errors.PetServiceGetImageErr,
+		}
+	}
+
+	file, err := p.blobs.Get(ctx, image.StorageKey)
+	if err != nil {
+		p.logger.Error("Error GetImage get:", zap.Error(err))
+		errorcode := errors.PetServiceGetImageErr
+		if err == blobstore.ErrNotFound {
+			errorcode = errors.PetServiceImageNotFoundErr
+		}
+		return RequestOutImageFile{
+			Status:    false,
+			ErrorCode: errorcode,
+		}
+	}
+
+	return RequestOutImageFile{
+		Image:  image,
+		File:   file,
+		Status: true,
+	}
+}
@@ -31,6 +31,24 @@ func (_m *Peter) AddPet(ctx context.Context, pet service.PetAddRequest) service.
 	return r0
 }
 
+// DeletePet provides a mock function with given fields: ctx, reqID
+func (_m *Peter) DeletePet(ctx context.Context, reqID string) service.RequestOut {
+	ret := _m.Called(ctx, reqID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeletePet")
+	}
+
+	var r0 service.RequestOut
+	if rf, ok := ret.Get(0).(func(context.Context, string) service.RequestOut); ok {
+		r0 = rf(ctx, reqID)
+	} else {
+		r0 = ret.Get(0).(service.RequestOut)
+	}
+
+	return r0
+}
+
 // FindPetbyID provides a mock function with given fields: ctx, strID
 func (_m *Peter) FindPetbyID(ctx context.Context, strID string) service.RequestOutWithPet {
 	ret := _m.Called(ctx, strID)
@@ -49,17 +67,125 @@ func (_m *Peter) FindPetbyID(ctx context.Context, strID string) service.RequestO
 	return r0
 }
 
-// FindPetbyStatus provides a mock function with given fields: ctx, statuses
-func (_m *Peter) FindPetbyStatus(ctx context.Context, statuses []string) service.RequestOutWithPets {
-	ret := _m.Called(ctx, statuses)
+// FindPetbyStatus provides a mock function with given fields: ctx, statuses, req
+func (_m *Peter) FindPetbyStatus(ctx context.Context, statuses []string, req service.PageRequest) service.RequestOutWithPets {
+	ret := _m.Called(ctx, statuses, req)
 
 	if len(ret) == 0 {
 		panic("no return value specified for FindPetbyStatus")
 	}
 
 	var r0 service.RequestOutWithPets
-	if rf, ok := ret.Get(0).(func(context.Context, []string) service.RequestOutWithPets); ok {
-		r0 = rf(ctx, statuses)
+	if rf, ok := ret.Get(0).(func(context.Context, []string, service.PageRequest) service.RequestOutWithPets); ok {
+		r0 = rf(ctx, statuses, req)
+	} else {
+		r0 = ret.Get(0).(service.RequestOutWithPets)
+	}
+
+	return r0
+}
+
+// FindPetbyTags provides a mock function with given fields: ctx, tags, match
+func (_m *Peter) FindPetbyTags(ctx context.Context, tags []string, match string) service.RequestOutWithPets {
+	ret := _m.Called(ctx, tags, match)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindPetbyTags")
+	}
+
+	var r0 service.RequestOutWithPets
+	if rf, ok := ret.Get(0).(func(context.Context, []string, string) service.RequestOutWithPets); ok {
+		r0 = rf(ctx, tags, match)
+	} else {
+		r0 = ret.Get(0).(service.RequestOutWithPets)
+	}
+
+	return r0
+}
+
+// GetImage provides a mock function with given fields: ctx, reqID, name
+func (_m *Peter) GetImage(ctx context.Context, reqID string, name string) service.RequestOutImageFile {
+	ret := _m.Called(ctx, reqID, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetImage")
+	}
+
+	var r0 service.RequestOutImageFile
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) service.RequestOutImageFile); ok {
+		r0 = rf(ctx, reqID, name)
+	} else {
+		r0 = ret.Get(0).(service.RequestOutImageFile)
+	}
+
+	return r0
+}
+
+// PatchPet provides a mock function with given fields: ctx, patch
+func (_m *Peter) PatchPet(ctx context.Context, patch service.PetPatchRequest) service.RequestOutWithVersion {
+	ret := _m.Called(ctx, patch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PatchPet")
+	}
+
+	var r0 service.RequestOutWithVersion
+	if rf, ok := ret.Get(0).(func(context.Context, service.PetPatchRequest) service.RequestOutWithVersion); ok {
+		r0 = rf(ctx, patch)
+	} else {
+		r0 = ret.Get(0).(service.RequestOutWithVersion)
+	}
+
+	return r0
+}
+
+// PurgeDeletedPets provides a mock function with given fields: ctx
+func (_m *Peter) PurgeDeletedPets(ctx context.Context) service.RequestOutPurge {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeDeletedPets")
+	}
+
+	var r0 service.RequestOutPurge
+	if rf, ok := ret.Get(0).(func(context.Context) service.RequestOutPurge); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(service.RequestOutPurge)
+	}
+
+	return r0
+}
+
+// RestorePet provides a mock function with given fields: ctx, reqID
+func (_m *Peter) RestorePet(ctx context.Context, reqID string) service.RequestOut {
+	ret := _m.Called(ctx, reqID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestorePet")
+	}
+
+	var r0 service.RequestOut
+	if rf, ok := ret.Get(0).(func(context.Context, string) service.RequestOut); ok {
+		r0 = rf(ctx, reqID)
+	} else {
+		r0 = ret.Get(0).(service.RequestOut)
+	}
+
+	return r0
+}
+
+// SearchPets provides a mock function with given fields: ctx, req
+func (_m *Peter) SearchPets(ctx context.Context, req service.RequestSearchPets) service.RequestOutWithPets {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchPets")
+	}
+
+	var r0 service.RequestOutWithPets
+	if rf, ok := ret.Get(0).(func(context.Context, service.RequestSearchPets) service.RequestOutWithPets); ok {
+		r0 = rf(ctx, req)
 	} else {
 		r0 = ret.Get(0).(service.RequestOutWithPets)
 	}
@@ -68,36 +194,54 @@ func (_m *Peter) FindPetbyStatus(ctx context.Context, statuses []string) service
 }
 
 // UpdatePet provides a mock function with given fields: ctx, pet
-func (_m *Peter) UpdatePet(ctx context.Context, pet service.PetUpdateRequest) service.RequestOut {
+func (_m *Peter) UpdatePet(ctx context.Context, pet service.PetUpdateRequest) service.RequestOutWithVersion {
 	ret := _m.Called(ctx, pet)
 
 	if len(ret) == 0 {
 		panic("no return value specified for UpdatePet")
 	}
 
-	var r0 service.RequestOut
-	if rf, ok := ret.Get(0).(func(context.Context, service.PetUpdateRequest) service.RequestOut); ok {
+	var r0 service.RequestOutWithVersion
+	if rf, ok := ret.Get(0).(func(context.Context, service.PetUpdateRequest) service.RequestOutWithVersion); ok {
 		r0 = rf(ctx, pet)
 	} else {
-		r0 = ret.Get(0).(service.RequestOut)
+		r0 = ret.Get(0).(service.RequestOutWithVersion)
 	}
 
 	return r0
 }
 
-// UpdatePetForm provides a mock function with given fields: ctx, name, status, reqID
-func (_m *Peter) UpdatePetForm(ctx context.Context, name string, status string, reqID string) service.RequestOut {
-	ret := _m.Called(ctx, name, status, reqID)
+// UpdatePetForm provides a mock function with given fields: ctx, name, status, reqID, version
+func (_m *Peter) UpdatePetForm(ctx context.Context, name string, status string, reqID string, version int) service.RequestOutWithVersion {
+	ret := _m.Called(ctx, name, status, reqID, version)
 
 	if len(ret) == 0 {
 		panic("no return value specified for UpdatePetForm")
 	}
 
-	var r0 service.RequestOut
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) service.RequestOut); ok {
-		r0 = rf(ctx, name, status, reqID)
+	var r0 service.RequestOutWithVersion
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, int) service.RequestOutWithVersion); ok {
+		r0 = rf(ctx, name, status, reqID, version)
 	} else {
-		r0 = ret.Get(0).(service.RequestOut)
+		r0 = ret.Get(0).(service.RequestOutWithVersion)
+	}
+
+	return r0
+}
+
+// UploadImage provides a mock function with given fields: ctx, req
+func (_m *Peter) UploadImage(ctx context.Context, req service.UploadImageRequest) service.RequestOutWithImage {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UploadImage")
+	}
+
+	var r0 service.RequestOutWithImage
+	if rf, ok := ret.Get(0).(func(context.Context, service.UploadImageRequest) service.RequestOutWithImage); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Get(0).(service.RequestOutWithImage)
 	}
 
 	return r0
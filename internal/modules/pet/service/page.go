@@ -0,0 +1,113 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"pet-store/internal/models"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPetsLimit = 20
+	maxPetsLimit     = 100
+)
+
+// Направления сортировки
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// pageCursor - содержимое курсора. Клиенту курсор отдаётся непрозрачной строкой
+type pageCursor struct {
+	Sort      string    `json:"s"`
+	Desc      bool      `json:"d,omitempty"`
+	ID        int       `json:"i"`
+	Name      string    `json:"n,omitempty"`
+	CreatedAt time.Time `json:"c"`
+	Rank      float64   `json:"r,omitempty"`
+}
+
+// parsePage - проверяет параметры страницы. Сортировка курсора должна совпадать с запрошенной.
+// rank - разрешена ли сортировка по релевантности, она есть только у полнотекстового поиска
+func parsePage(req PageRequest, rank bool) (models.PetPage, bool) {
+	page := models.PetPage{
+		Sort:  models.PetSortID,
+		Limit: defaultPetsLimit,
+	}
+
+	if req.Limit != "" {
+		limit, err := strconv.Atoi(req.Limit)
+		if err != nil || limit <= 0 {
+			return models.PetPage{}, false
+		}
+		if limit > maxPetsLimit {
+			limit = maxPetsLimit
+		}
+		page.Limit = limit
+	}
+
+	if req.Cursor != "" {
+		data, err := base64.RawURLEncoding.DecodeString(req.Cursor)
+		if err != nil {
+			return models.PetPage{}, false
+		}
+		var cursor pageCursor
+		if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 {
+			return models.PetPage{}, false
+		}
+		page.Sort = cursor.Sort
+		page.Desc = cursor.Desc
+		page.After = &models.PetCursor{
+			ID:        cursor.ID,
+			Name:      cursor.Name,
+			CreatedAt: cursor.CreatedAt,
+			Rank:      cursor.Rank,
+		}
+	}
+
+	if req.Sort != "" {
+		if page.After != nil && req.Sort != page.Sort {
+			return models.PetPage{}, false
+		}
+		page.Sort = req.Sort
+	}
+	switch page.Sort {
+	case models.PetSortID, models.PetSortName, models.PetSortCreated:
+	case models.PetSortRank:
+		if !rank {
+			return models.PetPage{}, false
+		}
+	default:
+		return models.PetPage{}, false
+	}
+
+	switch req.Order {
+	case "":
+	case OrderAsc, OrderDesc:
+		desc := req.Order == OrderDesc
+		if page.After != nil && desc != page.Desc {
+			return models.PetPage{}, false
+		}
+		page.Desc = desc
+	default:
+		return models.PetPage{}, false
+	}
+
+	return page, true
+}
+
+// encodeCursor - курсор для страницы, следующей за питомцем last
+func encodeCursor(page models.PetPage, last models.Pet) string {
+	data, _ := json.Marshal(pageCursor{
+		Sort:      page.Sort,
+		Desc:      page.Desc,
+		ID:        last.ID,
+		Name:      last.Name,
+		CreatedAt: last.CreatedAt,
+		Rank:      last.Rank,
+	})
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
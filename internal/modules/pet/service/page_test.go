@@ -0,0 +1,97 @@
+package service
+
+import (
+	"pet-store/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePage(t *testing.T) {
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	cursor := encodeCursor(models.PetPage{Sort: models.PetSortName, Desc: true},
+		models.Pet{ID: 7, Name: "Rex", CreatedAt: created})
+
+	cases := []struct {
+		nameTest string
+		req      PageRequest
+		rank     bool
+		page     models.PetPage
+		ok       bool
+	}{
+		{
+			nameTest: "Defaults",
+			page:     models.PetPage{Sort: models.PetSortID, Limit: defaultPetsLimit},
+			ok:       true,
+		},
+		{
+			nameTest: "Limit capped",
+			req:      PageRequest{Limit: "1000", Sort: "created", Order: "desc"},
+			page:     models.PetPage{Sort: models.PetSortCreated, Desc: true, Limit: maxPetsLimit},
+			ok:       true,
+		},
+		{
+			nameTest: "Cursor round trip",
+			req:      PageRequest{Cursor: cursor, Limit: "5"},
+			page: models.PetPage{
+				Sort:  models.PetSortName,
+				Desc:  true,
+				Limit: 5,
+				After: &models.PetCursor{ID: 7, Name: "Rex", CreatedAt: created},
+			},
+			ok: true,
+		},
+		{
+			nameTest: "Cursor with other sort",
+			req:      PageRequest{Cursor: cursor, Sort: "id"},
+		},
+		{
+			nameTest: "Cursor with other order",
+			req:      PageRequest{Cursor: cursor, Order: "asc"},
+		},
+		{
+			nameTest: "Broken cursor",
+			req:      PageRequest{Cursor: "not-a-cursor"},
+		},
+		{
+			nameTest: "Unknown sort",
+			req:      PageRequest{Sort: "price"},
+		},
+		{
+			nameTest: "Rank without text query",
+			req:      PageRequest{Sort: "rank"},
+		},
+		{
+			nameTest: "Rank cursor round trip",
+			req: PageRequest{Cursor: encodeCursor(models.PetPage{Sort: models.PetSortRank, Desc: true},
+				models.Pet{ID: 3, Rank: 0.25})},
+			rank: true,
+			page: models.PetPage{
+				Sort:  models.PetSortRank,
+				Desc:  true,
+				Limit: defaultPetsLimit,
+				After: &models.PetCursor{ID: 3, Rank: 0.25},
+			},
+			ok: true,
+		},
+		{
+			nameTest: "Bad limit",
+			req:      PageRequest{Limit: "-1"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.nameTest, func(t *testing.T) {
+			t.Parallel()
+
+			page, ok := parsePage(tc.req, tc.rank)
+			assert.Equal(t, tc.ok, ok)
+			if tc.ok {
+				assert.Equal(t, tc.page, page)
+			}
+		})
+	}
+}
@@ -2,17 +2,33 @@ package service
 
 import (
 	"context"
+	"io"
+	"pet-store/internal/infrastructure/db/types"
 	"pet-store/internal/models"
 )
 
 //go:generate mockery --name=Peter
 
+// Режимы поиска питомцев по тегам
+const (
+	MatchAny = "any"
+	MatchAll = "all"
+)
+
 type Peter interface {
 	AddPet(ctx context.Context, pet PetAddRequest) RequestOut
-	UpdatePet(ctx context.Context, pet PetUpdateRequest) RequestOut
-	FindPetbyStatus(ctx context.Context, statuses []string) RequestOutWithPets
+	UpdatePet(ctx context.Context, pet PetUpdateRequest) RequestOutWithVersion
+	PatchPet(ctx context.Context, patch PetPatchRequest) RequestOutWithVersion
+	FindPetbyStatus(ctx context.Context, statuses []string, req PageRequest) RequestOutWithPets
+	FindPetbyTags(ctx context.Context, tags []string, match string) RequestOutWithPets
+	SearchPets(ctx context.Context, req RequestSearchPets) RequestOutWithPets
 	FindPetbyID(ctx context.Context, strID string) RequestOutWithPet
-	UpdatePetForm(ctx context.Context, name, status, reqID string) RequestOut
+	UpdatePetForm(ctx context.Context, name, status, reqID string, version int) RequestOutWithVersion
+	DeletePet(ctx context.Context, reqID string) RequestOut
+	RestorePet(ctx context.Context, reqID string) RequestOut
+	PurgeDeletedPets(ctx context.Context) RequestOutPurge
+	UploadImage(ctx context.Context, req UploadImageRequest) RequestOutWithImage
+	GetImage(ctx context.Context, reqID, name string) RequestOutImageFile
 }
 
 type PetAddRequest struct {
@@ -21,6 +37,19 @@ type PetAddRequest struct {
 	PhotoUrls []string `json:"photourls"`
 	Tags      []Tag    `json:"tags"`
 	Status    string   `json:"status"`
+	PetAttributes
+}
+
+// PetAttributes - необязательные сведения о питомце, пустое значение - не указано.
+// BirthDate - дата в формате 2006-01-02, не позже сегодняшней, Description - описание в markdown
+type PetAttributes struct {
+	Breed       string  `json:"breed" validate:"max=100"`
+	BirthDate   string  `json:"birthdate"`
+	Sex         string  `json:"sex" validate:"omitempty,oneof=male female"`
+	Weight      float64 `json:"weight" validate:"gte=0,lte=9999"`
+	Color       string  `json:"color" validate:"max=50"`
+	MicrochipID string  `json:"microchipid" validate:"omitempty,alphanum,min=9,max=15"`
+	Description string  `json:"description" validate:"max=10000"`
 }
 
 type Category struct {
@@ -36,6 +65,41 @@ type RequestOut struct {
 	ErrorCode int
 }
 
+// RequestOutWithVersion - результат изменения питомца с его новой версией
+type RequestOutWithVersion struct {
+	Version   int
+	Status    bool
+	ErrorCode int
+}
+
+type RequestOutPurge struct {
+	Purged    int
+	Status    bool
+	ErrorCode int
+}
+
+// UploadImageRequest - загружаемое изображение питомца
+type UploadImageRequest struct {
+	PetID              string
+	FileName           string
+	AdditionalMetadata string
+	Size               int64
+	File               io.Reader
+}
+
+type RequestOutWithImage struct {
+	Image     models.PetImage
+	Status    bool
+	ErrorCode int
+}
+
+type RequestOutImageFile struct {
+	Image     models.PetImage
+	File      io.ReadCloser
+	Status    bool
+	ErrorCode int
+}
+
 type RequestOutWithPet struct {
 	Pet       models.Pet
 	Status    bool
@@ -44,10 +108,45 @@ type RequestOutWithPet struct {
 
 type RequestOutWithPets struct {
 	Pets      []models.Pet
+	Next      string
 	Status    bool
 	ErrorCode int
 }
 
+// RequestSearchPets - условия поиска в том виде, в каком они пришли в запросе.
+// Query - слова для полнотекстового поиска по имени, категории и тегам.
+// Теги и статусы можно передать несколькими параметрами или через запятую, даты создания - в формате RFC 3339,
+// даты рождения - в формате 2006-01-02, вес - в килограммах
+type RequestSearchPets struct {
+	Query       string
+	Name        string
+	Category    string
+	Tags        []string
+	Match       string
+	Statuses    []string
+	CreatedFrom string
+	CreatedTo   string
+	Breed       string
+	Sex         string
+	Color       string
+	MicrochipID string
+	BornFrom    string
+	BornTo      string
+	WeightMin   string
+	WeightMax   string
+	Page        PageRequest
+}
+
+// PageRequest - параметры страницы в том виде, в каком они пришли в запросе.
+// Cursor - непрозрачный курсор из Next предыдущей страницы
+type PageRequest struct {
+	Cursor string
+	Limit  string
+	Sort   string
+	Order  string
+}
+
+// PetUpdateRequest - новые данные питомца. Version - ожидаемая версия питомца из If-Match, 0 - любая
 type PetUpdateRequest struct {
 	ID        int      `json:"id"`
 	Category  Category `json:"category"`
@@ -55,6 +154,42 @@ type PetUpdateRequest struct {
 	PhotoUrls []string `json:"photourls"`
 	Tags      []Tag    `json:"tags"`
 	Status    string   `json:"status"`
+	Version   int      `json:"-"`
+	PetAttributes
+}
+
+// PetPatchRequest - изменения питомца в формате JSON Merge Patch (RFC 7396): отсутствующие поля
+// не меняются, null очищает необязательные поля, массивы заменяются целиком.
+// Статус и категорию очистить нельзя. Version - ожидаемая версия питомца из If-Match, 0 - любая
+type PetPatchRequest struct {
+	ID          int                   `json:"-"`
+	Version     int                   `json:"-"`
+	Category    types.Patch[Category] `json:"category"`
+	Name        types.PatchString     `json:"name"`
+	PhotoUrls   types.Patch[[]string] `json:"photourls"`
+	Tags        types.Patch[[]Tag]    `json:"tags"`
+	Status      types.PatchString     `json:"status"`
+	Breed       types.PatchString     `json:"breed"`
+	BirthDate   types.PatchString     `json:"birthdate"`
+	Sex         types.PatchString     `json:"sex"`
+	Weight      types.PatchFloat64    `json:"weight"`
+	Color       types.PatchString     `json:"color"`
+	MicrochipID types.PatchString     `json:"microchipid"`
+	Description types.PatchString     `json:"description"`
+}
+
+// Attributes - необязательные сведения из патча для проверки теми же правилами, что и PetAttributes.
+// Очищаемые поля проверяются как пустые
+func (r PetPatchRequest) Attributes() PetAttributes {
+	return PetAttributes{
+		Breed:       r.Breed.String,
+		BirthDate:   r.BirthDate.String,
+		Sex:         r.Sex.String,
+		Weight:      r.Weight.Float64,
+		Color:       r.Color.String,
+		MicrochipID: r.MicrochipID.String,
+		Description: r.Description.String,
+	}
 }
 
 type PetFindResponse struct {
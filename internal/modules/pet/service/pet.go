@@ -2,26 +2,34 @@ package service
 
 import (
 	"context"
+	"pet-store/config"
+	"pet-store/internal/infrastructure/blobstore"
+	"pet-store/internal/infrastructure/db/types"
 	"pet-store/internal/infrastructure/errors"
 	"pet-store/internal/models"
 	"pet-store/internal/modules/pet/storage"
 	"strconv"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 type PetService struct {
 	storage storage.Peter
+	blobs   blobstore.Storer
+	conf    config.Pet
 	logger  *zap.Logger
 }
 
-func NewPetService(storage storage.Peter, logger *zap.Logger) *PetService {
-	return &PetService{storage: storage, logger: logger}
+func NewPetService(storage storage.Peter, blobs blobstore.Storer, conf config.Pet, logger *zap.Logger) *PetService {
+	return &PetService{storage: storage, blobs: blobs, conf: conf, logger: logger}
 }
 
-func (p *PetService) UpdatePetForm(ctx context.Context, name, status, reqID string) RequestOut {
+// UpdatePetForm - меняет имя и статус питомца. version - ожидаемая версия питомца, 0 - любая
+func (p *PetService) UpdatePetForm(ctx context.Context, name, status, reqID string, version int) RequestOutWithVersion {
 	if status == "" && reqID == "" {
-		return RequestOut{
+		return RequestOutWithVersion{
 			Status:    false,
 			ErrorCode: errors.PetServiceUpdatePetFormBadReuest,
 		}
@@ -30,23 +38,31 @@ func (p *PetService) UpdatePetForm(ctx context.Context, name, status, reqID stri
 	petID, err := strconv.Atoi(reqID)
 	if err != nil {
 		p.logger.Error("Error during conversion:", zap.Error(err))
-		return RequestOut{
+		return RequestOutWithVersion{
 			Status:    false,
 			ErrorCode: errors.UpdatePetFormErrorDuringConversion,
 		}
 	}
 
-	err = p.storage.UpdatePetForm(ctx, name, status, petID)
+	newVersion, err := p.storage.UpdatePetForm(ctx, name, status, petID, version)
 	if err != nil {
 		p.logger.Error("Error:", zap.Error(err))
-		return RequestOut{
+		errorcode := errors.UpdatePetFormError
+		switch err {
+		case errors.ErrPetNotFound:
+			errorcode = errors.PetServiceErrPetNotFound
+		case errors.ErrPetVersion:
+			errorcode = errors.PetServiceVersionMismatchErr
+		}
+		return RequestOutWithVersion{
 			Status:    false,
-			ErrorCode: errors.UpdatePetFormError,
+			ErrorCode: errorcode,
 		}
 	}
 
-	return RequestOut{
-		Status: true,
+	return RequestOutWithVersion{
+		Version: newVersion,
+		Status:  true,
 	}
 }
 
@@ -79,8 +95,22 @@ func (p *PetService) FindPetbyID(ctx context.Context, strID string) RequestOutWi
 	}
 }
 
-func (p *PetService) FindPetbyStatus(ctx context.Context, statuses []string) RequestOutWithPets {
-	pets, err := p.storage.FindPetbyStatus(ctx, statuses)
+func (p *PetService) FindPetbyStatus(ctx context.Context, statuses []string, req PageRequest) RequestOutWithPets {
+	page, ok := parsePage(req, false)
+	if ok {
+		statuses, ok = parseStatuses(statuses)
+	}
+	if !ok || len(statuses) == 0 {
+		return RequestOutWithPets{
+			Status:    false,
+			ErrorCode: errors.FindPetbyStatusBadRequest,
+		}
+	}
+
+	// Запрашиваем на одного питомца больше, чтобы узнать, есть ли следующая страница
+	limit := page.Limit
+	page.Limit++
+	pets, err := p.storage.FindPetbyStatus(ctx, statuses, page)
 	if err != nil {
 		p.logger.Error("Error FindPetbyStatus:", zap.Error(err))
 		return RequestOutWithPets{
@@ -89,13 +119,234 @@ func (p *PetService) FindPetbyStatus(ctx context.Context, statuses []string) Req
 		}
 	}
 
+	var next string
+	if len(pets) > limit {
+		pets = pets[:limit]
+		next = encodeCursor(page, pets[limit-1])
+	}
+
+	return RequestOutWithPets{
+		Pets:   pets,
+		Next:   next,
+		Status: true,
+	}
+}
+
+// FindPetbyTags - поиск питомцев по тегам. match: "any" (по умолчанию) - хотя бы один тег, "all" - все теги
+func (p *PetService) FindPetbyTags(ctx context.Context, tags []string, match string) RequestOutWithPets {
+	matchAll, ok := parseMatch(match)
+	if !ok {
+		return RequestOutWithPets{
+			Status:    false,
+			ErrorCode: errors.FindPetbyTagsBadRequest,
+		}
+	}
+
+	names := splitList(tags)
+	if len(names) == 0 {
+		return RequestOutWithPets{
+			Status:    false,
+			ErrorCode: errors.FindPetbyTagsBadRequest,
+		}
+	}
+
+	pets, err := p.storage.FindPetbyTags(ctx, names, matchAll)
+	if err != nil {
+		p.logger.Error("Error FindPetbyTags:", zap.Error(err))
+		return RequestOutWithPets{
+			Status:    false,
+			ErrorCode: errors.PetServiceFindPetbyTags,
+		}
+	}
+
 	return RequestOutWithPets{
 		Pets:   pets,
 		Status: true,
 	}
 }
 
+// SearchPets - страница питомцев, подходящих под все переданные условия
+func (p *PetService) SearchPets(ctx context.Context, req RequestSearchPets) RequestOutWithPets {
+	badRequest := RequestOutWithPets{
+		Status:    false,
+		ErrorCode: errors.SearchPetsBadRequest,
+	}
+
+	text := strings.TrimSpace(req.Query)
+	// Результаты полнотекстового поиска по умолчанию упорядочены от самых релевантных
+	if text != "" && req.Page.Sort == "" && req.Page.Cursor == "" {
+		req.Page.Sort = models.PetSortRank
+		if req.Page.Order == "" {
+			req.Page.Order = OrderDesc
+		}
+	}
+	page, ok := parsePage(req.Page, text != "")
+	if !ok {
+		return badRequest
+	}
+	matchAll, ok := parseMatch(req.Match)
+	if !ok {
+		return badRequest
+	}
+	filter := models.PetFilter{
+		Query:        text,
+		Name:         strings.TrimSpace(req.Name),
+		Category:     strings.TrimSpace(req.Category),
+		Tags:         splitList(req.Tags),
+		MatchAllTags: matchAll,
+		Breed:        strings.TrimSpace(req.Breed),
+		Sex:          req.Sex,
+		Color:        strings.TrimSpace(req.Color),
+		MicrochipID:  strings.TrimSpace(req.MicrochipID),
+	}
+	if filter.Sex != "" && filter.Sex != models.PetSexMale && filter.Sex != models.PetSexFemale {
+		return badRequest
+	}
+	if filter.Statuses, ok = parseStatuses(req.Statuses); !ok {
+		return badRequest
+	}
+	var err error
+	if req.CreatedFrom != "" {
+		if filter.CreatedFrom, err = time.Parse(time.RFC3339, req.CreatedFrom); err != nil {
+			return badRequest
+		}
+	}
+	if req.CreatedTo != "" {
+		if filter.CreatedTo, err = time.Parse(time.RFC3339, req.CreatedTo); err != nil {
+			return badRequest
+		}
+	}
+	if req.BornFrom != "" {
+		if filter.BornFrom, err = time.Parse(models.PetDateLayout, req.BornFrom); err != nil {
+			return badRequest
+		}
+	}
+	if req.BornTo != "" {
+		if filter.BornTo, err = time.Parse(models.PetDateLayout, req.BornTo); err != nil {
+			return badRequest
+		}
+	}
+	if req.WeightMin != "" {
+		if filter.WeightMin, err = strconv.ParseFloat(req.WeightMin, 64); err != nil || filter.WeightMin < 0 {
+			return badRequest
+		}
+	}
+	if req.WeightMax != "" {
+		if filter.WeightMax, err = strconv.ParseFloat(req.WeightMax, 64); err != nil || filter.WeightMax < 0 {
+			return badRequest
+		}
+	}
+
+	// Запрашиваем на одного питомца больше, чтобы узнать, есть ли следующая страница
+	limit := page.Limit
+	page.Limit++
+	pets, err := p.storage.SearchPets(ctx, filter, page)
+	if err != nil {
+		p.logger.Error("Error SearchPets:", zap.Error(err))
+		return RequestOutWithPets{
+			Status:    false,
+			ErrorCode: errors.PetServiceSearchPetsErr,
+		}
+	}
+
+	var next string
+	if len(pets) > limit {
+		pets = pets[:limit]
+		next = encodeCursor(page, pets[limit-1])
+	}
+
+	return RequestOutWithPets{
+		Pets:   pets,
+		Next:   next,
+		Status: true,
+	}
+}
+
+// parseMatch - режим поиска по тегам, по умолчанию MatchAny
+func parseMatch(match string) (bool, bool) {
+	switch match {
+	case "", MatchAny:
+		return false, true
+	case MatchAll:
+		return true, true
+	}
+
+	return false, false
+}
+
+// splitList - значения из повторяющихся параметров и списков через запятую без пустых строк и повторов
+func splitList(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	list := make([]string, 0, len(values))
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" || seen[item] {
+				continue
+			}
+			seen[item] = true
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+// parseStatuses - статусы питомцев из повторяющихся параметров и списков через запятую.
+// false, если среди них есть неизвестный статус
+func parseStatuses(values []string) ([]string, bool) {
+	statuses := splitList(values)
+	for _, status := range statuses {
+		if !isPetStatus(status) {
+			return nil, false
+		}
+	}
+
+	return statuses, true
+}
+
+// validBirthDate - дата рождения не указана или это дата не позже сегодняшней
+func validBirthDate(date string) bool {
+	if date == "" {
+		return true
+	}
+	birthDate, err := time.Parse(models.PetDateLayout, date)
+	if err != nil {
+		return false
+	}
+
+	return !birthDate.After(time.Now())
+}
+
+// setAttributes - переносит необязательные сведения о питомце из запроса
+func setAttributes(pet *models.Pet, attrs PetAttributes) {
+	pet.Breed = strings.TrimSpace(attrs.Breed)
+	pet.BirthDate = attrs.BirthDate
+	pet.Sex = attrs.Sex
+	pet.Weight = attrs.Weight
+	pet.Color = strings.TrimSpace(attrs.Color)
+	pet.MicrochipID = attrs.MicrochipID
+	pet.Description = attrs.Description
+}
+
+func isPetStatus(status string) bool {
+	for _, known := range models.PetStatuses {
+		if status == known {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (p *PetService) AddPet(ctx context.Context, pet PetAddRequest) RequestOut {
+	if !validBirthDate(pet.BirthDate) {
+		return RequestOut{
+			Status:    false,
+			ErrorCode: errors.PetAttributesBadRequest,
+		}
+	}
+
 	photourls := make([]string, 0, len(pet.PhotoUrls))
 	if pet.PhotoUrls != nil {
 		photourls = append(photourls, pet.PhotoUrls...)
@@ -115,12 +366,20 @@ func (p *PetService) AddPet(ctx context.Context, pet PetAddRequest) RequestOut {
 		Tags:      tags,
 		Status:    pet.Status,
 	}
+	setAttributes(&petStorage, pet.PetAttributes)
 	err := p.storage.AddPet(ctx, &petStorage)
 	if err != nil {
 		p.logger.Error("Error AddPet:", zap.Error(err))
+		errorcode := errors.AddPetErr
+		switch err {
+		case errors.ErrCategoryNotFound:
+			errorcode = errors.PetServiceCategoryNotFoundErr
+		case errors.ErrMicrochipExists:
+			errorcode = errors.PetServiceMicrochipExistsErr
+		}
 		return RequestOut{
 			Status:    false,
-			ErrorCode: errors.AddPetErr,
+			ErrorCode: errorcode,
 		}
 	}
 
@@ -129,10 +388,19 @@ func (p *PetService) AddPet(ctx context.Context, pet PetAddRequest) RequestOut {
 	}
 }
 
-func (p *PetService) UpdatePet(ctx context.Context, pet PetUpdateRequest) RequestOut {
-	photourls := make([]string, 0, len(pet.PhotoUrls))
+// UpdatePet - обновляет питомца, если его версия равна pet.Version (0 - любая)
+func (p *PetService) UpdatePet(ctx context.Context, pet PetUpdateRequest) RequestOutWithVersion {
+	if !validBirthDate(pet.BirthDate) {
+		return RequestOutWithVersion{
+			Status:    false,
+			ErrorCode: errors.PetAttributesBadRequest,
+		}
+	}
+
+	// nil - фотографии не переданы и остаются прежними, пустой список - удалить все
+	var photourls []string
 	if pet.PhotoUrls != nil {
-		photourls = append(photourls, pet.PhotoUrls...)
+		photourls = append(make([]string, 0, len(pet.PhotoUrls)), pet.PhotoUrls...)
 	}
 
 	tags := make([]models.Tag, 0, len(pet.Tags))
@@ -149,14 +417,132 @@ func (p *PetService) UpdatePet(ctx context.Context, pet PetUpdateRequest) Reques
 		PhotoUrls: photourls,
 		Tags:      tags,
 		Status:    pet.Status,
+		Version:   pet.Version,
 	}
+	setAttributes(&petStorage, pet.PetAttributes)
 
-	err := p.storage.UpdatePet(ctx, petStorage)
+	err := p.storage.UpdatePet(ctx, &petStorage)
 	if err != nil {
 		p.logger.Error("Error UpdatePet:", zap.Error(err))
+		errorcode := errors.PetServiceUpdateErr
+		switch err {
+		case errors.ErrCategoryNotFound:
+			errorcode = errors.PetServiceCategoryNotFoundErr
+		case errors.ErrMicrochipExists:
+			errorcode = errors.PetServiceMicrochipExistsErr
+		case errors.ErrPetNotFound:
+			errorcode = errors.PetServiceErrPetNotFound
+		case errors.ErrPetVersion:
+			errorcode = errors.PetServiceVersionMismatchErr
+		}
+		return RequestOutWithVersion{
+			Status:    false,
+			ErrorCode: errorcode,
+		}
+	}
+
+	return RequestOutWithVersion{
+		Version: petStorage.Version,
+		Status:  true,
+	}
+}
+
+// PatchPet - частично обновляет питомца по JSON Merge Patch, если его версия равна patch.Version (0 - любая)
+func (p *PetService) PatchPet(ctx context.Context, patch PetPatchRequest) RequestOutWithVersion {
+	if !validBirthDate(patch.BirthDate.String) {
+		return RequestOutWithVersion{
+			Status:    false,
+			ErrorCode: errors.PetAttributesBadRequest,
+		}
+	}
+	// Статус и категория у питомца обязательны: null и пустое значение для них недопустимы
+	statusInvalid := patch.Status.Set && !isPetStatus(patch.Status.String)
+	categoryInvalid := patch.Category.Set && strings.TrimSpace(patch.Category.Value.Name) == ""
+	if statusInvalid || categoryInvalid {
+		return RequestOutWithVersion{
+			Status:    false,
+			ErrorCode: errors.PetPatchBadRequest,
+		}
+	}
+
+	petPatch := models.PetPatch{
+		ID:          patch.ID,
+		Version:     patch.Version,
+		Name:        patch.Name,
+		Status:      patch.Status,
+		PhotoUrls:   patch.PhotoUrls,
+		Breed:       trimPatch(patch.Breed),
+		BirthDate:   patch.BirthDate,
+		Sex:         patch.Sex,
+		Weight:      patch.Weight,
+		Color:       trimPatch(patch.Color),
+		MicrochipID: patch.MicrochipID,
+		Description: patch.Description,
+	}
+	if patch.Category.Set {
+		petPatch.Category = types.PatchString{NullString: types.NewNullString(patch.Category.Value.Name), Set: true}
+	}
+	if patch.Tags.Set {
+		petPatch.Tags.Set = true
+		for i := range patch.Tags.Value {
+			petPatch.Tags.Value = append(petPatch.Tags.Value, models.Tag{Name: patch.Tags.Value[i].Name})
+		}
+	}
+
+	err := p.storage.PatchPet(ctx, &petPatch)
+	if err != nil {
+		p.logger.Error("Error PatchPet:", zap.Error(err))
+		errorcode := errors.PetServiceUpdateErr
+		switch err {
+		case errors.ErrCategoryNotFound:
+			errorcode = errors.PetServiceCategoryNotFoundErr
+		case errors.ErrMicrochipExists:
+			errorcode = errors.PetServiceMicrochipExistsErr
+		case errors.ErrPetNotFound:
+			errorcode = errors.PetServiceErrPetNotFound
+		case errors.ErrPetVersion:
+			errorcode = errors.PetServiceVersionMismatchErr
+		}
+		return RequestOutWithVersion{
+			Status:    false,
+			ErrorCode: errorcode,
+		}
+	}
+
+	return RequestOutWithVersion{
+		Version: petPatch.Version,
+		Status:  true,
+	}
+}
+
+// trimPatch - убирает пробелы по краям значения поля патча, пустое после этого значение очищает поле
+func trimPatch(field types.PatchString) types.PatchString {
+	if !field.Set {
+		return field
+	}
+	return types.PatchString{NullString: types.NewNullString(strings.TrimSpace(field.String)), Set: true}
+}
+
+func (p *PetService) DeletePet(ctx context.Context, reqID string) RequestOut {
+	petID, err := strconv.Atoi(reqID)
+	if err != nil {
+		p.logger.Error("Error during conversion:", zap.Error(err))
 		return RequestOut{
 			Status:    false,
-			ErrorCode: errors.PetServiceUpdateErr,
+			ErrorCode: errors.PetIDErrorDuringConversion,
+		}
+	}
+
+	err = p.storage.DeletePet(ctx, petID)
+	if err != nil {
+		p.logger.Error("Error DeletePet:", zap.Error(err))
+		errorcode := errors.PetServiceDeletePetErr
+		if err == errors.ErrPetNotFound {
+			errorcode = errors.PetServiceErrPetNotFound
+		}
+		return RequestOut{
+			Status:    false,
+			ErrorCode: errorcode,
 		}
 	}
 
@@ -164,3 +550,56 @@ func (p *PetService) UpdatePet(ctx context.Context, pet PetUpdateRequest) Reques
 		Status: true,
 	}
 }
+
+func (p *PetService) RestorePet(ctx context.Context, reqID string) RequestOut {
+	petID, err := strconv.Atoi(reqID)
+	if err != nil {
+		p.logger.Error("Error during conversion:", zap.Error(err))
+		return RequestOut{
+			Status:    false,
+			ErrorCode: errors.PetIDErrorDuringConversion,
+		}
+	}
+
+	err = p.storage.RestorePet(ctx, petID)
+	if err != nil {
+		p.logger.Error("Error RestorePet:", zap.Error(err))
+		errorcode := errors.PetServiceRestorePetErr
+		if err == errors.ErrPetNotFound {
+			errorcode = errors.PetServiceErrPetNotFound
+		}
+		return RequestOut{
+			Status:    false,
+			ErrorCode: errorcode,
+		}
+	}
+
+	return RequestOut{
+		Status: true,
+	}
+}
+
+// PurgeDeletedPets - окончательно удаляет питомцев, удалённых раньше срока хранения
+func (p *PetService) PurgeDeletedPets(ctx context.Context) RequestOutPurge {
+	deletedBefore := time.Now().Add(-p.conf.DeletedRetention)
+	// Файлы удаляем только у действительно удалённых из БД питомцев
+	purged, keys, err := p.storage.PurgeDeletedPets(ctx, deletedBefore)
+	if err != nil {
+		p.logger.Error("Error PurgeDeletedPets:", zap.Error(err))
+		return RequestOutPurge{
+			Status:    false,
+			ErrorCode: errors.PetServicePurgeErr,
+		}
+	}
+	for _, key := range keys {
+		if err := p.blobs.Delete(ctx, key); err != nil {
+			p.logger.Error("Error PurgeDeletedPets delete image:", zap.Error(err))
+		}
+	}
+	p.logger.Info("deleted pets purged", zap.Int("count", purged))
+
+	return RequestOutPurge{
+		Purged: purged,
+		Status: true,
+	}
+}
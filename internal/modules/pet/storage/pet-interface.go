@@ -3,12 +3,21 @@ package storage
 import (
 	"context"
 	"pet-store/internal/models"
+	"time"
 )
 
 type Peter interface {
 	AddPet(ctx context.Context, pet *models.Pet) error
-	UpdatePet(ctx context.Context, pet models.Pet) error
-	FindPetbyStatus(ctx context.Context, statuses []string) ([]models.Pet, error)
+	UpdatePet(ctx context.Context, pet *models.Pet) error
+	PatchPet(ctx context.Context, patch *models.PetPatch) error
+	FindPetbyStatus(ctx context.Context, statuses []string, page models.PetPage) ([]models.Pet, error)
+	FindPetbyTags(ctx context.Context, tags []string, matchAll bool) ([]models.Pet, error)
+	SearchPets(ctx context.Context, filter models.PetFilter, page models.PetPage) ([]models.Pet, error)
 	FindPetbyID(ctx context.Context, petID int) (models.Pet, error)
-	UpdatePetForm(ctx context.Context, name, status string, petID int) error
+	UpdatePetForm(ctx context.Context, name, status string, petID, version int) (int, error)
+	DeletePet(ctx context.Context, petID int) error
+	RestorePet(ctx context.Context, petID int) error
+	PurgeDeletedPets(ctx context.Context, deletedBefore time.Time) (int, []string, error)
+	AddPetImage(ctx context.Context, image *models.PetImage) error
+	FindPetImage(ctx context.Context, petID int, name string) (models.PetImage, error)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"pet-store/internal/db/adapter"
 	"pet-store/internal/models"
+	"time"
 )
 
 // PetStorage - хранилище животных
@@ -21,18 +22,50 @@ func (a *PetStorage) AddPet(ctx context.Context, pet *models.Pet) error {
 	return err
 }
 
-func (a *PetStorage) UpdatePet(ctx context.Context, pet models.Pet) error {
+func (a *PetStorage) UpdatePet(ctx context.Context, pet *models.Pet) error {
 	return a.adapter.UpdatePet(ctx, pet)
 }
 
-func (a *PetStorage) FindPetbyStatus(ctx context.Context, statuses []string) ([]models.Pet, error) {
-	return a.adapter.FindPetbyStatus(ctx, statuses)
+func (a *PetStorage) PatchPet(ctx context.Context, patch *models.PetPatch) error {
+	return a.adapter.PatchPet(ctx, patch)
+}
+
+func (a *PetStorage) FindPetbyStatus(ctx context.Context, statuses []string, page models.PetPage) ([]models.Pet, error) {
+	return a.adapter.FindPetbyStatus(ctx, statuses, page)
+}
+
+func (a *PetStorage) FindPetbyTags(ctx context.Context, tags []string, matchAll bool) ([]models.Pet, error) {
+	return a.adapter.FindPetbyTags(ctx, tags, matchAll)
+}
+
+func (a *PetStorage) SearchPets(ctx context.Context, filter models.PetFilter, page models.PetPage) ([]models.Pet, error) {
+	return a.adapter.SearchPets(ctx, filter, page)
 }
 
 func (a *PetStorage) FindPetbyID(ctx context.Context, petID int) (models.Pet, error) {
 	return a.adapter.FindPetbyID(ctx, petID)
 }
 
-func (a *PetStorage) UpdatePetForm(ctx context.Context, name, status string, petID int) error {
-	return a.adapter.UpdatePetForm(ctx, name, status, petID)
+func (a *PetStorage) UpdatePetForm(ctx context.Context, name, status string, petID, version int) (int, error) {
+	return a.adapter.UpdatePetForm(ctx, name, status, petID, version)
+}
+
+func (a *PetStorage) DeletePet(ctx context.Context, petID int) error {
+	return a.adapter.DeletePet(ctx, petID)
+}
+
+func (a *PetStorage) RestorePet(ctx context.Context, petID int) error {
+	return a.adapter.RestorePet(ctx, petID)
+}
+
+func (a *PetStorage) PurgeDeletedPets(ctx context.Context, deletedBefore time.Time) (int, []string, error) {
+	return a.adapter.PurgeDeletedPets(ctx, deletedBefore)
+}
+
+func (a *PetStorage) AddPetImage(ctx context.Context, image *models.PetImage) error {
+	return a.adapter.AddPetImage(ctx, image)
+}
+
+func (a *PetStorage) FindPetImage(ctx context.Context, petID int, name string) (models.PetImage, error) {
+	return a.adapter.FindPetImage(ctx, petID, name)
 }
@@ -3,25 +3,31 @@ package modules
 import (
 	"pet-store/internal/infrastructure/component"
 	aservice "pet-store/internal/modules/auth/service"
+	cservice "pet-store/internal/modules/category/service"
+	oservice "pet-store/internal/modules/order/service"
 	petservice "pet-store/internal/modules/pet/service"
+	tservice "pet-store/internal/modules/tag/service"
 	uservice "pet-store/internal/modules/user/service"
-	oservice "pet-store/internal/modules/order/service"
 	"pet-store/internal/storages"
 )
 
 type Services struct {
-	User uservice.Userer
-	Auth aservice.Auther
-	Pet  petservice.Peter
-	Order oservice.Orderer
+	User     uservice.Userer
+	Auth     aservice.Auther
+	Pet      petservice.Peter
+	Order    oservice.Orderer
+	Category cservice.Categorer
+	Tag      tservice.Tagger
 }
 
 func NewServices(storages *storages.Storages, components *component.Components) *Services {
-	userService := uservice.NewUserService(storages.User, components.Logger)
+	userService := uservice.NewUserService(storages.User, storages.Token, components.Logger)
 	return &Services{
-		User: userService,
-		Auth: aservice.NewAuth(userService, components),
-		Pet:  petservice.NewPetService(storages.Pet, components.Logger),
-		Order: oservice.NewOrderService(storages.Order, components.Logger),
+		User:     userService,
+		Auth:     aservice.NewAuth(userService, storages.Token, components),
+		Pet:      petservice.NewPetService(storages.Pet, storages.Blob, components.Conf.Pet, components.Logger),
+		Order:    oservice.NewOrderService(storages.Order, components.Logger),
+		Category: cservice.NewCategoryService(storages.Category, components.Logger),
+		Tag:      tservice.NewTagService(storages.Tag, components.Logger),
 	}
 }
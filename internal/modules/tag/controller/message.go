@@ -0,0 +1,27 @@
+package controller
+
+import "pet-store/internal/models"
+
+type TagResponseErr struct {
+	Success   bool
+	ErrorCode int
+	Data      Data
+}
+
+type Data struct {
+	Message string
+}
+
+type ListTagsResponse struct {
+	Success bool
+	Tags    []models.TagWithCount
+}
+
+type TagResponse struct {
+	Success bool
+}
+
+type DeleteOrphanTagsResponse struct {
+	Success bool
+	Deleted int
+}
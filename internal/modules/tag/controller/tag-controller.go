@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"net/http"
+	"pet-store/internal/infrastructure/component"
+	"pet-store/internal/infrastructure/errors"
+	"pet-store/internal/infrastructure/responder"
+	"pet-store/internal/modules/tag/service"
+
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator"
+	"github.com/ptflp/godecoder"
+)
+
+type Tagger interface {
+	ListTags(w http.ResponseWriter, r *http.Request)
+	RenameTag(w http.ResponseWriter, r *http.Request)
+	MergeTags(w http.ResponseWriter, r *http.Request)
+	DeleteOrphanTags(w http.ResponseWriter, r *http.Request)
+}
+
+type Tag struct {
+	service service.Tagger
+	responder.Responder
+	godecoder.Decoder
+}
+
+func NewTag(service service.Tagger, components *component.Components) Tagger {
+	return &Tag{service: service, Responder: components.Responder, Decoder: components.Decoder}
+}
+
+// @Summary List Tags
+// @Security ApiKeyAuth
+// @Tags tag
+// @Description list all tags with the number of pets using each of them
+// @ID ListTags
+// @Produce  json
+// @Success 200 {object} ListTagsResponse "Success"
+// @Router /tag [get]
+func (t *Tag) ListTags(w http.ResponseWriter, r *http.Request) {
+	out := t.service.ListTags(r.Context())
+	if out.ErrorCode != errors.NoError {
+		t.outputTagError(w, out.ErrorCode, "error list tags")
+		return
+	}
+
+	t.OutputJSON(w, ListTagsResponse{
+		Success: true,
+		Tags:    out.Tags,
+	})
+}
+
+// @Summary Rename Tag
+// @Security ApiKeyAuth
+// @Tags tag
+// @Description rename a tag, the new name must be unique. To combine two tags use merge.
+// @ID RenameTag
+// @Accept  json
+// @Produce  json
+// @Param tagId path string true "Tag ID"
+// @Param input body service.RequestRenameTag true "New name"
+// @Success 200 {object} TagResponse "Success"
+// @Failure 400 {object} TagResponseErr "Invalid id or name"
+// @Failure 404 {object} TagResponseErr "Tag not found"
+// @Failure 409 {object} TagResponseErr "Tag already exists"
+// @Router /tag/{tagId} [put]
+func (t *Tag) RenameTag(w http.ResponseWriter, r *http.Request) {
+	var req service.RequestRenameTag
+	if err := t.Decode(r.Body, &req); err != nil {
+		t.ErrorBadRequest(w, err)
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		t.ErrorBadRequest(w, err)
+		return
+	}
+
+	out := t.service.RenameTag(r.Context(), chi.URLParam(r, "tagId"), req)
+	if out.ErrorCode != errors.NoError {
+		t.outputTagError(w, out.ErrorCode, "error rename tag")
+		return
+	}
+
+	t.OutputJSON(w, TagResponse{
+		Success: true,
+	})
+}
+
+// @Summary Merge Tags
+// @Security ApiKeyAuth
+// @Tags tag
+// @Description merge the tag into another one: its pets get the target tag and the tag is deleted
+// @ID MergeTags
+// @Accept  json
+// @Produce  json
+// @Param tagId path string true "Tag ID to merge"
+// @Param input body service.RequestMergeTags true "Target tag"
+// @Success 200 {object} TagResponse "Success"
+// @Failure 400 {object} TagResponseErr "Invalid id"
+// @Failure 404 {object} TagResponseErr "Tag not found"
+// @Router /tag/{tagId}/merge [post]
+func (t *Tag) MergeTags(w http.ResponseWriter, r *http.Request) {
+	var req service.RequestMergeTags
+	if err := t.Decode(r.Body, &req); err != nil {
+		t.ErrorBadRequest(w, err)
+		return
+	}
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		t.ErrorBadRequest(w, err)
+		return
+	}
+
+	out := t.service.MergeTags(r.Context(), chi.URLParam(r, "tagId"), req)
+	if out.ErrorCode != errors.NoError {
+		t.outputTagError(w, out.ErrorCode, "error merge tags")
+		return
+	}
+
+	t.OutputJSON(w, TagResponse{
+		Success: true,
+	})
+}
+
+// @Summary Delete orphaned Tags
+// @Security ApiKeyAuth
+// @Tags tag
+// @Description delete all tags that are not used by any pet
+// @ID DeleteOrphanTags
+// @Produce  json
+// @Success 200 {object} DeleteOrphanTagsResponse "Number of deleted tags"
+// @Router /tag/orphans [delete]
+func (t *Tag) DeleteOrphanTags(w http.ResponseWriter, r *http.Request) {
+	out := t.service.DeleteOrphanTags(r.Context())
+	if out.ErrorCode != errors.NoError {
+		t.outputTagError(w, out.ErrorCode, "error delete orphaned tags")
+		return
+	}
+
+	t.OutputJSON(w, DeleteOrphanTagsResponse{
+		Success: true,
+		Deleted: out.Deleted,
+	})
+}
+
+// outputTagError - отвечает ошибкой с HTTP статусом, соответствующим коду ошибки сервиса
+func (t *Tag) outputTagError(w http.ResponseWriter, errorCode int, msg string) {
+	switch errorCode {
+	case errors.TagBadRequest:
+		w.WriteHeader(http.StatusBadRequest)
+		msg = "invalid tag id or name"
+	case errors.TagServiceNotFoundErr:
+		w.WriteHeader(http.StatusNotFound)
+		msg = "no tag found with the provided id"
+	case errors.TagServiceExistsErr:
+		w.WriteHeader(http.StatusConflict)
+		msg = "tag with this name already exists"
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	t.OutputJSON(w, TagResponseErr{
+		Success:   false,
+		ErrorCode: errorCode,
+		Data: Data{
+			Message: msg,
+		},
+	})
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"pet-store/internal/models"
+)
+
+type Tagger interface {
+	ListTags(ctx context.Context) ResponseListTags
+	RenameTag(ctx context.Context, tagIDstr string, req RequestRenameTag) ResponseTag
+	MergeTags(ctx context.Context, tagIDstr string, req RequestMergeTags) ResponseTag
+	DeleteOrphanTags(ctx context.Context) ResponseDeleteOrphanTags
+}
+
+type RequestRenameTag struct {
+	Name string `json:"name" validate:"required"`
+}
+
+// RequestMergeTags - тег, в который сливается тег из пути запроса
+type RequestMergeTags struct {
+	Into int `json:"into" validate:"required"`
+}
+
+type ResponseListTags struct {
+	Status    bool
+	ErrorCode int
+	Tags      []models.TagWithCount
+}
+
+type ResponseTag struct {
+	Status    bool
+	ErrorCode int
+}
+
+type ResponseDeleteOrphanTags struct {
+	Status    bool
+	ErrorCode int
+	Deleted   int
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"pet-store/internal/infrastructure/errors"
+	"pet-store/internal/modules/tag/storage"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+// maxTagNameLen - максимальная длина имени тега (размер колонки tags.name)
+const maxTagNameLen = 255
+
+type TagService struct {
+	storage storage.Tagger
+	logger  *zap.Logger
+}
+
+func NewTagService(storage storage.Tagger, logger *zap.Logger) *TagService {
+	return &TagService{storage: storage, logger: logger}
+}
+
+func (t *TagService) ListTags(ctx context.Context) ResponseListTags {
+	tags, err := t.storage.ListTags(ctx)
+	if err != nil {
+		t.logger.Error("Error ListTags:", zap.Error(err))
+		return ResponseListTags{
+			Status:    false,
+			ErrorCode: errors.TagServiceListErr,
+		}
+	}
+
+	return ResponseListTags{
+		Status: true,
+		Tags:   tags,
+	}
+}
+
+func (t *TagService) RenameTag(ctx context.Context, tagIDstr string, req RequestRenameTag) ResponseTag {
+	tagID, err := strconv.Atoi(tagIDstr)
+	name := strings.TrimSpace(req.Name)
+	if err != nil || tagID <= 0 || name == "" || utf8.RuneCountInString(name) > maxTagNameLen {
+		return ResponseTag{
+			Status:    false,
+			ErrorCode: errors.TagBadRequest,
+		}
+	}
+
+	err = t.storage.RenameTag(ctx, tagID, name)
+	if err != nil {
+		t.logger.Error("Error RenameTag:", zap.Error(err))
+		return ResponseTag{
+			Status:    false,
+			ErrorCode: tagErrorCode(err, errors.TagServiceRenameErr),
+		}
+	}
+
+	return ResponseTag{
+		Status: true,
+	}
+}
+
+// MergeTags - сливает тег tagIDstr в тег req.Into: питомцы получают тег req.Into, а tagIDstr удаляется
+func (t *TagService) MergeTags(ctx context.Context, tagIDstr string, req RequestMergeTags) ResponseTag {
+	tagID, err := strconv.Atoi(tagIDstr)
+	if err != nil || tagID <= 0 || req.Into <= 0 || req.Into == tagID {
+		return ResponseTag{
+			Status:    false,
+			ErrorCode: errors.TagBadRequest,
+		}
+	}
+
+	err = t.storage.MergeTags(ctx, tagID, req.Into)
+	if err != nil {
+		t.logger.Error("Error MergeTags:", zap.Error(err))
+		return ResponseTag{
+			Status:    false,
+			ErrorCode: tagErrorCode(err, errors.TagServiceMergeErr),
+		}
+	}
+
+	return ResponseTag{
+		Status: true,
+	}
+}
+
+func (t *TagService) DeleteOrphanTags(ctx context.Context) ResponseDeleteOrphanTags {
+	deleted, err := t.storage.DeleteOrphanTags(ctx)
+	if err != nil {
+		t.logger.Error("Error DeleteOrphanTags:", zap.Error(err))
+		return ResponseDeleteOrphanTags{
+			Status:    false,
+			ErrorCode: errors.TagServiceDeleteOrphansErr,
+		}
+	}
+
+	return ResponseDeleteOrphanTags{
+		Status:  true,
+		Deleted: deleted,
+	}
+}
+
+// tagErrorCode - переводит ошибку хранилища в код ошибки сервиса
+func tagErrorCode(err error, fallback int) int {
+	switch err {
+	case errors.ErrTagNotFound:
+		return errors.TagServiceNotFoundErr
+	case errors.ErrTagExists:
+		return errors.TagServiceExistsErr
+	}
+
+	return fallback
+}
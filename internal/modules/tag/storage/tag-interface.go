@@ -0,0 +1,13 @@
+package storage
+
+import (
+	"context"
+	"pet-store/internal/models"
+)
+
+type Tagger interface {
+	ListTags(ctx context.Context) ([]models.TagWithCount, error)
+	RenameTag(ctx context.Context, tagID int, name string) error
+	MergeTags(ctx context.Context, sourceID, targetID int) error
+	DeleteOrphanTags(ctx context.Context) (int, error)
+}
@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+	"pet-store/internal/db/adapter"
+	"pet-store/internal/models"
+)
+
+// TagStorage - хранилище тегов питомцев
+type TagStorage struct {
+	adapter *adapter.SQLAdapter
+}
+
+// NewTagStorage - конструктор хранилища тегов
+func NewTagStorage(sqlAdapter *adapter.SQLAdapter) *TagStorage {
+	return &TagStorage{adapter: sqlAdapter}
+}
+
+func (t *TagStorage) ListTags(ctx context.Context) ([]models.TagWithCount, error) {
+	return t.adapter.ListTags(ctx)
+}
+
+func (t *TagStorage) RenameTag(ctx context.Context, tagID int, name string) error {
+	return t.adapter.RenameTag(ctx, tagID, name)
+}
+
+func (t *TagStorage) MergeTags(ctx context.Context, sourceID, targetID int) error {
+	return t.adapter.MergeTags(ctx, sourceID, targetID)
+}
+
+func (t *TagStorage) DeleteOrphanTags(ctx context.Context) (int, error) {
+	return t.adapter.DeleteOrphanTags(ctx)
+}
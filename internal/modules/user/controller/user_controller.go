@@ -9,12 +9,15 @@ import (
 	"pet-store/internal/modules/user/service"
 
 	"github.com/go-chi/chi"
+	"github.com/go-playground/validator"
 	"github.com/ptflp/godecoder"
 )
 
 type Userer interface {
 	GetUser(w http.ResponseWriter, r *http.Request)
 	UpdateUser(w http.ResponseWriter, r *http.Request)
+	PatchUser(w http.ResponseWriter, r *http.Request)
+	SetRole(w http.ResponseWriter, r *http.Request)
 }
 
 type User struct {
@@ -29,6 +32,7 @@ func NewUser(service service.Userer, components *component.Components) Userer {
 
 
 // @Summary Get a user by username
+// @Security ApiKeyAuth
 // @Tags user
 // @Description Fetches a user by their username.
 // @ID GetUser
@@ -36,6 +40,9 @@ func NewUser(service service.Userer, components *component.Components) Userer {
 // @Produce  json
 // @Param username path string true "Username of the user"
 // @Success 200 {object} GetUserResponseSuccess "Successfully retrieved user"
+// @Failure 403 {object} UserResponseErr "Access to another user's profile is forbidden"
+// @Failure 404 {object} UserResponseErr "User not found"
+// @Failure 500 {object} UserResponseErr "Error retrieving user"
 // @Router /user/{username} [get]
 func (u *User) GetUser(w http.ResponseWriter, r *http.Request) {
 	username := chi.URLParam(r, "username")
@@ -43,6 +50,16 @@ func (u *User) GetUser(w http.ResponseWriter, r *http.Request) {
 	out := u.service.GetByUsername(r.Context(), username)
 	if out.ErrorCode != errors.NoError {
 		msg := "getuser error"
+		switch out.ErrorCode {
+		case errors.UserServiceForbiddenErr:
+			w.WriteHeader(http.StatusForbidden)
+			msg = "access to another user's profile is forbidden"
+		case errors.UserServiceNotFoundErr:
+			w.WriteHeader(http.StatusNotFound)
+			msg = "user not found"
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
 		u.OutputJSON(w, UserResponseErr{
 			Success:   false,
 			ErrorCode: out.ErrorCode,
@@ -69,7 +86,8 @@ func (u *User) GetUser(w http.ResponseWriter, r *http.Request) {
 // @Summary Update user information
 // @Security ApiKeyAuth
 // @Tags user
-// @Description Updates the information of an existing user identified by username.
+// @Description Updates the information of an existing user identified by username. The password is replaced too,
+// @Description so every token issued to the user so far is revoked and the user has to log in again.
 // @ID UpdateUser
 // @Accept  json
 // @Produce  json
@@ -91,11 +109,16 @@ func (u *User) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	out := u.service.UpdateUser(r.Context(), userdata)
 	if out.ErrorCode != errors.NoError {
 		msg := "updateuser error"
+		if out.ErrorCode == errors.UserServiceForbiddenErr {
+			w.WriteHeader(http.StatusForbidden)
+			msg = "access to another user's profile is forbidden"
+		}
 		u.OutputJSON(w, UserResponseErr{
 			Success:   false,
 			ErrorCode: out.ErrorCode,
 			Data:      msg,
 		})
+		return
 	}
 	message := fmt.Sprintf("%s data has been updated", username)
 	u.OutputJSON(w, UserUpdateResponse{
@@ -103,3 +126,117 @@ func (u *User) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		Message: message,
 	})
 }
+
+// @Summary Partially update user information
+// @Security ApiKeyAuth
+// @Tags user
+// @Description Applies a JSON Merge Patch (RFC 7396) to the user profile. Absent fields stay unchanged,
+// @Description null clears firstname, lastname and phone. Password can be changed but not cleared,
+// @Description changing it revokes every token issued to the user so far.
+// @ID PatchUser
+// @Accept  application/merge-patch+json
+// @Produce  json
+// @Param username path string true "Username of the user to update"
+// @Param user body service.UserPatchRequest true "User fields to change"
+// @Success 200 {object} UserUpdateResponse "Successfully updated user data"
+// @Failure 400 {object} UserResponseErr "Invalid patch"
+// @Failure 403 {object} UserResponseErr "Access to another user's profile is forbidden"
+// @Failure 404 {object} UserResponseErr "User not found"
+// @Failure 415 "Content-Type is not application/merge-patch+json"
+// @Router /user/{username} [patch]
+func (u *User) PatchUser(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+	var patch service.UserPatchRequest
+	err := u.Decode(r.Body, &patch)
+	if err != nil {
+		u.ErrorBadRequest(w, err)
+		return
+	}
+
+	patch.UserName = username
+
+	out := u.service.PatchUser(r.Context(), patch)
+	if out.ErrorCode != errors.NoError {
+		msg := "patchuser error"
+		switch out.ErrorCode {
+		case errors.UserServiceForbiddenErr:
+			w.WriteHeader(http.StatusForbidden)
+			msg = "access to another user's profile is forbidden"
+		case errors.UserPatchBadRequest:
+			w.WriteHeader(http.StatusBadRequest)
+			msg = "password cannot be cleared, firstname and lastname are limited to 20 characters, phone to 55"
+		case errors.UserServiceNotFoundErr:
+			w.WriteHeader(http.StatusNotFound)
+			msg = "user not found"
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		u.OutputJSON(w, UserResponseErr{
+			Success:   false,
+			ErrorCode: out.ErrorCode,
+			Data:      msg,
+		})
+		return
+	}
+
+	u.OutputJSON(w, UserUpdateResponse{
+		Success: true,
+		Message: fmt.Sprintf("%s data has been updated", username),
+	})
+}
+
+// @Summary Set user role
+// @Security ApiKeyAuth
+// @Tags user
+// @Description Changes the role of a user. Available roles: user, admin. Requires the admin role.
+// @ID SetRole
+// @Accept  json
+// @Produce  json
+// @Param username path string true "Username of the user"
+// @Param role body service.SetRoleRequest true "New role"
+// @Success 200 {object} UserUpdateResponse "Successfully updated role"
+// @Failure 400 {object} UserResponseErr "Error"
+// @Failure 404 {object} UserResponseErr "User not found"
+// @Router /user/{username}/role [put]
+func (u *User) SetRole(w http.ResponseWriter, r *http.Request) {
+	var req service.SetRoleRequest
+	err := u.Decode(r.Body, &req)
+	if err != nil {
+		u.ErrorBadRequest(w, err)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		u.ErrorBadRequest(w, err)
+		return
+	}
+
+	req.UserName = chi.URLParam(r, "username")
+
+	out := u.service.SetRole(r.Context(), req)
+	if out.ErrorCode != errors.NoError {
+		msg := "setrole error"
+		switch out.ErrorCode {
+		case errors.UserServiceUnknownRoleErr:
+			w.WriteHeader(http.StatusBadRequest)
+			msg = "unknown role"
+		case errors.UserServiceNotFoundErr:
+			w.WriteHeader(http.StatusNotFound)
+			msg = "user not found"
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		u.OutputJSON(w, UserResponseErr{
+			Success:   false,
+			ErrorCode: out.ErrorCode,
+			Data:      msg,
+		})
+		return
+	}
+
+	u.OutputJSON(w, UserUpdateResponse{
+		Success: true,
+		Message: fmt.Sprintf("%s role has been set to %s", req.UserName, req.Role),
+	})
+}
@@ -2,21 +2,32 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	stderrors "errors"
+	"pet-store/internal/infrastructure/db/types"
 	"pet-store/internal/infrastructure/errors"
 	"pet-store/internal/infrastructure/tools/cryptography"
 	"pet-store/internal/models"
 	"pet-store/internal/modules/user/storage"
+	"time"
+	"unicode/utf8"
 
 	"go.uber.org/zap"
 )
 
+const (
+	maxNameLength  = 20
+	maxPhoneLength = 55
+)
+
 type UserService struct {
 	storage storage.Userer
+	tokens  TokenRevoker
 	logger  *zap.Logger
 }
 
-func NewUserService(storage storage.Userer, logger *zap.Logger) *UserService {
-	return &UserService{storage: storage, logger: logger}
+func NewUserService(storage storage.Userer, tokens TokenRevoker, logger *zap.Logger) *UserService {
+	return &UserService{storage: storage, tokens: tokens, logger: logger}
 }
 
 func (u *UserService) Create(ctx context.Context, in UserCreateIn) UserCreateOut {
@@ -50,20 +61,25 @@ func (u *UserService) GetByEmail(ctx context.Context, in GetByEmailIn) UserOut {
 	}
 
 	return UserOut{
-		User: &models.User{
-			ID:        userDTO.GetID(),
-			Username:  userDTO.GetUserName(),
-			Phone:     userDTO.GetPhone(),
-			Email:     userDTO.GetEmail(),
-			Password:  userDTO.GetPassword(),
-			FirstName: userDTO.GetFirstName(),
-			LastName:  userDTO.GetLastName(),
-		},
+		User: userFromDTO(userDTO),
 	}
 }
 
 func (u *UserService) GetByUsername(ctx context.Context, username string) UserOut {
+	// Читать чужой профиль может только администратор. Доступ проверяется до поиска,
+	// чтобы по ответу нельзя было узнать, есть ли пользователь с таким именем
+	if errorCode := u.checkAccess(ctx, username); errorCode != errors.NoError {
+		return UserOut{
+			ErrorCode: errorCode,
+		}
+	}
+
 	userDTO, err := u.storage.GetByUsername(ctx, username)
+	if stderrors.Is(err, sql.ErrNoRows) {
+		return UserOut{
+			ErrorCode: errors.UserServiceNotFoundErr,
+		}
+	}
 	if err != nil {
 		u.logger.Error("user: GetByUsername err", zap.Error(err))
 		return UserOut{
@@ -72,19 +88,67 @@ func (u *UserService) GetByUsername(ctx context.Context, username string) UserOu
 	}
 
 	return UserOut{
-		User: &models.User{
-			ID:        userDTO.GetID(),
-			Username:  userDTO.GetUserName(),
-			Phone:     userDTO.GetPhone(),
-			Email:     userDTO.GetEmail(),
-			Password:  userDTO.GetPassword(),
-			FirstName: userDTO.GetFirstName(),
-			LastName:  userDTO.GetLastName(),
-		},
+		User: userFromDTO(userDTO),
+	}
+}
+
+func (u *UserService) GetByID(ctx context.Context, id int) UserOut {
+	userDTO, err := u.storage.GetByID(ctx, id)
+	if stderrors.Is(err, sql.ErrNoRows) {
+		return UserOut{
+			ErrorCode: errors.UserServiceNotFoundErr,
+		}
+	}
+	if err != nil {
+		u.logger.Error("user: GetByID err", zap.Error(err))
+		return UserOut{
+			ErrorCode: errors.UserServiceRetrieveUserErr,
+		}
+	}
+
+	return UserOut{
+		User: userFromDTO(userDTO),
+	}
+}
+
+func (u *UserService) SetRole(ctx context.Context, in SetRoleRequest) UpdateUserResponse {
+	role, ok := models.RoleFromName(in.Role)
+	if !ok {
+		return UpdateUserResponse{
+			Success:   false,
+			ErrorCode: errors.UserServiceUnknownRoleErr,
+		}
+	}
+
+	err := u.storage.UpdateRole(ctx, in.UserName, role)
+	if err == errors.ErrUserNotFound {
+		return UpdateUserResponse{
+			Success:   false,
+			ErrorCode: errors.UserServiceNotFoundErr,
+		}
+	}
+	if err != nil {
+		u.logger.Error("user: SetRole err", zap.Error(err))
+		return UpdateUserResponse{
+			Success:   false,
+			ErrorCode: errors.UserServiceUpdateErr,
+		}
+	}
+
+	return UpdateUserResponse{
+		Success: true,
 	}
 }
 
 func (u *UserService) UpdateUser(ctx context.Context, userdata UpdateUserRequest) UpdateUserResponse {
+	// Изменять чужой профиль может только администратор
+	if errorCode := u.checkAccess(ctx, userdata.UserName); errorCode != errors.NoError {
+		return UpdateUserResponse{
+			Success:   false,
+			ErrorCode: errorCode,
+		}
+	}
+
 	hashPass, err := cryptography.HashPassword(userdata.Password)
 	if err != nil {
 		return UpdateUserResponse{
@@ -109,8 +173,142 @@ func (u *UserService) UpdateUser(ctx context.Context, userdata UpdateUserRequest
 			ErrorCode: errors.UserServiceUpdateErr,
 		}
 	}
+	// Профиль заменяется целиком вместе с паролем: токены, выданные по старому паролю, отзываем
+	if errorCode := u.revokeTokens(ctx, userdata.UserName); errorCode != errors.NoError {
+		return UpdateUserResponse{
+			Success:   false,
+			ErrorCode: errorCode,
+		}
+	}
 
 	return UpdateUserResponse{
 		Success: true,
 	}
 }
+
+// PatchUser - частично обновляет профиль по JSON Merge Patch
+func (u *UserService) PatchUser(ctx context.Context, patch UserPatchRequest) UpdateUserResponse {
+	// Изменять чужой профиль может только администратор
+	if errorCode := u.checkAccess(ctx, patch.UserName); errorCode != errors.NoError {
+		return UpdateUserResponse{
+			Success:   false,
+			ErrorCode: errorCode,
+		}
+	}
+
+	// Значения не должны превышать размер колонок: имя и фамилия до 20 символов, телефон до 55
+	tooLong := utf8.RuneCountInString(patch.FirstName.String) > maxNameLength ||
+		utf8.RuneCountInString(patch.LastName.String) > maxNameLength ||
+		utf8.RuneCountInString(patch.Phone.String) > maxPhoneLength
+	if tooLong {
+		return UpdateUserResponse{
+			Success:   false,
+			ErrorCode: errors.UserPatchBadRequest,
+		}
+	}
+
+	userPatch := models.UserPatch{
+		UserName:  patch.UserName,
+		FirstName: patch.FirstName,
+		LastName:  patch.LastName,
+		Phone:     patch.Phone,
+	}
+	if patch.Password.Set {
+		if !patch.Password.Valid {
+			return UpdateUserResponse{
+				Success:   false,
+				ErrorCode: errors.UserPatchBadRequest,
+			}
+		}
+		hashPass, err := cryptography.HashPassword(patch.Password.String)
+		if err != nil {
+			return UpdateUserResponse{
+				Success:   false,
+				ErrorCode: errors.HashPasswordError,
+			}
+		}
+		userPatch.Password = types.PatchString{NullString: types.NewNullString(hashPass), Set: true}
+	}
+
+	err := u.storage.PatchUser(ctx, userPatch)
+	if err == errors.ErrUserNotFound {
+		return UpdateUserResponse{
+			Success:   false,
+			ErrorCode: errors.UserServiceNotFoundErr,
+		}
+	}
+	if err != nil {
+		u.logger.Error("user: PatchUser err", zap.Error(err))
+		return UpdateUserResponse{
+			Success:   false,
+			ErrorCode: errors.UserServiceUpdateErr,
+		}
+	}
+	// Смена пароля отзывает токены, выданные по старому паролю
+	if userPatch.Password.Set {
+		if errorCode := u.revokeTokens(ctx, patch.UserName); errorCode != errors.NoError {
+			return UpdateUserResponse{
+				Success:   false,
+				ErrorCode: errorCode,
+			}
+		}
+	}
+
+	return UpdateUserResponse{
+		Success: true,
+	}
+}
+
+// revokeTokens - отзывает все выпущенные до этого момента токены пользователя
+func (u *UserService) revokeTokens(ctx context.Context, username string) int {
+	userDTO, err := u.storage.GetByUsername(ctx, username)
+	if err != nil {
+		u.logger.Error("user: revokeTokens err", zap.Error(err))
+		return errors.UserServiceUpdateErr
+	}
+	if err := u.tokens.RevokeUser(ctx, userDTO.GetID(), time.Now()); err != nil {
+		u.logger.Error("user: revokeTokens err", zap.Error(err))
+		return errors.UserServiceUpdateErr
+	}
+
+	return errors.NoError
+}
+
+// checkAccess - проверяет, что вызывающий пользователь - владелец профиля или администратор
+func (u *UserService) checkAccess(ctx context.Context, username string) int {
+	caller, ok := cryptography.UserFromContext(ctx)
+	if !ok {
+		return errors.UserServiceForbiddenErr
+	}
+	if caller.IsAdmin() {
+		return errors.NoError
+	}
+
+	// Сравниваем имя из запроса с именем вызывающего, не ища чужого пользователя
+	self, err := u.storage.GetByID(ctx, caller.ID)
+	if stderrors.Is(err, sql.ErrNoRows) {
+		return errors.UserServiceForbiddenErr
+	}
+	if err != nil {
+		u.logger.Error("user: checkAccess err", zap.Error(err))
+		return errors.UserServiceRetrieveUserErr
+	}
+	if self.GetUserName() != username {
+		return errors.UserServiceForbiddenErr
+	}
+
+	return errors.NoError
+}
+
+func userFromDTO(userDTO models.UserDTO) *models.User {
+	return &models.User{
+		ID:         userDTO.GetID(),
+		Username:   userDTO.GetUserName(),
+		Phone:      userDTO.GetPhone(),
+		Email:      userDTO.GetEmail(),
+		Password:   userDTO.GetPassword(),
+		FirstName:  userDTO.GetFirstName(),
+		LastName:   userDTO.GetLastName(),
+		UserStatus: userDTO.GetStatus(),
+	}
+}
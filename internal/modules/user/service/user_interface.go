@@ -2,14 +2,24 @@ package service
 
 import (
 	"context"
+	"pet-store/internal/infrastructure/db/types"
 	"pet-store/internal/models"
+	"time"
 )
 
 type Userer interface {
 	Create(ctx context.Context, in UserCreateIn) UserCreateOut
 	GetByEmail(ctx context.Context, in GetByEmailIn) UserOut
 	GetByUsername(ctx context.Context, username string) UserOut
+	GetByID(ctx context.Context, id int) UserOut
 	UpdateUser(ctx context.Context, userdata UpdateUserRequest) UpdateUserResponse
+	PatchUser(ctx context.Context, patch UserPatchRequest) UpdateUserResponse
+	SetRole(ctx context.Context, in SetRoleRequest) UpdateUserResponse
+}
+
+// TokenRevoker - отзыв всех токенов пользователя, выпущенных раньше at
+type TokenRevoker interface {
+	RevokeUser(ctx context.Context, userID int, at time.Time) error
 }
 
 type UpdateUserRequest struct {
@@ -20,6 +30,21 @@ type UpdateUserRequest struct {
 	Phone     string `json:"phone"`
 }
 
+// UserPatchRequest - изменения профиля в формате JSON Merge Patch (RFC 7396): отсутствующие поля
+// не меняются, null очищает имя, фамилию и телефон. Пароль очистить нельзя, новый пароль хешируется
+type UserPatchRequest struct {
+	UserName  string            `json:"-"`
+	FirstName types.PatchString `json:"firstname"`
+	LastName  types.PatchString `json:"lastname"`
+	Phone     types.PatchString `json:"phone"`
+	Password  types.PatchString `json:"password"`
+}
+
+type SetRoleRequest struct {
+	UserName string `json:"-"`
+	Role     string `json:"role" validate:"required"`
+}
+
 type UserCreateIn struct {
 	UserName  string `json:"username"`
 	FirstName string `json:"firstname"`
@@ -43,10 +43,28 @@ func (s *UserStorage) GetByUsername(ctx context.Context, username string) (model
 	return user, nil
 }
 
+func (s *UserStorage) GetByID(ctx context.Context, id int) (models.UserDTO, error) {
+	var user models.UserDTO
+	err := s.adapter.GetUserByID(ctx, &user, id)
+	if err != nil {
+		return models.UserDTO{}, err
+	}
+
+	return user, nil
+}
+
+func (s *UserStorage) UpdateRole(ctx context.Context, username string, role int) error {
+	return s.adapter.UpdateUserRole(ctx, username, role)
+}
+
 func (s *UserStorage) UpdateUser(ctx context.Context,  userdata models.UserDTO) error {
 	err:= s.adapter.UpdateUser(ctx, &userdata)
 	if err != nil{
 		return  err
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+func (s *UserStorage) PatchUser(ctx context.Context, patch models.UserPatch) error {
+	return s.adapter.PatchUser(ctx, patch)
+}
@@ -9,5 +9,8 @@ type Userer interface {
 	Create(ctx context.Context, u models.UserDTO) (int, error)
 	GetByEmail(ctx context.Context, email string) (models.UserDTO, error)
 	GetByUsername(ctx context.Context, username string) (models.UserDTO, error)
+	GetByID(ctx context.Context, id int) (models.UserDTO, error)
 	UpdateUser(ctx context.Context, u models.UserDTO) error
+	PatchUser(ctx context.Context, patch models.UserPatch) error
+	UpdateRole(ctx context.Context, username string, role int) error
 }
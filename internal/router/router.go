@@ -4,6 +4,8 @@ import (
 	"net/http"
 	_ "pet-store/docs"
 	"pet-store/internal/infrastructure/component"
+	mymiddleware "pet-store/internal/middleware"
+	"pet-store/internal/models"
 	"pet-store/internal/modules"
 
 	"github.com/go-chi/chi"
@@ -12,48 +14,92 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-func NewApiRouter(controllers *modules.Controllers, components *component.Components) http.Handler {
+// mergePatchJSON - тип содержимого JSON Merge Patch (RFC 7396), единственный, который принимают PATCH-запросы
+const mergePatchJSON = "application/merge-patch+json"
+
+func NewApiRouter(controllers *modules.Controllers, components *component.Components, token *mymiddleware.Token) http.Handler {
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
 	// Swagger route
 	r.Get("/swagger/*", httpSwagger.WrapHandler)
 
+	// Цепочка проверки токена доступа
+	authenticated := chi.Chain(
+		jwtauth.Verifier(components.TokenManager.GetAccessSecret()),
+		jwtauth.Authenticator,
+		token.CheckRevoked,
+		token.ExtractUser,
+	)
+
 	// Auth routes
 	r.Route("/user", func(r chi.Router) {
 		authController := controllers.Auth
 		userController := controllers.User
 		r.Post("/", authController.CreateUser)
 		r.Get("/login", authController.Login)
-		//r.Post("/logout", authController.Logout)
+		r.Post("/refresh", authController.Refresh)
+		r.With(authenticated...).Post("/logout", authController.Logout)
 		//r.Post("/createWithList", authController.CreateWithList)
-		r.Get("/{username}", userController.GetUser)
-		r.Put("/{username}", userController.UpdateUser)
+		r.With(authenticated...).Get("/{username}", userController.GetUser)
+		r.With(authenticated...).Put("/{username}", userController.UpdateUser)
+		r.With(authenticated...).With(middleware.AllowContentType(mergePatchJSON)).
+			Patch("/{username}", userController.PatchUser)
+		r.With(authenticated...).With(token.RequireRole(models.RoleNameAdmin)).
+			Put("/{username}/role", userController.SetRole)
+		r.With(authenticated...).With(token.RequireRole(models.RoleNameAdmin)).
+			Post("/{username}/revoke", authController.RevokeTokens)
 		//r.Delete("/{username}", c.deleteUser)
 		//r.Post("/createWithArray", c.createWithArray)
 	})
 	r.Group(func(r chi.Router) {
 		r.Route("/pet", func(r chi.Router) {
-			r.Use(jwtauth.Verifier(components.TokenManager.GetAccessSecret()))
-			r.Use(jwtauth.Authenticator)
+			r.Use(authenticated...)
 			petController := controllers.Pet
-			//	r.Post("/{petId}/uploadImage", c.uploadImage)
-			r.Post("/", petController.AddPet)
-			r.Put("/", petController.UpdatePet)
+			r.With(token.RequireRole(models.RoleNameAdmin)).Post("/", petController.AddPet)
+			r.With(token.RequireRole(models.RoleNameAdmin)).Put("/", petController.UpdatePet)
 			r.Get("/findByStatus", petController.FindPetbyStatus)
+			r.Get("/findByTags", petController.FindPetbyTags)
+			r.Get("/search", petController.SearchPets)
 			r.Get("/{petId}", petController.FindPetbyID)
-			r.Post("/{petId}", petController.UpdatePetForm)
-			//r.Delete("/{petId}", petController.DeletePet)
+			r.With(token.RequireRole(models.RoleNameAdmin)).Post("/{petId}", petController.UpdatePetForm)
+			r.With(token.RequireRole(models.RoleNameAdmin), middleware.AllowContentType(mergePatchJSON)).
+				Patch("/{petId}", petController.PatchPet)
+			r.With(token.RequireRole(models.RoleNameAdmin)).Delete("/{petId}", petController.DeletePet)
+			r.With(token.RequireRole(models.RoleNameAdmin)).Post("/{petId}/restore", petController.RestorePet)
+			r.With(token.RequireRole(models.RoleNameAdmin)).Post("/purge", petController.PurgeDeletedPets)
+			r.With(token.RequireRole(models.RoleNameAdmin)).Post("/{petId}/uploadImage", petController.UploadImage)
+			r.Get("/{petId}/images/{name}", petController.GetImage)
 		})
 	})
+	r.Route("/category", func(r chi.Router) {
+		r.Use(authenticated...)
+		categoryController := controllers.Category
+		r.Get("/", categoryController.ListCategories)
+		r.With(token.RequireRole(models.RoleNameAdmin)).Post("/", categoryController.CreateCategory)
+		r.With(token.RequireRole(models.RoleNameAdmin)).Put("/{categoryId}", categoryController.RenameCategory)
+		r.With(token.RequireRole(models.RoleNameAdmin)).Delete("/{categoryId}", categoryController.DeleteCategory)
+	})
+	r.Route("/tag", func(r chi.Router) {
+		r.Use(authenticated...)
+		tagController := controllers.Tag
+		r.Get("/", tagController.ListTags)
+		r.With(token.RequireRole(models.RoleNameAdmin)).Delete("/orphans", tagController.DeleteOrphanTags)
+		r.With(token.RequireRole(models.RoleNameAdmin)).Put("/{tagId}", tagController.RenameTag)
+		r.With(token.RequireRole(models.RoleNameAdmin)).Post("/{tagId}/merge", tagController.MergeTags)
+	})
 	r.Route("/store", func(r chi.Router) {
 		r.Route("/order", func(r chi.Router) {
+			r.Use(authenticated...)
 			orderController := controllers.Order
 			r.Post("/", orderController.CreateOrder)
+			r.Get("/", orderController.FindOrders)
+			r.With(token.RequireRole(models.RoleNameAdmin)).Get("/user/{userId}", orderController.FindUserOrders)
 			r.Get("/{orderId}", orderController.FindOrderByID)
-			r.Delete("/{orderId}", orderController.DeleteOrderByID)
+			r.Put("/{orderId}/status", orderController.ChangeOrderStatus)
+			r.Get("/{orderId}/history", orderController.FindOrderHistory)
+			r.With(token.RequireRole(models.RoleNameAdmin)).Delete("/{orderId}", orderController.DeleteOrderByID)
 		})
-		//r.With(jwtauth.Verifier(components.TokenManager.GetAccessSecret()), jwtauth.Authenticator).
-		//	Get("/inventory", orderController.Inventory)
+		r.With(authenticated...).Get("/inventory", controllers.Order.Inventory)
 	})
 
 	return r
@@ -1,22 +1,56 @@
 package storages
 
 import (
+	"pet-store/internal/db"
 	"pet-store/internal/db/adapter"
+	"pet-store/internal/db/memory"
+	"pet-store/internal/infrastructure/blobstore"
+	astorage "pet-store/internal/modules/auth/storage"
+	cstorage "pet-store/internal/modules/category/storage"
 	ostorage "pet-store/internal/modules/order/storage"
 	petstorage "pet-store/internal/modules/pet/storage"
+	tstorage "pet-store/internal/modules/tag/storage"
 	ustorage "pet-store/internal/modules/user/storage"
 )
 
 type Storages struct {
-	User  ustorage.Userer
-	Pet   petstorage.Peter
-	Order ostorage.Orderer
+	User     ustorage.Userer
+	Pet      petstorage.Peter
+	Order    ostorage.Orderer
+	Token    astorage.Revoker
+	Blob     blobstore.Storer
+	Category cstorage.Categorer
+	Tag      tstorage.Tagger
 }
 
-func NewStorages(sqlAdapter *adapter.SQLAdapter) *Storages {
+// NewStorages - хранилища поверх sqlAdapter, а для драйвера memory - в памяти процесса
+func NewStorages(driver string, sqlAdapter *adapter.SQLAdapter, blobs blobstore.Storer) *Storages {
+	if driver == db.DriverMemory {
+		return NewMemoryStorages(blobs)
+	}
+
+	return &Storages{
+		User:     ustorage.NewUserStorage(sqlAdapter),
+		Pet:      petstorage.NewPetStorage(sqlAdapter),
+		Order:    ostorage.NewOrderStorage(sqlAdapter),
+		Token:    astorage.NewRevokeStorage(sqlAdapter),
+		Blob:     blobs,
+		Category: cstorage.NewCategoryStorage(sqlAdapter),
+		Tag:      tstorage.NewTagStorage(sqlAdapter),
+	}
+}
+
+// NewMemoryStorages - хранилища в памяти процесса: данные живут до перезапуска и общие для всех модулей
+func NewMemoryStorages(blobs blobstore.Storer) *Storages {
+	store := memory.NewStore()
+
 	return &Storages{
-		User:  ustorage.NewUserStorage(sqlAdapter),
-		Pet:   petstorage.NewPetStorage(sqlAdapter),
-		Order: ostorage.NewOrderStorage(sqlAdapter),
+		User:     store,
+		Pet:      store,
+		Order:    store,
+		Token:    astorage.NewMemoryRevokeStorage(),
+		Blob:     blobs,
+		Category: store,
+		Tag:      store,
 	}
 }
@@ -0,0 +1,529 @@
+// Package storagetest - общий набор тестов, который должна проходить любая реализация хранилищ
+// питомцев, заказов и пользователей, чтобы SQL-адаптер и другие бэкенды вели себя одинаково
+package storagetest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"pet-store/internal/infrastructure/db/types"
+	myerrors "pet-store/internal/infrastructure/errors"
+	"pet-store/internal/models"
+	"pet-store/internal/storages"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory - возвращает пустые хранилища проверяемого бэкенда, новые для каждого теста
+type Factory func(t *testing.T) *storages.Storages
+
+// Run - прогоняет весь набор тестов для бэкенда
+func Run(t *testing.T, newStorages Factory) {
+	t.Run("Pets", func(t *testing.T) { testPets(t, newStorages(t)) })
+	t.Run("Search", func(t *testing.T) { testSearch(t, newStorages(t)) })
+	t.Run("Tags", func(t *testing.T) { testTags(t, newStorages(t)) })
+	t.Run("Orders", func(t *testing.T) { testOrders(t, newStorages(t)) })
+	t.Run("Users", func(t *testing.T) { testUsers(t, newStorages(t)) })
+	t.Run("ConcurrentWrites", func(t *testing.T) { testConcurrentWrites(t, newStorages(t)) })
+}
+
+func testPets(t *testing.T, s *storages.Storages) {
+	ctx := context.Background()
+	categoryID := createCategory(t, s, "Dogs")
+
+	err := s.Pet.AddPet(ctx, &models.Pet{Name: "Rex", Category: models.Category{Name: "Cats"}, Status: models.PetStatusAvailable})
+	assert.ErrorIs(t, err, myerrors.ErrCategoryNotFound, "unknown category")
+
+	pet := &models.Pet{
+		Name:        "Rex",
+		Category:    models.Category{Name: "Dogs"},
+		Status:      models.PetStatusAvailable,
+		PhotoUrls:   []string{"https://example.com/1.jpg", "https://example.com/2.jpg"},
+		Tags:        []models.Tag{{Name: "friendly"}, {Name: "small"}, {Name: "friendly"}},
+		Breed:       "beagle",
+		BirthDate:   "2020-05-17",
+		Weight:      12.5,
+		MicrochipID: "900000000000001",
+	}
+	require.NoError(t, s.Pet.AddPet(ctx, pet))
+	require.NotZero(t, pet.ID)
+
+	found, err := s.Pet.FindPetbyID(ctx, pet.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Rex", found.Name)
+	assert.Equal(t, models.Category{ID: categoryID, Name: "Dogs"}, found.Category)
+	assert.Equal(t, models.PetStatusAvailable, found.Status)
+	assert.Equal(t, pet.PhotoUrls, found.PhotoUrls)
+	assert.Equal(t, []string{"friendly", "small"}, tagNames(found.Tags), "repeated tags are linked once")
+	assert.Equal(t, "beagle", found.Breed)
+	assert.Equal(t, "2020-05-17", found.BirthDate)
+	assert.Equal(t, 12.5, found.Weight)
+	assert.Equal(t, 1, found.Version)
+
+	_, err = s.Pet.FindPetbyID(ctx, pet.ID+1000)
+	assert.ErrorIs(t, err, myerrors.ErrPetNotFound)
+
+	other := &models.Pet{Name: "Max", Category: models.Category{Name: "Dogs"}, Status: models.PetStatusAvailable, MicrochipID: "900000000000001"}
+	assert.ErrorIs(t, s.Pet.AddPet(ctx, other), myerrors.ErrMicrochipExists)
+
+	// Пустые поля UpdatePet не меняются, версия растёт и проверяется
+	update := &models.Pet{ID: pet.ID, Name: "Rex II", Version: 1}
+	require.NoError(t, s.Pet.UpdatePet(ctx, update))
+	assert.Equal(t, 2, update.Version)
+	stale := &models.Pet{ID: pet.ID, Name: "Rex III", Version: 1}
+	assert.ErrorIs(t, s.Pet.UpdatePet(ctx, stale), myerrors.ErrPetVersion)
+	missing := &models.Pet{ID: pet.ID + 1000, Name: "Ghost"}
+	assert.ErrorIs(t, s.Pet.UpdatePet(ctx, missing), myerrors.ErrPetNotFound)
+
+	patch := &models.PetPatch{
+		ID:      pet.ID,
+		Version: 2,
+		Status:  types.PatchString{NullString: types.NewNullString(models.PetStatusSold), Set: true},
+		Breed:   types.PatchString{Set: true},
+	}
+	require.NoError(t, s.Pet.PatchPet(ctx, patch))
+	assert.Equal(t, 3, patch.Version)
+
+	found, err = s.Pet.FindPetbyID(ctx, pet.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Rex II", found.Name)
+	assert.Equal(t, models.PetStatusSold, found.Status)
+	assert.Empty(t, found.Breed, "null in a patch clears the field")
+	assert.Equal(t, "2020-05-17", found.BirthDate)
+	assert.Equal(t, []string{"friendly", "small"}, tagNames(found.Tags))
+
+	version, err := s.Pet.UpdatePetForm(ctx, "Rex", models.PetStatusAvailable, pet.ID, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 4, version)
+
+	byTags, err := s.Pet.FindPetbyTags(ctx, []string{"small", "unknown"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []int{pet.ID}, petIDs(byTags))
+	byTags, err = s.Pet.FindPetbyTags(ctx, []string{"small", "unknown"}, true)
+	require.NoError(t, err)
+	assert.Empty(t, byTags)
+
+	// Удалённый питомец не находится, пока его не восстановят
+	require.NoError(t, s.Pet.DeletePet(ctx, pet.ID))
+	_, err = s.Pet.FindPetbyID(ctx, pet.ID)
+	assert.ErrorIs(t, err, myerrors.ErrPetNotFound)
+	require.NoError(t, s.Pet.RestorePet(ctx, pet.ID))
+	found, err = s.Pet.FindPetbyID(ctx, pet.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 6, found.Version, "deletion and restoration change the version")
+
+	// Очистка удаляет только удалённых питомцев и возвращает ключи их изображений
+	gone := &models.Pet{Name: "Bim", Category: models.Category{Name: "Dogs"}, Status: models.PetStatusAvailable}
+	require.NoError(t, s.Pet.AddPet(ctx, gone))
+	image := &models.PetImage{PetID: gone.ID, Name: "bim.jpg", StorageKey: "pets/bim.jpg", URL: "/pet/bim.jpg"}
+	require.NoError(t, s.Pet.AddPetImage(ctx, image))
+	require.NoError(t, s.Pet.DeletePet(ctx, gone.ID))
+	purged, keys, err := s.Pet.PurgeDeletedPets(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Equal(t, []string{"pets/bim.jpg"}, keys)
+	assert.ErrorIs(t, s.Pet.RestorePet(ctx, gone.ID), myerrors.ErrPetNotFound)
+	_, err = s.Pet.FindPetbyID(ctx, pet.ID)
+	assert.NoError(t, err)
+}
+
+func testSearch(t *testing.T, s *storages.Storages) {
+	ctx := context.Background()
+	createCategory(t, s, "Dogs")
+	createCategory(t, s, "Cats")
+
+	rex := addPet(t, s, "Rex", "fluffy")
+	fluffy := &models.Pet{Name: "Fluffy", Category: models.Category{Name: "Cats"}, Status: models.PetStatusAvailable}
+	require.NoError(t, s.Pet.AddPet(ctx, fluffy))
+	tom := &models.Pet{Name: "Tom", Category: models.Category{Name: "Cats"}, Status: models.PetStatusSold}
+	require.NoError(t, s.Pet.AddPet(ctx, tom))
+
+	search := func(query string) []models.Pet {
+		pets, err := s.Pet.SearchPets(ctx, models.PetFilter{Query: query}, models.PetPage{Sort: models.PetSortRank, Desc: true, Limit: 10})
+		require.NoError(t, err)
+		return pets
+	}
+
+	// Совпадение в имени весит больше, чем в тегах
+	pets := search("FLUFFY")
+	assert.Equal(t, []int{fluffy.ID, rex}, petIDs(pets))
+	assert.Greater(t, pets[0].Rank, pets[1].Rank)
+	assert.Contains(t, pets[0].Highlight, "<mark>Fluffy</mark>")
+	assert.Contains(t, pets[1].Highlight, "<mark>fluffy</mark>")
+
+	assert.Equal(t, []int{fluffy.ID}, petIDs(search("cats -tom")))
+	assert.ElementsMatch(t, []int{rex, fluffy.ID, tom.ID}, petIDs(search(`"fluffy" or tom`)))
+	assert.Empty(t, search("parrot"))
+
+	// Переименование категории попадает в документ поиска
+	categories, err := s.Category.ListCategories(ctx)
+	require.NoError(t, err)
+	require.NoError(t, s.Category.RenameCategory(ctx, categories[0].ID, "Felines"))
+	assert.ErrorIs(t, s.Category.RenameCategory(ctx, categories[1].ID, "Felines"), myerrors.ErrCategoryExists)
+	assert.ElementsMatch(t, []int{fluffy.ID, tom.ID}, petIDs(search("felines")))
+}
+
+func testTags(t *testing.T, s *storages.Storages) {
+	ctx := context.Background()
+	createCategory(t, s, "Dogs")
+
+	first := addPet(t, s, "Rex", "good", "kind")
+	second := addPet(t, s, "Max", "kind")
+	third := addPet(t, s, "Bim", "old")
+
+	tags := tagIDs(t, s)
+	assert.ErrorIs(t, s.Tag.RenameTag(ctx, tags["good"], "kind"), myerrors.ErrTagExists)
+	assert.ErrorIs(t, s.Tag.RenameTag(ctx, tags["good"]+1000, "nice"), myerrors.ErrTagNotFound)
+	assert.NoError(t, s.Tag.RenameTag(ctx, tags["good"], "good"), "renaming to the same name is not a miss")
+	assert.ErrorIs(t, s.Tag.MergeTags(ctx, tags["good"], tags["good"]+1000), myerrors.ErrTagNotFound)
+
+	// Питомец с обоими тегами после слияния остаётся с одним
+	require.NoError(t, s.Tag.MergeTags(ctx, tags["good"], tags["kind"]))
+	// Переименование и слияние тега меняют версию только его питомцев
+	for id, version := range map[int]int{first: 3, second: 1} {
+		pet, err := s.Pet.FindPetbyID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"kind"}, tagNames(pet.Tags))
+		assert.Equal(t, version, pet.Version)
+	}
+
+	list, err := s.Tag.ListTags(ctx)
+	require.NoError(t, err)
+	counts := make(map[string]int)
+	for _, tag := range list {
+		counts[tag.Name] = tag.PetCount
+	}
+	assert.Equal(t, map[string]int{"kind": 2, "old": 1}, counts)
+
+	// Теги заменяются целиком, старый тег остаётся без питомцев
+	update := &models.Pet{ID: third, Tags: []models.Tag{{Name: "kind"}}}
+	require.NoError(t, s.Pet.UpdatePet(ctx, update))
+	deleted, err := s.Tag.DeleteOrphanTags(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.NotContains(t, tagIDs(t, s), "old")
+}
+
+func testOrders(t *testing.T, s *storages.Storages) {
+	ctx := context.Background()
+	createCategory(t, s, "Dogs")
+	userID := createUser(t, s, "buyer")
+	petID := addPet(t, s, "Rex")
+
+	shipDate := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	order := models.Order{PetID: petID, UserID: userID, Quantity: 1, ShipDate: shipDate, Status: "placed"}
+	orderID, err := s.Order.CreateOrder(ctx, order)
+	require.NoError(t, err)
+
+	found, err := s.Order.FindOrderByID(ctx, orderID)
+	require.NoError(t, err)
+	assert.Equal(t, petID, found.PetID)
+	assert.Equal(t, userID, found.UserID)
+	assert.Equal(t, 1, found.Quantity)
+	assert.True(t, shipDate.Equal(found.ShipDate), "ship date %v", found.ShipDate)
+	assert.Equal(t, "placed", found.Status)
+	assert.False(t, found.Complete)
+
+	// Заказ резервирует питомца, второй заказ на него невозможен
+	pet, err := s.Pet.FindPetbyID(ctx, petID)
+	require.NoError(t, err)
+	assert.Equal(t, models.PetStatusPending, pet.Status)
+	assert.Equal(t, 2, pet.Version)
+	_, err = s.Order.CreateOrder(ctx, order)
+	assert.ErrorIs(t, err, myerrors.ErrPetNotAvailable)
+	order.PetID = petID + 1000
+	_, err = s.Order.CreateOrder(ctx, order)
+	assert.ErrorIs(t, err, myerrors.ErrPetNotFound)
+
+	inventory, err := s.Order.Inventory(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{models.PetStatusPending: 1}, inventory)
+	byCategory, err := s.Order.InventoryByCategory(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]int{"Dogs": {models.PetStatusPending: 1}}, byCategory)
+
+	assert.ErrorIs(t, s.Order.UpdateOrderStatus(ctx, orderID, "approved", "delivered", true, userID, ""), myerrors.ErrOrderStatusStale)
+	require.NoError(t, s.Order.UpdateOrderStatus(ctx, orderID, "placed", "approved", false, userID, ""))
+	require.NoError(t, s.Order.UpdateOrderStatus(ctx, orderID, "approved", "delivered", true, userID, models.PetStatusSold))
+
+	pet, err = s.Pet.FindPetbyID(ctx, petID)
+	require.NoError(t, err)
+	assert.Equal(t, models.PetStatusSold, pet.Status)
+	assert.Equal(t, 3, pet.Version)
+
+	history, err := s.Order.FindOrderHistory(ctx, orderID)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	transitions := make([]string, len(history))
+	for i, change := range history {
+		transitions[i] = change.FromStatus + ">" + change.ToStatus
+		assert.Equal(t, userID, change.ChangedBy)
+	}
+	assert.Equal(t, []string{">placed", "placed>approved", "approved>delivered"}, transitions)
+
+	orders, err := s.Order.FindOrders(ctx, models.OrderFilter{UserID: userID, Statuses: []string{"delivered"}, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.True(t, orders[0].Complete)
+	orders, err = s.Order.FindOrders(ctx, models.OrderFilter{UserID: userID, ShipDateFrom: shipDate.Add(time.Hour), Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, orders)
+
+	// Заказ удаляется вместе с историей
+	require.NoError(t, s.Order.DeleteOrderByID(ctx, orderID))
+	_, err = s.Order.FindOrderByID(ctx, orderID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	history, err = s.Order.FindOrderHistory(ctx, orderID)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+	assert.ErrorIs(t, s.Order.DeleteOrderByID(ctx, orderID), myerrors.ErrOrderNotFound)
+
+	// Удаление незавершённого заказа возвращает питомца в продажу
+	other := addPet(t, s, "Max")
+	placedID, err := s.Order.CreateOrder(ctx, models.Order{PetID: other, UserID: userID, Quantity: 1, Status: models.OrderStatusPlaced})
+	require.NoError(t, err)
+	require.NoError(t, s.Order.DeleteOrderByID(ctx, placedID))
+	pet, err = s.Pet.FindPetbyID(ctx, other)
+	require.NoError(t, err)
+	assert.Equal(t, models.PetStatusAvailable, pet.Status)
+	assert.Equal(t, 3, pet.Version)
+	_, err = s.Order.CreateOrder(ctx, models.Order{PetID: other, UserID: userID, Quantity: 1, Status: models.OrderStatusPlaced})
+	assert.NoError(t, err, "released pet can be ordered again")
+}
+
+func testUsers(t *testing.T, s *storages.Storages) {
+	ctx := context.Background()
+	userID := createUser(t, s, "alice")
+
+	_, err := s.User.Create(ctx, newUser("alice"))
+	assert.EqualError(t, err, "user with username alice already exists")
+	duplicate := newUser("alice2")
+	duplicate.Email = types.NewNullString("alice@example.com")
+	_, err = s.User.Create(ctx, duplicate)
+	assert.Error(t, err, "email is unique")
+
+	for _, get := range []func() (models.UserDTO, error){
+		func() (models.UserDTO, error) { return s.User.GetByID(ctx, userID) },
+		func() (models.UserDTO, error) { return s.User.GetByUsername(ctx, "alice") },
+		func() (models.UserDTO, error) { return s.User.GetByEmail(ctx, "alice@example.com") },
+	} {
+		user, err := get()
+		require.NoError(t, err)
+		assert.Equal(t, userID, user.ID)
+		assert.Equal(t, "alice", user.UserName.String)
+		assert.Equal(t, "hash", user.Password.String)
+		assert.Equal(t, 0, user.Status)
+	}
+	_, err = s.User.GetByID(ctx, userID+1000)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = s.User.GetByUsername(ctx, "bob")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = s.User.GetByEmail(ctx, "bob@example.com")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	update := newUser("alice")
+	update.FirstName = types.NewNullString("Alice")
+	update.Phone = types.NewNullString("+100")
+	require.NoError(t, s.User.UpdateUser(ctx, update))
+	require.NoError(t, s.User.PatchUser(ctx, models.UserPatch{UserName: "alice", Phone: types.PatchString{Set: true}}))
+	require.NoError(t, s.User.UpdateRole(ctx, "alice", 1))
+
+	user, err := s.User.GetByUsername(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", user.FirstName.String)
+	assert.False(t, user.Phone.Valid, "null in a patch clears the field")
+	assert.Equal(t, 1, user.Status)
+
+	// Изменение без новых значений находит пользователя, а не сообщает, что его нет
+	assert.NoError(t, s.User.UpdateUser(ctx, update))
+	assert.NoError(t, s.User.PatchUser(ctx, models.UserPatch{UserName: "alice"}))
+	assert.NoError(t, s.User.UpdateRole(ctx, "alice", 1))
+	assert.NoError(t, s.Category.RenameCategory(ctx, createCategory(t, s, "Dogs"), "Dogs"))
+
+	assert.Error(t, s.User.UpdateUser(ctx, newUser("bob")))
+	assert.ErrorIs(t, s.User.PatchUser(ctx, models.UserPatch{UserName: "bob"}), myerrors.ErrUserNotFound)
+	assert.ErrorIs(t, s.User.UpdateRole(ctx, "bob", 1), myerrors.ErrUserNotFound)
+
+	// Отзыв всех токенов пользователя действует на выпущенные раньше и не трогает более новые
+	revokedAt := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, s.Token.RevokeUser(ctx, userID, revokedAt))
+	require.NoError(t, s.Token.RevokeUser(ctx, userID, revokedAt.Add(-time.Hour)), "earlier cut-off is ignored")
+	for issuedAt, want := range map[time.Time]bool{
+		revokedAt.Add(-time.Second): true,
+		revokedAt:                   false,
+		revokedAt.Add(time.Second):  false,
+	} {
+		revoked, err := s.Token.IsRevoked(ctx, "alice-jti", userID, issuedAt)
+		require.NoError(t, err)
+		assert.Equal(t, want, revoked, "issued at %v", issuedAt)
+	}
+	revoked, err := s.Token.IsRevoked(ctx, "alice-jti", userID+1000, revokedAt.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.False(t, revoked, "other users keep their tokens")
+}
+
+func testConcurrentWrites(t *testing.T, s *storages.Storages) {
+	ctx := context.Background()
+	createCategory(t, s, "Dogs")
+	const workers = 10
+
+	// Одновременно созданные питомцы делят один тег и получают разные id
+	ids := make([]int, workers)
+	parallel(workers, func(i int) {
+		pet := &models.Pet{
+			Name:     fmt.Sprintf("Pet %d", i),
+			Category: models.Category{Name: "Dogs"},
+			Status:   models.PetStatusAvailable,
+			Tags:     []models.Tag{{Name: "shared"}},
+		}
+		if assert.NoError(t, s.Pet.AddPet(ctx, pet)) {
+			ids[i] = pet.ID
+		}
+	})
+	unique := make(map[int]bool)
+	for _, id := range ids {
+		unique[id] = true
+	}
+	assert.Len(t, unique, workers)
+	list, err := s.Tag.ListTags(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, workers, list[0].PetCount)
+
+	// Из одновременных изменений одной версии питомца проходит ровно одно
+	petID := ids[0]
+	var updated, conflicts int
+	var mu sync.Mutex
+	parallel(workers, func(i int) {
+		_, err := s.Pet.UpdatePetForm(ctx, fmt.Sprintf("Rex %d", i), "", petID, 1)
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case err == nil:
+			updated++
+		case assert.ErrorIs(t, err, myerrors.ErrPetVersion):
+			conflicts++
+		}
+	})
+	assert.Equal(t, 1, updated)
+	assert.Equal(t, workers-1, conflicts)
+
+	// Питомца резервирует только один из одновременных заказов
+	userID := createUser(t, s, "buyer")
+	var placed int
+	parallel(workers, func(i int) {
+		_, err := s.Order.CreateOrder(ctx, models.Order{PetID: petID, UserID: userID, Quantity: 1, Status: "placed"})
+		mu.Lock()
+		defer mu.Unlock()
+		if err == nil {
+			placed++
+		} else {
+			assert.ErrorIs(t, err, myerrors.ErrPetNotAvailable)
+		}
+	})
+	assert.Equal(t, 1, placed)
+
+	// Из одновременных регистраций одного имени проходит одна
+	var registered int
+	parallel(workers, func(i int) {
+		user := newUser("twin")
+		user.Email = types.NewNullString(fmt.Sprintf("twin%d@example.com", i))
+		_, err := s.User.Create(ctx, user)
+		mu.Lock()
+		defer mu.Unlock()
+		if err == nil {
+			registered++
+		}
+	})
+	assert.Equal(t, 1, registered)
+
+	// Токен отзывает только один из одновременных запросов, остальные узнают, что он уже отозван
+	var revoked int
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	parallel(workers, func(i int) {
+		ok, err := s.Token.Revoke(ctx, "refresh-jti", expiresAt)
+		mu.Lock()
+		defer mu.Unlock()
+		if assert.NoError(t, err) && ok {
+			revoked++
+		}
+	})
+	assert.Equal(t, 1, revoked)
+}
+
+// parallel - запускает fn(0..n-1) одновременно и ждёт завершения всех вызовов
+func parallel(n int, fn func(i int)) {
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			fn(i)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+}
+
+func createCategory(t *testing.T, s *storages.Storages, name string) int {
+	id, err := s.Category.CreateCategory(context.Background(), name)
+	require.NoError(t, err)
+	return id
+}
+
+func createUser(t *testing.T, s *storages.Storages, username string) int {
+	id, err := s.User.Create(context.Background(), newUser(username))
+	require.NoError(t, err)
+	return id
+}
+
+func newUser(username string) models.UserDTO {
+	return models.UserDTO{
+		UserName: types.NewNullString(username),
+		Email:    types.NewNullString(username + "@example.com"),
+		Password: types.NewNullString("hash"),
+	}
+}
+
+// addPet - доступный питомец категории Dogs с тегами tags
+func addPet(t *testing.T, s *storages.Storages, name string, tags ...string) int {
+	pet := &models.Pet{Name: name, Category: models.Category{Name: "Dogs"}, Status: models.PetStatusAvailable}
+	for _, tag := range tags {
+		pet.Tags = append(pet.Tags, models.Tag{Name: tag})
+	}
+	require.NoError(t, s.Pet.AddPet(context.Background(), pet))
+	return pet.ID
+}
+
+// tagIDs - id всех тегов по именам
+func tagIDs(t *testing.T, s *storages.Storages) map[string]int {
+	list, err := s.Tag.ListTags(context.Background())
+	require.NoError(t, err)
+	ids := make(map[string]int, len(list))
+	for _, tag := range list {
+		ids[tag.Name] = tag.ID
+	}
+	return ids
+}
+
+func tagNames(tags []models.Tag) []string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return names
+}
+
+func petIDs(pets []models.Pet) []int {
+	ids := make([]int, len(pets))
+	for i, pet := range pets {
+		ids[i] = pet.ID
+	}
+	return ids
+}
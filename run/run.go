@@ -7,6 +7,7 @@ import (
 	"os"
 	"pet-store/config"
 	"pet-store/internal/db"
+	"pet-store/internal/infrastructure/blobstore"
 	"pet-store/internal/infrastructure/component"
 	migrations "pet-store/internal/infrastructure/db/migrate"
 	"pet-store/internal/infrastructure/errors"
@@ -14,8 +15,11 @@ import (
 	"pet-store/internal/infrastructure/router"
 	"pet-store/internal/infrastructure/server"
 	"pet-store/internal/infrastructure/tools/cryptography"
+	"pet-store/internal/middleware"
+	"pet-store/internal/models"
 	"pet-store/internal/modules"
 	"pet-store/internal/storages"
+	"time"
 
 	jsoniter "github.com/json-iterator/go"
 	"golang.org/x/sync/errgroup"
@@ -24,6 +28,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// purgeInterval - период очистки мягко удалённых питомцев
+const purgeInterval = 24 * time.Hour
+
 // App - структура приложения
 type App struct {
 	conf     config.AppConf
@@ -60,6 +67,20 @@ func (a *App) Run() int {
 		return nil
 	})
 
+	// периодически удаляем питомцев, срок хранения которых после удаления истёк
+	errGroup.Go(func() error {
+		ticker := time.NewTicker(purgeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				a.Servises.Pet.PurgeDeletedPets(ctx)
+			}
+		}
+	})
+
 	errGroup.Go(func() error {
 		err := a.srv.Serve(ctx)
 		if err != nil && err != http.ErrServerClosed {
@@ -101,17 +122,29 @@ func (a *App) Bootstrap(options ...interface{}) Runner {
 		a.logger.Fatal("error init db", zap.Error(err))
 	}
 
+	// инициализация хранилища изображений
+	blobs, err := blobstore.NewLocalStorage(a.conf.Pet.ImagesDir)
+	if err != nil {
+		a.logger.Fatal("error init blob storage", zap.Error(err))
+	}
+
 	// инициализация хранилищ
-	newStorages := storages.NewStorages(sqlAdapter)
+	newStorages := storages.NewStorages(a.conf.DB.Driver, sqlAdapter, blobs)
 	a.Storages = newStorages
 	// инициализация сервисов
 	services := modules.NewServices(newStorages, components)
 	a.Servises = services
 	controllers := modules.NewControllers(services, components)
+	// инициализация middleware проверки токенов
+	tokenMiddleware := middleware.NewTokenManager(responseManager, tokenManager, services.Auth)
 	// инициализация роутера
-	r := router.NewRouter(controllers, components)
-	//Применение миграций если они ещё не применены
-	migrations.MigrationInit(a.conf, a.logger)
+	r := router.NewRouter(controllers, components, tokenMiddleware)
+	//Применение миграций, которые ещё не применены, если это не отключено в DB_AUTO_MIGRATE
+	if a.conf.DB.Driver != db.DriverMemory && a.conf.DB.AutoMigrate {
+		migrations.MigrationInit(a.conf, a.logger)
+	}
+	// назначение первого администратора из ADMIN_USERNAME
+	a.promoteAdmin(context.Background())
 	// конфигурация сервера
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%s", a.conf.Server.Port),
@@ -122,3 +155,23 @@ func (a *App) Bootstrap(options ...interface{}) Runner {
 	// возвращаем приложение
 	return a
 }
+
+// promoteAdmin - назначает роль администратора пользователю из ADMIN_USERNAME. Назначать роли может
+// только администратор, поэтому первого назначаем так. Пользователь должен быть уже зарегистрирован:
+// если его ещё нет, после регистрации сервер нужно перезапустить
+func (a *App) promoteAdmin(ctx context.Context) {
+	if a.conf.AdminUsername == "" {
+		return
+	}
+
+	err := a.Storages.User.UpdateRole(ctx, a.conf.AdminUsername, models.RoleAdmin)
+	if err == errors.ErrUserNotFound {
+		a.logger.Warn("admin user is not registered yet", zap.String("username", a.conf.AdminUsername))
+		return
+	}
+	if err != nil {
+		a.logger.Error("error promote admin", zap.Error(err))
+		return
+	}
+	a.logger.Info("admin role granted", zap.String("username", a.conf.AdminUsername))
+}